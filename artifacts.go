@@ -0,0 +1,66 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/JasonPodgorny/terraformDecodeTest/pkg/decodecheck"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// writeMergedArtifacts deep merges the files of report in each directory depth
+// levels below the roots, of the decoders in types, and writes each merged
+// value to dir as canonical JSON, sorted keys with two space indents, at
+// dir/envs/prod.json for envs/prod.   Type conflicts are logged and fail the
+// run before anything is written, so a half written set of artifacts is never
+// left behind.
+func writeMergedArtifacts(dir string, depth int, types []string, opts decodecheck.Options, report *decodecheck.Report) {
+	merged, err := decodecheck.NewRunner(opts).MergeDirectories(report, depth, types)
+	if err != nil {
+		fatalf("Cannot Merge Artifacts: %v", err)
+	}
+	dirs := make([]string, 0, len(merged))
+	conflicts := 0
+	for name, rep := range merged {
+		dirs = append(dirs, name)
+		for _, conflict := range rep.Conflicts {
+			logEvent(slog.LevelError, "merge-conflict", fmt.Sprintf("type conflict merging %s: %s", name, conflict), "dir", name, "key", conflict.Path,
+				"file", conflict.File, "kind", conflict.Kind, "previousFile", conflict.PreviousFile, "previousKind", conflict.PreviousKind)
+			conflicts++
+		}
+	}
+	if conflicts > 0 {
+		fatalf("%d Type Conflicts Found Merging Artifacts, None Written", conflicts)
+	}
+	sort.Strings(dirs)
+
+	for _, name := range dirs {
+		value, err := ctyjson.SimpleJSONValue{Value: merged[name].Value}.MarshalJSON()
+		if err != nil {
+			fatalf("Cannot Encode Artifact For %s: %v", name, err)
+		}
+		var canonical bytes.Buffer
+		if err := json.Indent(&canonical, value, "", "  "); err != nil {
+			fatalf("Cannot Encode Artifact For %s: %v", name, err)
+		}
+		canonical.WriteByte('\n')
+
+		target := filepath.Join(dir, filepath.FromSlash(name)+".json")
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			fatalf("Cannot Write Artifact %s: %v", target, err)
+		}
+		if err := os.WriteFile(target, canonical.Bytes(), 0o644); err != nil {
+			fatalf("Cannot Write Artifact %s: %v", target, err)
+		}
+		logEvent(slog.LevelDebug, "merge", fmt.Sprintf("Merged %d Files Under %s Into %s", len(merged[name].Files), name, target), "dir", name, "files", len(merged[name].Files), "artifact", target)
+	}
+	logf("merge", "Wrote %d Merged Artifacts To %s", len(dirs), dir)
+}
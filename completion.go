@@ -0,0 +1,202 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/JasonPodgorny/terraformDecodeTest/pkg/decodecheck"
+)
+
+// completionShells are the shells completion scripts can be written for.
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// subcommands are the words decodeTest takes in place of a path.
+var subcommands = []string{"diff", "report", "version", "completion"}
+
+// completionFlag is a flag as the completion scripts offer it.
+type completionFlag struct {
+	name   string
+	usage  string
+	isBool bool
+	values []string // fixed set of values, files are completed when empty
+}
+
+// completionFlags returns every defined flag in name order, along with the
+// values of those taking one of a fixed set, decoder names for -stdin-format.
+func completionFlags(opts decodecheck.Options) []completionFlag {
+	values := map[string][]string{
+		"output":       {"text", "json"},
+		"log-format":   {"text", "json"},
+		"convert":      decodecheck.ConvertFormats,
+		"stdin-format": decodecheck.DecoderNames(opts.Decoders),
+	}
+	var flags []completionFlag
+	flag.VisitAll(func(f *flag.Flag) {
+		boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+		flags = append(flags, completionFlag{
+			name:   f.Name,
+			usage:  strings.Join(strings.Fields(f.Usage), " "),
+			isBool: ok && boolFlag.IsBoolFlag(),
+			values: values[f.Name],
+		})
+	})
+	sort.Slice(flags, func(i, j int) bool { return flags[i].name < flags[j].name })
+	return flags
+}
+
+// writeCompletion writes the completion script for shell to w, completing the
+// flags, the values of those with a fixed set, the subcommands and paths.
+func writeCompletion(w io.Writer, shell string, opts decodecheck.Options) error {
+	flags := completionFlags(opts)
+	switch shell {
+	case "bash":
+		writeBashCompletion(w, flags)
+	case "zsh":
+		writeZshCompletion(w, flags)
+	case "fish":
+		writeFishCompletion(w, flags)
+	case "powershell":
+		writePowerShellCompletion(w, flags)
+	default:
+		return fmt.Errorf("unknown shell %q, must be one of %s", shell, strings.Join(completionShells, ", "))
+	}
+	return nil
+}
+
+// writeBashCompletion writes a completion function for bash, which also
+// handles -flag=value since bash splits words at the =.
+func writeBashCompletion(w io.Writer, flags []completionFlag) {
+	var names, valueFlags []string
+	for _, f := range flags {
+		names = append(names, "-"+f.name)
+		if !f.isBool && f.values == nil {
+			valueFlags = append(valueFlags, "-"+f.name)
+		}
+	}
+
+	fmt.Fprintln(w, "# bash completion for decodeTest, load with: source <(decodeTest completion bash)")
+	fmt.Fprintln(w, "_decodeTest() {")
+	fmt.Fprintln(w, `    local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"`)
+	fmt.Fprintln(w, `    if [[ "$cur" == "=" ]]; then`)
+	fmt.Fprintln(w, `        cur=""`)
+	fmt.Fprintln(w, `    elif [[ "$prev" == "=" ]]; then`)
+	fmt.Fprintln(w, `        prev="${COMP_WORDS[COMP_CWORD-2]}"`)
+	fmt.Fprintln(w, `    fi`)
+	fmt.Fprintln(w, `    case "$prev" in`)
+	for _, f := range flags {
+		if f.values != nil {
+			fmt.Fprintf(w, "        -%s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", f.name, strings.Join(f.values, " "))
+		}
+	}
+	fmt.Fprintf(w, "        %s) COMPREPLY=($(compgen -f -- \"$cur\")); return ;;\n", strings.Join(valueFlags, "|"))
+	fmt.Fprintf(w, "        completion) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", strings.Join(completionShells, " "))
+	fmt.Fprintln(w, `    esac`)
+	fmt.Fprintln(w, `    if [[ "$cur" == -* ]]; then`)
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(names, " "))
+	fmt.Fprintln(w, `    else`)
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -W %q -- \"$cur\") $(compgen -f -- \"$cur\"))\n", strings.Join(subcommands, " "))
+	fmt.Fprintln(w, `    fi`)
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "complete -o filenames -F _decodeTest decodeTest")
+}
+
+// writeZshCompletion writes a completion function for zsh, describing each
+// flag with its help text.
+func writeZshCompletion(w io.Writer, flags []completionFlag) {
+	escape := strings.NewReplacer(`'`, `'\''`, `[`, `\[`, `]`, `\]`, `:`, `\:`)
+
+	fmt.Fprintln(w, "#compdef decodeTest")
+	fmt.Fprintln(w, "# zsh completion for decodeTest, load with: source <(decodeTest completion zsh)")
+	fmt.Fprintln(w, "_decodeTest_args() {")
+	fmt.Fprintln(w, `    if [[ ${words[CURRENT-1]} == completion ]]; then`)
+	fmt.Fprintf(w, "        _values shell %s\n", strings.Join(completionShells, " "))
+	fmt.Fprintln(w, `    else`)
+	fmt.Fprintf(w, "        _alternative 'commands:command:(%s)' 'files:path:_files'\n", strings.Join(subcommands, " "))
+	fmt.Fprintln(w, `    fi`)
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "_decodeTest() {")
+	fmt.Fprintln(w, "    _arguments \\")
+	for _, f := range flags {
+		switch {
+		case f.isBool:
+			fmt.Fprintf(w, "        '-%s[%s]' \\\n", f.name, escape.Replace(f.usage))
+		case f.values != nil:
+			fmt.Fprintf(w, "        '-%s=[%s]:%s:(%s)' \\\n", f.name, escape.Replace(f.usage), f.name, strings.Join(f.values, " "))
+		default:
+			fmt.Fprintf(w, "        '-%s=[%s]:%s:_files' \\\n", f.name, escape.Replace(f.usage), f.name)
+		}
+	}
+	fmt.Fprintln(w, "        '*:argument:_decodeTest_args'")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, `if [[ $zsh_eval_context[-1] == loadautofunc ]]; then`)
+	fmt.Fprintln(w, `    _decodeTest "$@"`)
+	fmt.Fprintln(w, "else")
+	fmt.Fprintln(w, "    compdef _decodeTest decodeTest")
+	fmt.Fprintln(w, "fi")
+}
+
+// writeFishCompletion writes completions for fish, which takes single dash
+// long flags as old style options.
+func writeFishCompletion(w io.Writer, flags []completionFlag) {
+	escape := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+	fmt.Fprintln(w, "# fish completion for decodeTest, load with: decodeTest completion fish | source")
+	fmt.Fprintln(w, "complete -c decodeTest -f")
+	fmt.Fprintf(w, "complete -c decodeTest -n '__fish_use_subcommand' -a '%s'\n", strings.Join(subcommands, " "))
+	fmt.Fprintf(w, "complete -c decodeTest -n '__fish_seen_subcommand_from completion' -a '%s'\n", strings.Join(completionShells, " "))
+	fmt.Fprintln(w, "complete -c decodeTest -n 'not __fish_seen_subcommand_from completion version' -F")
+	for _, f := range flags {
+		switch {
+		case f.isBool:
+			fmt.Fprintf(w, "complete -c decodeTest -o %s -d '%s'\n", f.name, escape.Replace(f.usage))
+		case f.values != nil:
+			fmt.Fprintf(w, "complete -c decodeTest -o %s -d '%s' -x -a '%s'\n", f.name, escape.Replace(f.usage), strings.Join(f.values, " "))
+		default:
+			fmt.Fprintf(w, "complete -c decodeTest -o %s -d '%s' -r -F\n", f.name, escape.Replace(f.usage))
+		}
+	}
+}
+
+// writePowerShellCompletion writes an argument completer for PowerShell,
+// which falls back to completing paths when it offers nothing.
+func writePowerShellCompletion(w io.Writer, flags []completionFlag) {
+	quote := func(values []string) string {
+		quoted := make([]string, len(values))
+		for i, value := range values {
+			quoted[i] = "'" + strings.ReplaceAll(value, "'", "''") + "'"
+		}
+		return strings.Join(quoted, ", ")
+	}
+	var names []string
+	for _, f := range flags {
+		names = append(names, "-"+f.name)
+	}
+
+	fmt.Fprintln(w, "# PowerShell completion for decodeTest, load with: decodeTest completion powershell | Out-String | Invoke-Expression")
+	fmt.Fprintln(w, "Register-ArgumentCompleter -Native -CommandName 'decodeTest', 'decodeTest.exe' -ScriptBlock {")
+	fmt.Fprintln(w, "    param($wordToComplete, $commandAst, $cursorPosition)")
+	fmt.Fprintln(w, "    $values = @{")
+	for _, f := range flags {
+		if f.values != nil {
+			fmt.Fprintf(w, "        '-%s' = @(%s)\n", f.name, quote(f.values))
+		}
+	}
+	fmt.Fprintf(w, "        'completion' = @(%s)\n", quote(completionShells))
+	fmt.Fprintln(w, "    }")
+	fmt.Fprintf(w, "    $flags = @(%s)\n", quote(names))
+	fmt.Fprintln(w, "    $words = @($commandAst.CommandElements | ForEach-Object { $_.ToString() })")
+	fmt.Fprintln(w, "    $prev = if ($wordToComplete) { $words[-2] } else { $words[-1] }")
+	fmt.Fprintln(w, "    $candidates = if ($values.ContainsKey($prev)) { $values[$prev] }")
+	fmt.Fprintln(w, "        elseif ($wordToComplete -like '-*') { $flags }")
+	fmt.Fprintf(w, "        elseif ($prev -eq $words[0]) { @(%s) }\n", quote(subcommands))
+	fmt.Fprintln(w, "    $candidates | Where-Object { $_ -like \"$wordToComplete*\" } | ForEach-Object {")
+	fmt.Fprintln(w, "        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)")
+	fmt.Fprintln(w, "    }")
+	fmt.Fprintln(w, "}")
+}
@@ -0,0 +1,193 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/JasonPodgorny/terraformDecodeTest/pkg/decodecheck"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	ctyyaml "github.com/zclconf/go-cty-yaml"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// envPrefix starts the environment variables read as flag defaults, followed by
+// the flag name in upper case with - as _, like DECODETEST_IO_CONCURRENCY.
+const envPrefix = "DECODETEST_"
+
+// applyEnv sets each flag that has a DECODETEST_* variable in the environment,
+// given as it would be on the command line, unless it is in skip.   It returns
+// skip with the flags it set added, for applyConfig.
+func applyEnv(skip map[string]bool) (map[string]bool, error) {
+	set := make(map[string]bool, len(skip))
+	for name := range skip {
+		set[name] = true
+	}
+
+	var err error
+	flag.VisitAll(func(f *flag.Flag) {
+		key := envPrefix + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+		value, ok := os.LookupEnv(key)
+		if !ok || skip[f.Name] || err != nil {
+			return
+		}
+		if setErr := flag.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("invalid %s: %v", key, setErr)
+			return
+		}
+		set[f.Name] = true
+	})
+	return set, err
+}
+
+// configNames are the config files looked for, in order, in the search path
+// and each directory above it up to the top of the repository.
+var configNames = []string{".decodetest.yaml", ".decodetest.yml", ".decodetest.hcl"}
+
+// findConfig returns the first config file in dir or the directories above it
+// up to the top of its git work tree, or only in dir outside a work tree.
+func findConfig(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	top, inRepo := decodecheck.WorkTreeTop(dir)
+	for current := dir; ; {
+		for _, name := range configNames {
+			if _, err := os.Stat(filepath.Join(current, name)); err == nil {
+				return filepath.Join(current, name), true
+			}
+		}
+		parent := filepath.Dir(current)
+		if !inRepo || current == top || parent == current {
+			return "", false
+		}
+		current = parent
+	}
+}
+
+// readConfig decodes the YAML or HCL config file name into its settings, keyed
+// by flag name.   HCL configs are attributes only, like a tfvars file.
+func readConfig(name string) (map[string]any, error) {
+	src, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var value cty.Value
+	if filepath.Ext(name) == ".hcl" {
+		file, diags := hclparse.NewParser().ParseHCL(src, name)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		attrs, diags := file.Body.JustAttributes()
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		values := make(map[string]cty.Value, len(attrs))
+		for key, attr := range attrs {
+			if values[key], diags = attr.Expr.Value(nil); diags.HasErrors() {
+				return nil, diags
+			}
+		}
+		value = cty.ObjectVal(values)
+	} else {
+		ty, err := ctyyaml.ImpliedType(src)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		if value, err = ctyyaml.Unmarshal(src, ty); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+	}
+
+	// Round Trip Through JSON To Get Plain Go Values
+	settings := map[string]any{}
+	if value.IsNull() {
+		return settings, nil
+	}
+	if !value.Type().IsObjectType() && !value.Type().IsMapType() {
+		return nil, fmt.Errorf("%s: expected a mapping of flag names to values", name)
+	}
+	buf, err := ctyjson.Marshal(value, value.Type())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	if err := json.Unmarshal(buf, &settings); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return settings, nil
+}
+
+// applyConfig sets each flag named in settings, unless it is in skip (set on
+// the command line, which takes precedence).   Lists set -matchpatterns style
+// flags as one comma separated value and repeat repeatable flags like
+// -decoder, which also accept a mapping of pattern to decoder.
+func applyConfig(name string, settings map[string]any, skip map[string]bool) error {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		f := flag.Lookup(key)
+		if f == nil || key == "config" {
+			return fmt.Errorf("%s: unknown setting %q", name, key)
+		}
+		if skip[key] {
+			continue
+		}
+
+		var values []string
+		switch value := settings[key].(type) {
+		case []any:
+			for _, item := range value {
+				values = append(values, configString(item))
+			}
+			if _, ok := f.Value.(*stringSlice); ok {
+				values = []string{strings.Join(values, ",")}
+			}
+		case map[string]any:
+			items := make([]string, 0, len(value))
+			for item := range value {
+				items = append(items, item)
+			}
+			sort.Strings(items)
+			for _, item := range items {
+				values = append(values, item+"="+configString(value[item]))
+			}
+		default:
+			values = []string{configString(value)}
+		}
+		for _, value := range values {
+			if err := flag.Set(key, value); err != nil {
+				return fmt.Errorf("%s: invalid %s: %v", name, key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// configString formats a config value the way it would be given as a flag.
+func configString(value any) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case nil:
+		return ""
+	}
+	buf, _ := json.Marshal(value)
+	return string(buf)
+}
@@ -0,0 +1,94 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/JasonPodgorny/terraformDecodeTest/pkg/decodecheck"
+)
+
+// csvColumns is the header row of a -csv export.
+var csvColumns = []string{"path", "extension", "type", "size", "status", "failed", "line", "column", "error", "warnings", "duration_ms", "cached"}
+
+// walkFailedStatus is the status of the rows -csv and -history add for the
+// paths the walk could not read.
+const walkFailedStatus = "walk-failed"
+
+// writeCSVReport writes one row for each file in report to name, with a header
+// row, tab separated when name ends in .tsv and comma separated otherwise, and
+// one for each path the walk could not read, failed when strict makes them
+// fail the run.   Errors and warnings are kept to one line each, so the rows
+// load into spreadsheets and BigQuery as they are.
+func writeCSVReport(name string, report *decodecheck.Report, strict bool) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(file)
+	if strings.EqualFold(filepath.Ext(name), ".tsv") {
+		w.Comma = '\t'
+	}
+
+	w.Write(csvColumns)
+	for _, result := range report.Results {
+		var message string
+		if result.Err != nil {
+			message = oneLine(result.Err.Error())
+		}
+		warnings := make([]string, len(result.Warnings))
+		for i, warning := range result.Warnings {
+			warnings[i] = oneLine(warning)
+		}
+		w.Write([]string{
+			result.Path,
+			strings.TrimPrefix(filepath.Ext(result.Path), "."),
+			result.Type,
+			strconv.FormatInt(result.Size, 10),
+			result.Status.String(),
+			strconv.FormatBool(result.Failed()),
+			strconv.Itoa(result.Line),
+			strconv.Itoa(result.Column),
+			message,
+			strings.Join(warnings, " | "),
+			strconv.FormatFloat(float64(result.Duration.Microseconds())/1000, 'f', 3, 64),
+			strconv.FormatBool(result.Cached),
+		})
+	}
+	for _, err := range report.WalkErrors {
+		w.Write([]string{walkErrorPath(err), "", "", "0", walkFailedStatus, strconv.FormatBool(strict), "0", "0", oneLine(err.Error()), "", "0.000", "false"})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// walkErrorPath returns the path a walk error was met at, or "" when it names
+// none.
+func walkErrorPath(err error) string {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Path
+	}
+	return ""
+}
+
+// oneLine joins the lines of a multi line message, like a list of schema
+// violations, with semicolons.
+func oneLine(message string) string {
+	lines := strings.Split(message, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.Join(lines, "; ")
+}
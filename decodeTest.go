@@ -10,28 +10,57 @@
 // It still continues to count the files and disk usage of them but in addition it attempts
 // To Decode These Files Using The go-cty library which is the same library and functions
 // That terraform itself is using for the jsondecode and yamldecode functions.
+// HCL files (terragrunt.hcl and terraform modules) are parsed with hclparse
+// The same way terraform and terragrunt load them.
 
 // The intended purpose of this is as a pre processor for the json and yaml files we are
 // Getting from end users inside of terragrunt.
 
+// The walking, decoding and counting lives in pkg/decodecheck so it can be embedded
+// In other tools, this command only handles flags and output.
+
 package main
 
 import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"io/fs"
 	"log"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
-	"sync"
+	"syscall"
+	"time"
 
-	ctyyaml "github.com/zclconf/go-cty-yaml"
-	"github.com/zclconf/go-cty/cty"
+	"github.com/JasonPodgorny/terraformDecodeTest/pkg/decodecheck"
 	"github.com/zclconf/go-cty/cty/function"
-	"github.com/zclconf/go-cty/cty/function/stdlib"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+	"google.golang.org/grpc"
 )
 
+// exitInterrupted is the exit code when a run is stopped by SIGINT or SIGTERM,
+// following the shell convention of 128 + SIGINT.
+const exitInterrupted = 130
+
+// exitTimedOut is the exit code when a run is stopped by -timeout, the code
+// timeout(1) exits with.
+const exitTimedOut = 124
+
 // Define a type named "stringSlice" as a slice of Strings
 type stringSlice []string
 
@@ -48,211 +77,2151 @@ func (ss *stringSlice) Set(value string) error {
 	return nil
 }
 
-type SafeCounter struct {
-	mu          sync.Mutex
-	nbytes      int64
-	fileCounts  map[string]int
-	errorCounts map[string]int
+// decoderFlag collects repeatable -decoder 'pattern=name' flags.   Names are
+// checked against the available decoders by check, once -plugin flags have
+// added theirs.
+type decoderFlag struct {
+	rules    []decodecheck.DecoderRule
+	decoders map[string]function.Function
+}
+
+func (df *decoderFlag) String() string {
+	rules := make([]string, len(df.rules))
+	for i, rule := range df.rules {
+		rules[i] = rule.Pattern + "=" + rule.Decoder
+	}
+	return strings.Join(rules, ", ")
 }
 
-func (sc *SafeCounter) AddBytes(size int64) {
-	sc.mu.Lock()
-	sc.nbytes += size
-	sc.mu.Unlock()
+func (df *decoderFlag) Set(value string) error {
+	sep := strings.LastIndex(value, "=")
+	if sep < 1 || sep == len(value)-1 {
+		return fmt.Errorf("expected pattern=decoder, got %q", value)
+	}
+	pattern := strings.TrimSpace(value[:sep])
+	decoder := strings.TrimSpace(value[sep+1:])
+	if err := decodecheck.ValidatePatterns([]string{pattern}); err != nil {
+		return err
+	}
+	df.rules = append(df.rules, decodecheck.DecoderRule{Pattern: pattern, Decoder: decoder})
+	return nil
 }
 
-func (sc *SafeCounter) AddFile(extension string) {
-	sc.mu.Lock()
-	sc.fileCounts["total"]++
-	sc.fileCounts[extension]++
-	sc.mu.Unlock()
+// check returns an error for the first rule naming an unknown decoder.
+func (df *decoderFlag) check() error {
+	for _, rule := range df.rules {
+		if _, ok := df.decoders[rule.Decoder]; !ok {
+			return fmt.Errorf("unknown decoder %q, must be one of %s", rule.Decoder, strings.Join(decodecheck.DecoderNames(df.decoders), ", "))
+		}
+	}
+	return nil
 }
 
-func (sc *SafeCounter) AddError(extension string) {
-	sc.mu.Lock()
-	sc.errorCounts["total"]++
-	sc.errorCounts[extension]++
-	sc.mu.Unlock()
+// pluginFlag collects repeatable -plugin 'name=command args' flags.   The
+// command is split on spaces, with no quoting.
+type pluginFlag []decodecheck.Plugin
+
+func (pf *pluginFlag) String() string {
+	plugins := make([]string, len(*pf))
+	for i, plugin := range *pf {
+		plugins[i] = plugin.Name + "=" + strings.Join(plugin.Command, " ")
+	}
+	return strings.Join(plugins, ", ")
 }
 
-// Print Overall file count and usage, YAML file and error count, JSON file and error count
-func (sc *SafeCounter) printFileCounts() {
-	log.Printf("%d total files  %.1f MB\n", sc.fileCounts["total"], float64(sc.nbytes)/1e6)
-	for extension, count := range sc.fileCounts {
-		if extension == "total" {
-			continue
+func (pf *pluginFlag) Set(value string) error {
+	name, command, ok := strings.Cut(value, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || len(strings.Fields(command)) == 0 {
+		return fmt.Errorf("expected name=command, got %q", value)
+	}
+	*pf = append(*pf, decodecheck.Plugin{Name: name, Command: strings.Fields(command)})
+	return nil
+}
+
+// schemaFlag collects repeatable -schema-map 'pattern=schema' flags, and -schema
+// flags applying to every file, compiling each schema as it is given.   With
+// wasm set it collects -wasm-map and -wasm flags, compiling WASM validators.
+type schemaFlag struct {
+	rules []decodecheck.CheckRule
+	all   bool
+	wasm  bool
+}
+
+func (sf *schemaFlag) String() string {
+	rules := make([]string, len(sf.rules))
+	for i, rule := range sf.rules {
+		rules[i] = rule.Name
+	}
+	return strings.Join(rules, ", ")
+}
+
+func (sf *schemaFlag) Set(value string) error {
+	pattern, schemaPath := "*", strings.TrimSpace(value)
+	if !sf.all {
+		sep := strings.LastIndex(value, "=")
+		if sep < 1 || sep == len(value)-1 {
+			if sf.wasm {
+				return fmt.Errorf("expected pattern=module, got %q", value)
+			}
+			return fmt.Errorf("expected pattern=schema, got %q", value)
+		}
+		pattern, schemaPath = strings.TrimSpace(value[:sep]), strings.TrimSpace(value[sep+1:])
+		if err := decodecheck.ValidatePatterns([]string{pattern}); err != nil {
+			return err
 		}
-		log.Printf("%d %s files, %d Decode Errors\n", count, extension, sc.errorCounts[extension])
 	}
+	newRule := decodecheck.SchemaRule
+	if sf.wasm {
+		newRule = decodecheck.WASMRule
+	}
+	rule, err := newRule(pattern, schemaPath)
+	if err != nil {
+		return err
+	}
+	sf.rules = append(sf.rules, rule)
+	return nil
 }
 
-func main() {
+// validateSpec is what -validate checks the files matching its pattern for,
+// any of a JSON Schema file, a Terraform type constraint and top level keys.
+type validateSpec struct {
+	Schema  string   `json:"schema"`
+	Type    string   `json:"type"`
+	Require []string `json:"require"`
+}
 
-	// Set Match Pattern Defaults, And Read From Flags For Overrides
-	var matchPatterns = stringSlice{"*.json", "*.yaml"}
-	flag.Var(&matchPatterns, "matchpatterns", "List of match patterns")
+// validateFlag collects repeatable -validate 'pattern={"schema": ...}' flags,
+// given in a config file as a mapping of patterns to specs, compiling the
+// checks of each spec as it is given.
+type validateFlag struct {
+	patterns []string
+	rules    []decodecheck.CheckRule
+}
 
-	// Set ExcludeDir Defaults, And Read From Flags For Overrides
-	var excludeDirs = stringSlice{".git", ".terragrunt-cache", "scripts"}
-	flag.Var(&excludeDirs, "excludedirs", "List of exclude dirs")
+func (vf *validateFlag) String() string {
+	return strings.Join(vf.patterns, ", ")
+}
 
-	// Check Flag For Path To Search, Set To Current Directory (.) If None Provided
-	pathPtr := flag.String("path", ".", "Path to search")
+func (vf *validateFlag) Set(value string) error {
+	pattern, specJSON, ok := strings.Cut(value, "=")
+	pattern = strings.TrimSpace(pattern)
+	if !ok || pattern == "" {
+		return fmt.Errorf("expected pattern={\"schema\": ..., \"type\": ..., \"require\": [...]}, got %q", value)
+	}
+	if err := decodecheck.ValidatePatterns([]string{pattern}); err != nil {
+		return err
+	}
+	var spec validateSpec
+	decoder := json.NewDecoder(strings.NewReader(specJSON))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&spec); err != nil {
+		return fmt.Errorf("%s: %v", pattern, err)
+	}
+	if spec.Schema == "" && spec.Type == "" && len(spec.Require) == 0 {
+		return fmt.Errorf("%s: expected at least one of schema, type or require", pattern)
+	}
 
-	flag.Parse()
-	extraArgs := flag.Args()
+	if spec.Schema != "" {
+		rule, err := decodecheck.SchemaRule(pattern, spec.Schema)
+		if err != nil {
+			return fmt.Errorf("%s: %v", pattern, err)
+		}
+		vf.rules = append(vf.rules, rule)
+	}
+	if spec.Type != "" {
+		rule, err := decodecheck.TypeRule(pattern, spec.Type)
+		if err != nil {
+			return fmt.Errorf("%s: %v", pattern, err)
+		}
+		vf.rules = append(vf.rules, rule)
+	}
+	if len(spec.Require) > 0 {
+		vf.rules = append(vf.rules, decodecheck.RequireRule(pattern, spec.Require))
+	}
+	vf.patterns = append(vf.patterns, pattern)
+	return nil
+}
+
+// repeatedFlag collects the values of a flag that can be given more than once.
+type repeatedFlag []string
+
+func (rf *repeatedFlag) String() string {
+	return strings.Join(*rf, ", ")
+}
+
+func (rf *repeatedFlag) Set(value string) error {
+	*rf = append(*rf, strings.TrimSpace(value))
+	return nil
+}
 
-	// If There Are Extra Arguments Beyond Flags, Inputs Were Formatted Improperly
-	if len(extraArgs) > 0 {
-		flag.PrintDefaults()
-		os.Exit(1)
+// severityFlag collects repeatable -severity 'category=level' flags into the
+// severity overrides, with level error or warning.
+type severityFlag map[string]decodecheck.Severity
+
+func (sf severityFlag) String() string {
+	severities := make([]string, 0, len(sf))
+	for category, severity := range sf {
+		severities = append(severities, category+"="+severity.String())
 	}
+	sort.Strings(severities)
+	return strings.Join(severities, ", ")
+}
 
-	// Initialize Safe Counter
-	counter := SafeCounter{
-		fileCounts:  map[string]int{"total": 0},
-		errorCounts: map[string]int{"total": 0},
+func (sf severityFlag) Set(value string) error {
+	category, level, ok := strings.Cut(value, "=")
+	category = strings.TrimSpace(category)
+	if !ok || category == "" {
+		return fmt.Errorf("expected category=error or category=warning, got %q", value)
 	}
+	var severity decodecheck.Severity
+	if err := severity.UnmarshalText([]byte(strings.TrimSpace(level))); err != nil {
+		return err
+	}
+	sf[category] = severity
+	return nil
+}
 
-	// Create Channels And WaitGroup
-	fileSizes := make(chan int64)
-	fileNames := make(chan string)
-	var n sync.WaitGroup
+// errorFilterFlag collects repeatable -ignore-error-map 'pattern=regex' flags,
+// and -ignore-error flags applying to every file, compiling each regular
+// expression as it is given.   The pattern ends at the first =, so the
+// expression may hold more.
+type errorFilterFlag struct {
+	filters []decodecheck.ErrorFilter
+	all     bool
+}
 
-	// Search Root Recursively
-	var roots = []string{*pathPtr}
-	for _, root := range roots {
-		n.Add(1)
-		go walkDir(root, matchPatterns, excludeDirs, &n, fileSizes, fileNames)
+func (ef *errorFilterFlag) String() string {
+	filters := make([]string, len(ef.filters))
+	for i, filter := range ef.filters {
+		filters[i] = filter.Pattern + "=" + filter.Regexp.String()
 	}
-	go func() {
-		n.Wait()
-		close(fileSizes)
-		close(fileNames)
-	}()
+	return strings.Join(filters, ", ")
+}
 
-loop:
-	for {
-		select {
-		case size, ok := <-fileSizes:
-			if !ok {
-				break loop // fileSizes was closed
-			}
+func (ef *errorFilterFlag) Set(value string) error {
+	pattern, expr := "*", value
+	if !ef.all {
+		var ok bool
+		pattern, expr, ok = strings.Cut(value, "=")
+		pattern = strings.TrimSpace(pattern)
+		if !ok || pattern == "" || expr == "" {
+			return fmt.Errorf("expected pattern=regex, got %q", value)
+		}
+		if err := decodecheck.ValidatePatterns([]string{pattern}); err != nil {
+			return err
+		}
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return err
+	}
+	ef.filters = append(ef.filters, decodecheck.ErrorFilter{Pattern: pattern, Regexp: re})
+	return nil
+}
 
-			// Add to Overall File Size Counter
-			counter.AddBytes(size)
+// filenameRuleFlag collects repeatable -filename-rule 'dir=regex' flags,
+// compiling each regular expression as it is given.   The directory pattern
+// ends at the first =, so the expression may hold more.
+type filenameRuleFlag []decodecheck.FilenameRule
 
-		case name, ok := <-fileNames:
-			if !ok {
-				break loop // fileNames was closed
-			}
+func (ff *filenameRuleFlag) String() string {
+	rules := make([]string, len(*ff))
+	for i, rule := range *ff {
+		rules[i] = rule.Dir + "=" + rule.Regexp.String()
+	}
+	return strings.Join(rules, ", ")
+}
+
+func (ff *filenameRuleFlag) Set(value string) error {
+	dir, expr, ok := strings.Cut(value, "=")
+	dir = strings.TrimSpace(dir)
+	if !ok || dir == "" || expr == "" {
+		return fmt.Errorf("expected dir=regex, got %q", value)
+	}
+	if err := decodecheck.ValidatePatterns([]string{dir}); err != nil {
+		return err
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return err
+	}
+	*ff = append(*ff, decodecheck.FilenameRule{Dir: dir, Regexp: re})
+	return nil
+}
 
-			// Add File Suffix To File Counter
-			fileSuffix := filepath.Ext(name)
-			counter.AddFile(fileSuffix)
+// aliasFlag collects repeatable -ext-alias 'ext=ext' flags into the extension
+// alias map, adding the leading dot where it was left off.
+type aliasFlag map[string]string
 
-			decodeSuccess := fileDecode(name)
-			if !decodeSuccess {
-				// Add File Suffix To Error Counter
-				counter.AddError(fileSuffix)
-			}
+func (af aliasFlag) String() string {
+	aliases := make([]string, 0, len(af))
+	for ext, alias := range af {
+		aliases = append(aliases, ext+"="+alias)
+	}
+	sort.Strings(aliases)
+	return strings.Join(aliases, ", ")
+}
+
+func (af aliasFlag) Set(value string) error {
+	ext, alias, ok := strings.Cut(value, "=")
+	ext, alias = strings.TrimSpace(ext), strings.TrimSpace(alias)
+	if !ok || strings.Trim(ext, ".") == "" || strings.Trim(alias, ".") == "" {
+		return fmt.Errorf("expected ext=ext, got %q", value)
+	}
+	af[strings.ToLower("."+strings.TrimPrefix(ext, "."))] = "." + strings.TrimPrefix(alias, ".")
+	return nil
+}
 
+// Print Overall file count and usage, then file, error, non-strict and warning counts per file type,
+// As A Table Unless Logging JSON
+func printFileCounts(counts *decodecheck.SafeCounter) {
+	if !jsonLog {
+		printCountsTable(log.Writer(), counts)
+		return
+	}
+	logEvent(slog.LevelInfo, "summary", fmt.Sprintf("%d total files  %.1f MB", counts.Files("total"), float64(counts.Bytes())/1e6),
+		"files", counts.Files("total"), "bytes", counts.Bytes(), "errors", counts.Errors("total"))
+	for _, fileType := range counts.Types() {
+		logEvent(slog.LevelInfo, "type-summary", fmt.Sprintf("%d %s files, %d Decode Errors", counts.Files(fileType), fileType, counts.Errors(fileType)),
+			"type", fileType, "files", counts.Files(fileType), "errors", counts.Errors(fileType))
+		if counts.NonStrictFiles(fileType) > 0 {
+			logf("type-summary", "%d %s files only decoded with relaxed syntax", counts.NonStrictFiles(fileType), fileType)
+		}
+		if counts.WarningFiles(fileType) > 0 {
+			logf("type-summary", "%d %s files with warnings", counts.WarningFiles(fileType), fileType)
 		}
 	}
-	counter.printFileCounts() // final totals
+}
 
-	// See If There Were Errors Decoding Any Files
-	// If No Errors, Log All Successful And Exit 0
-	// If Errors, Indicate Failure and Exit 1
-	if counter.errorCounts["total"] > 0 {
-		log.Fatalf("Decode Errors Found In Files")
-	} else {
-		log.Printf("All Files Decoded Successfully")
+// printDirectoryCounts logs the file, byte and error counts of each directory
+// in a -summary-depth breakdown, as a table unless logging JSON.
+func printDirectoryCounts(directories []decodecheck.DirectoryCounts) {
+	if len(directories) == 0 {
+		return
+	}
+	if !jsonLog {
+		printDirectoriesTable(log.Writer(), directories)
+		return
+	}
+	for _, dir := range directories {
+		logEvent(slog.LevelInfo, "directory-summary", fmt.Sprintf("%d files in %s  %.1f MB, %d Decode Errors", dir.Files, dir.Path, float64(dir.Bytes)/1e6, dir.Errors),
+			"file", dir.Path, "files", dir.Files, "bytes", dir.Bytes, "errors", dir.Errors, "warnings", dir.Warnings)
 	}
 }
 
-// walkDir recursively walks the file tree rooted at dir
-// and sends the size of each found file on fileSizes.
-func walkDir(dir string, matchPatterns stringSlice, excludeDirs stringSlice, n *sync.WaitGroup, fileSizes chan<- int64, fileNames chan<- string) {
-	defer n.Done()
+// writeConverted writes each file converted by -convert next to it with the
+// extension of format, or over it when it already has that extension, only
+// touching files whose content changes.   Unless write is set they are printed
+// to stdout instead, YAML documents separated by ---.
+func writeConverted(report *decodecheck.Report, format string, write bool) {
+	separate := false
+	for _, result := range report.Results {
+		if result.Converted == nil {
+			continue
+		}
+		if !write {
+			if separate && format == "yaml" {
+				fmt.Println("---")
+			}
+			os.Stdout.Write(result.Converted)
+			separate = true
+			continue
+		}
 
-	for _, entry := range dirents(dir) {
-		// If Entry Is Directory And Not In excludedDirs Recursively Walk It
-		if entry.IsDir() && contains(excludeDirs, entry.Name()) == false {
-			n.Add(1)
-			subdir := filepath.Join(dir, entry.Name())
-			go walkDir(subdir, matchPatterns, excludeDirs, n, fileSizes, fileNames)
+		target := result.Path
+		if ext := strings.ToLower(filepath.Ext(target)); ext != "."+format && !(format == "yaml" && ext == ".yml") {
+			target = strings.TrimSuffix(target, filepath.Ext(target)) + "." + format
+		}
+		if current, err := os.ReadFile(target); err == nil && bytes.Equal(current, result.Converted) {
+			continue
+		}
+		mode := fs.FileMode(0o644)
+		if info, err := os.Stat(result.Path); err == nil {
+			mode = info.Mode().Perm()
+		}
+		if err := os.WriteFile(target, result.Converted, mode); err != nil {
+			logEvent(slog.LevelError, "convert", fmt.Sprintf("error writing %s: %v", target, err), "file", result.Path, "target", target, "error", err.Error())
+			continue
+		}
+		if target == result.Path {
+			logEvent(slog.LevelInfo, "convert", fmt.Sprintf("normalized file %s", result.Path), "file", result.Path, "target", target)
 		} else {
-			// If Entry Is Not A Directory, Test For Pattern Match.   Exclude Files with Size 0
-			// Those don't need to be decoded.
-			for _, pattern := range matchPatterns {
-				if match, _ := filepath.Match(pattern, entry.Name()); match == true && entry.Size() > 0 {
-					fileSizes <- entry.Size()
-					fileNames <- filepath.Join(dir, entry.Name())
-				}
-			}
+			logEvent(slog.LevelInfo, "convert", fmt.Sprintf("converted file %s to %s", result.Path, target), "file", result.Path, "target", target)
 		}
 	}
 }
 
-var sema = make(chan struct{}, 20) // concurrency-limiting counting semaphore
+// printQueryResults writes the -query result of each file that has one to
+// stdout, strings unquoted like jq -r and other values as JSON, after the file
+// path unless only one file was checked.
+func printQueryResults(report *decodecheck.Report) {
+	withPath := len(report.Results) > 1
+	for _, result := range report.Results {
+		if result.QueryResult == "" {
+			continue
+		}
+		value := result.QueryResult
+		var text string
+		if json.Unmarshal([]byte(value), &text) == nil {
+			value = text
+		}
+		if withPath {
+			fmt.Printf("%s: %s\n", result.Path, value)
+		} else {
+			fmt.Println(value)
+		}
+	}
+}
 
-// dirents returns the entries of directory dir.
-func dirents(dir string) []os.FileInfo {
+// printIdentical logs each group of files that decoded to the same value.
+func printIdentical(report *decodecheck.Report) {
+	for _, group := range report.Identical() {
+		logEvent(slog.LevelInfo, "identical", fmt.Sprintf("identical content in %d files: %s", len(group.Paths), strings.Join(group.Paths, ", ")),
+			"files", group.Paths, "hash", group.Hash)
+	}
+}
 
-	sema <- struct{}{}        // acquire token
-	defer func() { <-sema }() // release token
+// printSizeHistogram logs how many files of each type fall in each size range,
+// as a table unless logging JSON.
+func printSizeHistogram(report *decodecheck.Report) {
+	histogram := report.SizeHistogram()
+	if !jsonLog {
+		printSizeTable(log.Writer(), histogram, report.Counts.Types())
+		return
+	}
+	for _, fileType := range append(report.Counts.Types(), "total") {
+		logEvent(slog.LevelInfo, "size-histogram", fmt.Sprintf("%s file sizes %v", fileType, histogram[fileType]),
+			"type", fileType, "buckets", decodecheck.SizeBuckets, "counts", histogram[fileType])
+	}
+}
 
-	f, err := os.Open(dir)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "decodeTest: %v\n", err)
-		return nil
+// printTiming logs the decode time statistics of report and its slowest files,
+// as a table unless logging JSON.
+func printTiming(report *decodecheck.Report, slowest int) {
+	timing := report.Timing()
+	logEvent(slog.LevelInfo, "timing", fmt.Sprintf("%d files decoded in %v, median %v, 95th percentile %v, slowest %v", timing.Files, timing.Total.Round(time.Microsecond), timing.P50.Round(time.Microsecond), timing.P95.Round(time.Microsecond), timing.Max.Round(time.Microsecond)),
+		"files", timing.Files, "totalMs", timing.Total.Milliseconds(), "p50Ms", timing.P50.Milliseconds(), "p95Ms", timing.P95.Milliseconds(), "maxMs", timing.Max.Milliseconds())
+	if slowest <= 0 {
+		return
+	}
+	results := report.Slowest(slowest)
+	if !jsonLog {
+		printSlowestTable(log.Writer(), results)
+		return
 	}
-	defer f.Close()
+	for _, result := range results {
+		logEvent(slog.LevelInfo, "slow-file", fmt.Sprintf("decoding file %s took %v", result.Path, result.Duration.Round(time.Microsecond)),
+			"file", result.Path, "type", result.Type, "bytes", result.Size, "durationMs", result.Duration.Milliseconds())
+	}
+}
 
-	entries, err := f.Readdir(0) // 0 => no limit; read all entries
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "decodeTest: %v\n", err)
-		// Don't return: Readdir may return partial results.
+// printBench logs how files moved through the scan for -bench: how fast they
+// were found, how fast they were checked, and how long walkers and decodes
+// waited on the main loop.
+func printBench(report *decodecheck.Report) {
+	pipeline := report.Pipeline
+	rate := func(n float64, d time.Duration) float64 {
+		if d <= 0 {
+			return 0
+		}
+		return n / d.Seconds()
+	}
+	checked, megabytes := len(report.Results), float64(report.Counts.Bytes())/1e6
+	logEvent(slog.LevelInfo, "bench", fmt.Sprintf("Found %d Files (%.1f MB) In %v, %.0f Files/s", pipeline.Found, megabytes, pipeline.Walked.Round(time.Millisecond), rate(float64(pipeline.Found), pipeline.Walked)),
+		"found", pipeline.Found, "walkMs", pipeline.Walked.Milliseconds(), "filesPerSecond", rate(float64(pipeline.Found), pipeline.Walked))
+	logEvent(slog.LevelInfo, "bench", fmt.Sprintf("Checked %d Files In %v, %.0f Files/s, %.1f MB/s", checked, pipeline.Elapsed.Round(time.Millisecond), rate(float64(checked), pipeline.Elapsed), rate(megabytes, pipeline.Elapsed)),
+		"checked", checked, "elapsedMs", pipeline.Elapsed.Milliseconds(), "filesPerSecond", rate(float64(checked), pipeline.Elapsed), "mbPerSecond", rate(megabytes, pipeline.Elapsed))
+	logEvent(slog.LevelInfo, "bench", fmt.Sprintf("Walkers Waited %v And Decodes %v On The Main Loop, At Most %d Of %d Queued", pipeline.SizeWait.Round(time.Microsecond), pipeline.ResultWait.Round(time.Microsecond), pipeline.MaxQueued, pipeline.Buffer),
+		"sizeWaitMs", pipeline.SizeWait.Milliseconds(), "resultWaitMs", pipeline.ResultWait.Milliseconds(), "maxQueued", pipeline.MaxQueued, "buffer", pipeline.Buffer)
+}
+
+// printSnapshots logs how many snapshots were recorded and updated.
+func printSnapshots(snapshots *decodecheck.Snapshots, dir string) {
+	if n := snapshots.Recorded(); n > 0 {
+		logf("snapshot", "Recorded %d New Snapshots In %s", n, dir)
+	}
+	if n := snapshots.Updated(); n > 0 {
+		logf("snapshot", "Updated %d Snapshots In %s", n, dir)
 	}
-	return entries
 }
 
-func fileDecode(filename string) bool {
+// printAliases logs how many paths -dedupe-links found to be links to files
+// already checked.
+func printAliases(report *decodecheck.Report) {
+	aliases := 0
+	for _, result := range report.Results {
+		aliases += len(result.Aliases)
+	}
+	if aliases > 0 {
+		logf("aliases", "%d Paths Were Links To Files Already Checked, Not Counted Again", aliases)
+	}
+}
 
-	sema <- struct{}{}        // acquire token
-	defer func() { <-sema }() // release token
+// printListing prints the files -list-only found as a table, with the decoder
+// that would check each and the patterns that picked them, followed by any
+// walk errors.
+func printListing(report *decodecheck.Report) {
+	rows := make([][]tableCell, 0, len(report.Results))
+	noDecoder := 0
+	for _, result := range report.Results {
+		decoder := tableCell{text: result.Type}
+		if result.Status == decodecheck.NoDecoder {
+			decoder = tableCell{text: "none (" + result.Type + ")", color: colorRed}
+			noDecoder++
+		}
+		rows = append(rows, []tableCell{{text: result.Path}, decoder, {text: result.Rule}, {text: result.Match}})
+	}
+	printTable(os.Stdout, []string{"FILE", "DECODER", "RULE", "MATCHED BY"}, rows)
+	for _, err := range report.WalkErrors {
+		printWalkError(err)
+	}
+	logf("list", "%d Files Would Be Checked, %d Without A Decoder", len(report.Results)-noDecoder, noDecoder)
+}
 
-	var decodeFuncs = map[string]function.Function{
-		".yaml": ctyyaml.YAMLDecodeFunc,
-		".json": stdlib.JSONDecodeFunc,
+// printBaselined logs how many failures the baseline accepted.
+func printBaselined(report *decodecheck.Report, baselinePath string) {
+	baselined := 0
+	for _, result := range report.Results {
+		if result.Baselined {
+			baselined++
+		}
 	}
+	if baselined > 0 {
+		logf("baseline", "%d Known Failures Accepted By Baseline %s", baselined, baselinePath)
+	}
+}
 
-	fileSuffix := filepath.Ext(filename)
-	decodeFunction, ok := decodeFuncs[fileSuffix]
-	if !ok {
-		log.Printf("No Decoder For File Type %s: %s", fileSuffix, filename)
-		return false
+// exitCompared logs how results differ from the -compare report at
+// previousPath and exits, failing only when there are new failures.
+func exitCompared(comparison decodecheck.ReportComparison, previousPath string, codes exitCodes) {
+	for _, result := range comparison.NewFailures {
+		logEvent(slog.LevelError, "new-failure", fmt.Sprintf("new failure %s", result.Location()), "file", result.Path)
+	}
+	for _, path := range comparison.Fixed {
+		logEvent(slog.LevelInfo, "fixed", colorize(colorGreen, fmt.Sprintf("fixed %s", path)), "file", path)
+	}
+	for _, path := range comparison.Unchecked {
+		logEvent(slog.LevelDebug, "unchecked", fmt.Sprintf("not checked, failed in %s: %s", previousPath, path), "file", path)
+	}
+	logEvent(slog.LevelInfo, "compare", fmt.Sprintf("Compared To %s: %d New Failures, %d Fixed, %d Still Failing, %d No Longer Checked", previousPath, len(comparison.NewFailures), len(comparison.Fixed), len(comparison.StillFailing), len(comparison.Unchecked)),
+		"newFailures", len(comparison.NewFailures), "fixed", len(comparison.Fixed), "stillFailing", len(comparison.StillFailing), "unchecked", len(comparison.Unchecked))
+
+	if len(comparison.NewFailures) > 0 {
+		logEvent(slog.LevelError, "exit", fmt.Sprintf("%d New Failures Since %s", len(comparison.NewFailures), previousPath), "code", codes["failed"])
+		exit(codes["failed"])
+	}
+	logEvent(slog.LevelInfo, "exit", colorize(colorGreen, fmt.Sprintf("No New Failures Since %s", previousPath)), "code", codes["clean"])
+	exit(codes["clean"])
+}
+
+// printCacheHits logs how many results were reused from the cache.
+func printCacheHits(report *decodecheck.Report) {
+	cached := 0
+	for _, result := range report.Results {
+		if result.Cached {
+			cached++
+		}
+	}
+	logf("cache", "%d files unchanged since last run, results reused from cache", cached)
+}
+
+// printWatchResult logs every file checked in watch mode, passes included
+// without -v.
+func printWatchResult(result decodecheck.Result) {
+	if result.Status == decodecheck.Passed {
+		logResult(slog.LevelInfo, fmt.Sprintf("decoded file %s", result.Path), result)
+		printValueInfo(result)
+		return
+	}
+	printResult(result)
+}
+
+// printValueInfo logs the type of the value a file decoded to with
+// -type-signatures, the summary of a terraform state or plan, and its shape
+// with -shape.
+func printValueInfo(result decodecheck.Result) {
+	if result.TypeSignature != "" {
+		logEvent(slog.LevelInfo, "type-signature", fmt.Sprintf("type of file %s: %s", result.Path, result.TypeSignature), "file", result.Path, "type", result.Type, "ctyType", result.TypeSignature)
+	}
+	if result.Terraform != nil {
+		logEvent(slog.LevelInfo, "terraform", fmt.Sprintf("terraform %s of file %s: %s", result.Terraform.Kind, result.Path, result.Terraform), "file", result.Path, "type", result.Type,
+			"kind", result.Terraform.Kind, "version", result.Terraform.Version, "terraformVersion", result.Terraform.TerraformVersion, "resources", result.Terraform.Resources, "changes", result.Terraform.Changes)
+	}
+	if result.Shape != nil {
+		logEvent(slog.LevelInfo, "shape", fmt.Sprintf("shape of file %s: %d keys, %d leaves, depth %d", result.Path, result.Shape.Keys, result.Shape.Leaves, result.Shape.Depth),
+			"file", result.Path, "type", result.Type, "keys", result.Shape.Keys, "leaves", result.Shape.Leaves, "depth", result.Shape.Depth)
+	}
+}
+
+// serve runs the HTTP server on addr until ctx is cancelled, then waits for
+// in flight requests to finish.
+func serve(ctx context.Context, addr string, opts decodecheck.Options) {
+	server := &http.Server{Addr: addr, Handler: decodecheck.NewHandler(opts)}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	logf("serve", "Serving On %s, Press Ctrl-C To Stop", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fatalf("Cannot Serve: %v", err)
 	}
+}
 
-	fileString, err := ioutil.ReadFile(filename)
+// runDaemon serves daemon on addr, a TCP address or unix: and a socket path,
+// running its scans until ctx is cancelled.
+func runDaemon(ctx context.Context, addr string, daemon *decodecheck.Daemon) {
+	network, address := "tcp", addr
+	if socket, ok := strings.CutPrefix(addr, "unix:"); ok {
+		network, address = "unix", socket
+		os.Remove(socket) // left behind by a daemon that was killed
+		atExit = append(atExit, func() { os.Remove(socket) })
+	}
+	listener, err := net.Listen(network, address)
 	if err != nil {
-		log.Printf("error reading file %s: %v", filename, err)
-		return false
+		fatalf("Cannot Listen On %s: %v", addr, err)
+	}
+
+	server := &http.Server{Handler: daemon.Handler()}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	go daemon.Run(ctx)
+
+	logf("serve", "Daemon Listening On %s, Press Ctrl-C To Stop", addr)
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fatalf("Cannot Serve: %v", err)
 	}
+}
+
+// serveMetrics serves metrics on /metrics at addr until ctx is cancelled.
+func serveMetrics(ctx context.Context, addr string, metrics *decodecheck.Metrics) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler())
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
 
-	ctyValues := []cty.Value{
-		cty.StringVal(string(fileString)),
+	logf("serve", "Serving Metrics On %s", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fatalf("Cannot Serve Metrics: %v", err)
 	}
+}
 
-	_, err = decodeFunction.Call(ctyValues)
+// serveGRPC runs the gRPC server on addr until ctx is cancelled, then waits for
+// in flight calls to finish.
+func serveGRPC(ctx context.Context, addr string, opts decodecheck.Options) {
+	listener, err := net.Listen("tcp", addr)
 	if err != nil {
-		log.Printf("error decoding file %s: %v", filename, err)
-		return false
+		fatalf("Cannot Serve: %v", err)
+	}
+	server := grpc.NewServer()
+	decodecheck.RegisterGRPC(server, opts)
+	go func() {
+		<-ctx.Done()
+		server.GracefulStop()
+	}()
+
+	logf("serve", "Serving gRPC On %s, Press Ctrl-C To Stop", listener.Addr())
+	if err := server.Serve(listener); err != nil {
+		fatalf("Cannot Serve: %v", err)
+	}
+}
+
+// saveCache writes cached results back, if caching is enabled.
+func saveCache(cache *decodecheck.Cache) {
+	if cache == nil {
+		return
+	}
+	if err := cache.Save(); err != nil {
+		logEvent(slog.LevelError, "cache", fmt.Sprintf("error saving cache: %v", err), "error", err.Error())
+	}
+}
+
+// printWalkError logs a directory or file that could not be read during the walk.
+func printWalkError(err error) {
+	logEvent(slog.LevelError, "walk-error", fmt.Sprintf("decodeTest: %v", err), "error", err.Error())
+}
+
+// printResult logs problems with a single file as soon as it has been checked,
+// and with -v the files that passed.
+func printResult(result decodecheck.Result) {
+	printValueInfo(result)
+	if result.Status == decodecheck.Passed && len(result.Warnings) == 0 {
+		logResult(slog.LevelDebug, fmt.Sprintf("decoded file %s", result.Path), result)
+		return
 	}
+	for _, warning := range result.Warnings {
+		logEvent(slog.LevelWarn, "warning", fmt.Sprintf("warning in file %s: %s", result.Path, warning), "file", result.Path, "type", result.Type, "warning", warning)
+	}
+	if result.Severity == decodecheck.SeverityWarning || result.Baselined {
+		return // Logged As A Warning Above, Or Accepted By The Baseline
+	}
+	switch result.Status {
+	case decodecheck.NonStrict:
+		logResult(slog.LevelWarn, fmt.Sprintf("non-strict JSON (comments or trailing commas) in file %s", result.Path), result)
+	case decodecheck.NoDecoder:
+		logResult(slog.LevelError, fmt.Sprintf("No Decoder For File Type %s: %s", result.Type, result.Path), result)
+	case decodecheck.ReadFailed:
+		logResult(slog.LevelError, fmt.Sprintf("error reading file %s: %v", result.Path, result.Err), result)
+	case decodecheck.DecodeFailed:
+		logResult(slog.LevelError, fmt.Sprintf("error decoding file %s: %v", result.Location(), result.Err), result)
+	case decodecheck.TooLarge:
+		logResult(slog.LevelError, fmt.Sprintf("file too large %s: %v", result.Path, result.Err), result)
+	case decodecheck.Invalid:
+		logResult(slog.LevelError, fmt.Sprintf("invalid file %s: %v", result.Location(), result.Err), result)
+	}
+}
+
+func main() {
+
+	// Restore Standard Log Timestamps, The CUE Evaluator Clears Them In Its init
+	log.SetFlags(log.LstdFlags)
+
+	// Start From The Library Defaults, Flags Override Them Below
+	opts := decodecheck.DefaultOptions()
+
+	// Set Match Pattern Defaults, And Read From Flags For Overrides
+	var matchPatterns = stringSlice(opts.MatchPatterns)
+	flag.Var(&matchPatterns, "matchpatterns", "List of match patterns, a pattern with a / matches the path from -path and ** matches any directories")
+
+	// Read Regular Expression Includes And Excludes From Flags, Matched Against The Path From -path
+	var matchRegex, excludeRegex stringSlice
+	flag.Var(&matchRegex, "matchregex", "List of regular expressions matched against the path from -path, files matching are also decoded (alone if -matchpatterns is not set)")
+	flag.Var(&excludeRegex, "excluderegex", "List of regular expressions matched against the path from -path, files matching are skipped")
+
+	// Read Exclude Patterns From Flags, Files Matching These Are Skipped Even If They Match Above
+	var excludePatterns stringSlice
+	flag.Var(&excludePatterns, "excludepatterns", "List of file patterns to skip, a pattern with a / matches the path from -path and ** matches any directories")
+
+	// Set ExcludeDir Defaults, And Read From Flags For Overrides
+	var excludeDirs = stringSlice(opts.ExcludeDirs)
+	flag.Var(&excludeDirs, "excludedirs", "List of exclude dirs")
+
+	// Check Flag For Following Symlinked Directories, Each Real Directory Is Walked Once
+	followSymlinksPtr := flag.Bool("follow-symlinks", false, "Walk into symlinked directories, skipping links to directories already searched")
+
+	// Check Flag For Checking Each Physical File Once, However Many Hard Links Or Symlinks Lead To It
+	dedupeLinksPtr := flag.Bool("dedupe-links", false, "Check each file once however many hard links or symlinks lead to it, listing the other paths as its aliases")
+
+	// Check Flag For Opening Archives And Checking The Files In Them
+	archivesPtr := flag.Bool("archives", false, "Open zip, tar and tar.gz files and check the files in them, shown as bundle.zip!envs/prod.yaml")
+
+	// Check Flag For Limiting How Deep The Walk Goes Below Each Path
+	maxDepthPtr := flag.Int("max-depth", 0, "Only search this many directory levels, 1 being the files directly in each path (0 = no limit)")
+
+	// Check Flags For File Size Limits, Oversized Files Are Skipped Unless Reported
+	minSizePtr := flag.String("min-size", "0", "Skip files smaller than this size, in bytes or with a KB, MB or GB suffix")
+	maxSizePtr := flag.String("max-size", "0", "Skip files larger than this size, in bytes or with a KB, MB or GB suffix (0 = no limit)")
+	reportOversizePtr := flag.Bool("report-oversize", false, "Report files over -max-size as errors instead of skipping them")
+	syntaxOnlyAbovePtr := flag.String("syntax-only-above", "0", "Only check the syntax of json and yaml files larger than this size, parsing them as they are read instead of loading them (0 = never)")
+
+	// Check Flag For Only Checking Recently Modified Files
+	newerThanPtr := flag.String("newer-than", "", "Only check files modified after this time, a duration ago (like 24h or 7d) or an RFC 3339 timestamp or date")
+
+	// Check Flags For Hidden Files And Directories, Beyond Those In -excludedirs
+	skipHiddenPtr := flag.Bool("skip-hidden", false, "Skip files and directories whose names start with a dot")
+	includeHiddenPtr := flag.Bool("include-hidden", false, "Walk hidden directories named in -excludedirs too, like .git and .terragrunt-cache")
+
+	// Read The Exit Code Policy From Flags
+	codes := defaultExitCodes()
+	flag.Var(codes, "exit-codes", "Exit codes for run outcomes as outcome=code, comma separated, outcomes over-budget, failed, walk-error, warnings and clean")
+	strictPtr := flag.Bool("strict", false, "Fail the run on warnings and on directories or files that cannot be read")
+
+	// Check Flags For How Much To Log, Quiet For CI Or Verbose To See What Passed And Why
+	quietPtr := flag.Bool("q", false, "Only log errors and the final summary")
+	noColorPtr := flag.Bool("no-color", false, "Do not color text logs, which are colored on a terminal unless NO_COLOR is set")
+	vPtr := flag.Bool("v", false, "Also log each file that passes, and each file or directory skipped with the reason")
+	vvPtr := flag.Bool("vv", false, "Log everything -v does, plus each directory walked and the decoder picked for each file")
+	verbosePtr := flag.Bool("verbose", false, "Same as -v")
+
+	// Read Pattern To Decoder Mappings From Flags, These Take Precedence Over The Defaults
+	decoderMappings := decoderFlag{decoders: opts.Decoders}
+	flag.Var(&decoderMappings, "decoder", "Map a file pattern to a decoder as pattern=decoder, repeatable (decoders: "+strings.Join(decodecheck.DecoderNames(opts.Decoders), ", ")+", or a -plugin)")
+
+	// Check Flag For External Decoders, Programs Given Each File On Stdin That Write Their Findings As JSON
+	var plugins pluginFlag
+	flag.Var(&plugins, "plugin", "Register an external decoder as name=command, repeatable, and map files to it with -decoder (see Plugins in the README)")
+
+	// Read Extension Aliases From Flags, Added To The Default yml=yaml
+	extAliases := aliasFlag(opts.ExtensionAliases)
+	flag.Var(&extAliases, "ext-alias", "Match files with one extension as another as ext=ext, repeatable (like yml=yaml)")
+	caseSensitiveExtPtr := flag.Bool("case-sensitive-ext", false, "Match file extensions case sensitively, so *.json no longer matches A.JSON")
+	ignorePathCasePtr := flag.Bool("ignore-path-case", runtime.GOOS == "windows", "Match patterns, regexes, decoder rules, -excludedirs and ignore files without regard to case, on by default on Windows")
+
+	// Read JSON Schemas From Flags, For All Files Or Mapped To Patterns
+	schemas := schemaFlag{all: true}
+	flag.Var(&schemas, "schema", "Validate decoded values against this JSON Schema (JSON or YAML), repeatable")
+	schemaMappings := schemaFlag{}
+	flag.Var(&schemaMappings, "schema-map", "Validate files matching a pattern against a JSON Schema as pattern=schema, repeatable")
+
+	// Read Validation Specs From Flags, Mapping Patterns To A Schema, Type Or Required Keys
+	validations := validateFlag{}
+	flag.Var(&validations, "validate", "Validate files matching a pattern against a spec as pattern={\"schema\": file, \"type\": constraint, \"require\": [keys]}, repeatable, usually a mapping in the config file")
+
+	// Read WASM Validator Modules From Flags, For All Files Or Mapped To Patterns
+	wasmChecks := schemaFlag{all: true, wasm: true}
+	flag.Var(&wasmChecks, "wasm", "Validate decoded values with this sandboxed WebAssembly (WASI) module, repeatable")
+	wasmMappings := schemaFlag{wasm: true}
+	flag.Var(&wasmMappings, "wasm-map", "Validate files matching a pattern with a sandboxed WebAssembly (WASI) module as pattern=module, repeatable")
+
+	// Check Flag For A Terraform Type Constraint Every Decoded Value Must Convert To
+	typePtr := flag.String("type", "", "Require decoded values to convert to this Terraform type constraint, like map(object({cidr=string}))")
+
+	// Check Flag For Top Level Keys Every Decoded Object Must Have
+	var required repeatedFlag
+	flag.Var(&required, "require", "Require decoded values to be objects with this top level key, repeatable")
+
+	// Check Flag For CEL Expressions Every Decoded Value Must Satisfy
+	var celExpressions repeatedFlag
+	flag.Var(&celExpressions, "cel", "Require a CEL expression over the decoded value doc and file path to be true, like 'has(doc.vpc) && doc.vpc.cidr.startsWith(\"10.\")', repeatable")
+
+	// Check Flag For Directories Of Rego Policies To Evaluate Decoded Values Against
+	var policyDirs repeatedFlag
+	flag.Var(&policyDirs, "policy-dir", "Evaluate decoded values against the deny, violation and warn rules of the Rego policies in this directory, repeatable")
+
+	// Check Flag For Assertions On The Values At Paths In Decoded Values
+	var assertions repeatedFlag
+	flag.Var(&assertions, "assert", "Require the value at a path to be of a kind or type as path=type, like vpc.cidr=string or subnets=list, repeatable")
+
+	// Check Flag For Paths Whose Values Must Differ Across Files, Like Account IDs And CIDR Blocks
+	var uniquePaths repeatedFlag
+	flag.Var(&uniquePaths, "unique", "Fail files with the same value at this path as another file, like account_id or vpc.cidr, repeatable")
+
+	// Check Flag For The Names Files In Matching Directories Must Have
+	var filenameRules filenameRuleFlag
+	flag.Var(&filenameRules, "filename-rule", "Fail files in directories matching a pattern whose names do not match a regular expression as dir=regex, like 'envs/*=inputs-[a-z0-9-]+\\.yaml|terragrunt\\.hcl', repeatable")
+
+	// Check Flags For Object Keys Not Following A Naming Convention
+	checkKeyNamesPtr := flag.Bool("check-key-names", false, "Fail documents with object keys, at any depth, that do not follow -key-convention, naming the path to each")
+	keyConventionPtr := flag.String("key-convention", "snake_case", "Naming convention for -check-key-names: "+strings.Join(decodecheck.KeyConventionNames(), ", ")+", or regex:expr for keys matching a regular expression")
+
+	// Check Flag For Secrets Pasted Into Decoded Values
+	scanSecretsPtr := flag.Bool("scan-secrets", false, "Fail documents holding strings that look like secrets, like private keys, AWS access keys, tokens and other high-entropy strings")
+
+	// Check Flag For The Deepest Nesting Allowed In Decoded Values
+	maxNestingPtr := flag.Int("max-nesting", 0, "Fail documents whose decoded value nests objects and lists more than this many levels deep, 0 for no limit")
+
+	// Read The Decoded Value Budget From Flags, Independent Of File Size
+	maxElementsPtr := flag.Int("max-elements", 0, "Fail documents whose decoded value holds more than this many elements in all, 0 for no limit")
+	maxStringBytesPtr := flag.String("max-string-bytes", "0", "Fail documents whose decoded strings total more than this size, in bytes or with a KB, MB or GB suffix (0 = no limit)")
+
+	// Check Flag For A Terraform Module Whose Variables The Decoded Inputs Must Match
+	moduleVarsPtr := flag.String("module-vars", "", "Check decoded inputs set the variables declared by the Terraform module in this directory, with matching types")
+
+	// Check Flag For Honoring .gitignore Files While Walking
+	gitIgnorePtr := flag.Bool("gitignore", false, "Skip files and directories matched by .gitignore files, including those above -path in the same repository")
+
+	// Check Flag For .decodeignore Files, On By Default So Committed Exclusions Apply Everywhere
+	decodeIgnorePtr := flag.Bool("decodeignore", true, "Skip files and directories matched by .decodeignore files (gitignore syntax)")
+
+	// Check Flag For Path To Search, Set To Current Directory (.) If None Provided
+	pathPtr := flag.String("path", ".", "Path to search, when no paths are given as arguments")
+
+	// Check Flags For Concurrency, I/O And Decoding Are Limited Separately
+	concurrencyPtr := flag.Int("concurrency", 0, "Default for -io-concurrency and -decode-concurrency")
+	ioConcurrencyPtr := flag.Int("io-concurrency", 0, "Maximum directory reads and file reads in flight (0 = 4 x GOMAXPROCS)")
+	decodeConcurrencyPtr := flag.Int("decode-concurrency", 0, "Maximum file decodes in flight (0 = number of CPUs)")
+
+	// Read Severity Overrides From Flags, Making Findings Warnings That Do Not Fail Files
+	severities := severityFlag{}
+	flag.Var(severities, "severity", "Report findings of a category as category=warning or category=error, like no-decoder, yaml-lint, shape, unique, format, snapshot, sops, encoding, text, empty, terragrunt, filename, key-naming, cel, wasm, policy/warn, schema or schema/additionalProperties, repeatable")
+
+	// Check Flags For Known Errors To Report As Warnings, For All Files Or Mapped To Patterns
+	ignoreErrors := errorFilterFlag{all: true}
+	flag.Var(&ignoreErrors, "ignore-error", "Report failures whose error matches this regular expression as warnings, repeatable")
+	ignoreErrorMappings := errorFilterFlag{}
+	flag.Var(&ignoreErrorMappings, "ignore-error-map", "Report failures of files matching a pattern whose error matches a regular expression as warnings, as pattern=regex, repeatable")
+
+	// Check Flags For Stopping The Scan Early Once Enough Files Have Failed
+	failFastPtr := flag.Bool("fail-fast", false, "Stop scanning at the first file that fails, same as -max-errors 1")
+	maxErrorsPtr := flag.Int("max-errors", 0, "Stop scanning once this many files have failed (0 = no limit)")
+
+	// Check Flags For How Many Files And Bytes The Whole Scan May Take On, Protecting CI Runners From Huge Trees
+	maxFilesPtr := flag.Int("max-files", 0, "Abort the scan once it finds more than this many files to check, exiting 3 unless -exit-codes maps over-budget (0 = no limit)")
+	maxTotalBytesPtr := flag.String("max-total-bytes", "0", "Abort the scan once the files it finds to check total more than this size, in bytes or with a KB, MB or GB suffix, exiting 3 unless -exit-codes maps over-budget (0 = no limit)")
+
+	// Check Flag For Listing The Files A Scan Would Check, For Debugging Patterns And Excludes
+	listOnlyPtr := flag.Bool("list-only", false, "Walk and print the files that would be checked and the decoder for each without reading or decoding them, then exit")
+
+	// Check Flag For How Long The Whole Scan May Take
+	timeoutPtr := flag.Duration("timeout", 0, "Stop scanning after this long, like 10m, reporting the partial results and exiting 124 (0 = no limit)")
+
+	// Check Flag For How Long A Single File May Take To Decode
+	decodeTimeoutPtr := flag.Duration("decode-timeout", 0, "Fail files that take longer than this to decode, like 5s (0 = no limit)")
+
+	// Check Flags For Retrying Transient Errors, Which Network Filesystems Return Sporadically
+	retriesPtr := flag.Int("retries", opts.Retries, "Retry listing directories and reading files this many times when they fail with EIO, ESTALE, EAGAIN, EINTR or ETIMEDOUT")
+	retryBackoffPtr := flag.Duration("retry-backoff", opts.RetryBackoff, "How long to wait before the first retry, doubling for each retry after it")
+
+	// Check Flag For Relaxed JSON, Off By Default So Only Strict JSON Passes
+	relaxedJSONPtr := flag.Bool("relaxed-json", false, "Accept comments and trailing commas in JSON files, reporting them as non-strict")
+
+	// Check Flag For Failing JSON And YAML Files That Repeat A Key
+	strictKeysPtr := flag.Bool("strict-keys", false, "Fail JSON and YAML files that repeat a key in the same object, reporting both lines")
+
+	// Check Flag For Warning About YAML Values Read As A Different Type Than They Look
+	lintYAMLPtr := flag.Bool("lint-yaml", false, "Warn about unquoted YAML values read as a different type than they look, like no, 1.10 or 0123")
+
+	// Check Flag For Failing YAML Files That Rely On Anything Outside The YAML 1.2 Core Schema
+	yamlStrictPtr := flag.Bool("yaml-strict", false, "Fail YAML files using anything outside the YAML 1.2 core schema: other tags, << merge keys, YAML 1.1 values like yes, 1_000 or 2024-01-01, and base 60 numbers like 1:30")
+
+	// Check Flags For Problems With The Bytes Of Files That Break Templating Downstream
+	lintTextPtr := flag.Bool("lint-text", false, "Fail files with invalid UTF-8 or NUL bytes, naming the first")
+	lintCRLFPtr := flag.Bool("lint-crlf", false, "Warn about files with CRLF line endings")
+	lintFinalNewlinePtr := flag.Bool("lint-final-newline", false, "Warn about files that do not end with a newline")
+
+	// Check Flag For Empty And Null Documents, Which Usually Mean A Truncated Upload
+	checkEmptyPtr := flag.Bool("check-empty", false, "Fail empty files and files that decode to null or an empty object or list, like ---, null or {}")
+
+	// Check Flag For The Files Terragrunt Configs Load, Tying The Check To What Terragrunt Reads
+	terragruntRefsPtr := flag.Bool("terragrunt-refs", false, "Fail terragrunt.hcl files that load files with yamldecode(file(...)), jsondecode(file(...)) or read_terragrunt_config that are missing or do not decode")
+
+	// Read YAML Alias Expansion Limits From Flags, Guarding Against Billion Laughs Documents
+	yamlMaxAliasesPtr := flag.Int("yaml-max-aliases", opts.MaxYAMLAliases, "Fail YAML files with more aliases than this, 0 for no limit")
+	yamlMaxNodesPtr := flag.Int("yaml-max-nodes", opts.MaxYAMLNodes, "Fail YAML files that expand to more nodes than this once aliases are expanded, 0 for no limit")
+
+	// Check Flag For Multi Document YAML Streams
+	yamlMultiDocPtr := flag.Bool("yaml-multidoc", false, "Validate each --- separated document in YAML files")
+
+	// Check Flag For Markdown Front Matter Extraction
+	frontMatterPtr := flag.Bool("frontmatter", false, "Validate YAML front matter in markdown (*.md) files")
+
+	// Check Flag For Terraform State And Plan Files Archived Next To Inputs
+	terraformFilesPtr := flag.Bool("terraform-files", false, "Validate terraform state (*.tfstate, *.tfstate.backup) and plan JSON (*.tfplan.json, tfplan.json) files, logging their versions and resource counts")
+
+	// Check Flag For Jsonnet Rendering
+	jsonnetPtr := flag.Bool("jsonnet", false, "Render and validate *.jsonnet and *.libsonnet files with go-jsonnet")
+
+	// Check Flag For CUE Export, Which Requires CUE Files To Be Concrete
+	cueExportPtr := flag.Bool("cue-export", false, "Require CUE files to be concrete and validate their exported JSON")
 
-	return true
+	// Check Flags For CSV Delimiter And Header Row Handling
+	csvDelimiterPtr := flag.String("csv-delimiter", ",", "Field delimiter for CSV files (use \\t for tab)")
+	csvHeaderPtr := flag.Bool("csv-header", true, "Treat the first row of CSV files as a header row")
 
+	// Check Flags For A Baseline Of Accepted Failures, So Only New Failures Are Reported
+	baselinePtr := flag.String("baseline", "", "Only report failures not accepted by this baseline file, recording the current failures when it does not exist")
+	updateBaselinePtr := flag.Bool("update-baseline", false, "Rewrite the -baseline file with the current failures")
+
+	// Check Flag For A Previous JSON Report, Failing Only On Failures It Did Not Have
+	comparePtr := flag.String("compare", "", "Compare results to this JSON report from an earlier run, listing new failures and fixed files and only failing on new failures")
+
+	// Check Flags For Snapshots Of Decoded Values, To Catch Semantic Drift Even When Files Are Only Reformatted
+	snapshotDirPtr := flag.String("snapshot-dir", "", "Fail files whose decoded values changed since their snapshot in this directory, recording snapshots for files without one")
+	updateSnapshotsPtr := flag.Bool("update-snapshots", false, "Rewrite the snapshots in -snapshot-dir that differ instead of failing")
+
+	// Check Flag For Decrypting SOPS Files, Which Are Otherwise Only Reported
+	sopsDecryptPtr := flag.Bool("sops-decrypt", false, "Decrypt JSON, YAML and INI files encrypted by sops with the keys in the environment and check their values")
+
+	// Check Flags For The Result Cache, Off By Default So Every File Is Decoded
+	cachePtr := flag.Bool("cache", false, "Reuse results for files unchanged since the last cached run")
+	cacheFilePtr := flag.String("cache-file", "", "Path of the result cache, implies -cache (default results.json in the user cache dir)")
+
+	// Check Flag For Browsing Failures Interactively After The Scan
+	tuiPtr := flag.Bool("tui", false, "After the scan, browse the failed files in a terminal UI, showing each error in its file and checking files again after editing them")
+
+	// Check Flag For Running As A Terragrunt before_hook, With Terse Output And Details In A File
+	terragruntHookPtr := flag.Bool("terragrunt-hook", false, "Run as a terragrunt before_hook: one line per failed file, full details in a temporary file, exit 0 or 1, and a path inside .terragrunt-cache checks its unit")
+
+	// Check Flag For Watch Mode, Which Keeps Revalidating Files As They Change
+	watchPtr := flag.Bool("watch", false, "After the first run, keep watching for changed files and decode them until interrupted")
+
+	// Check Flag For Server Mode, Which Serves Checks Over HTTP Instead Of Scanning Once
+	servePtr := flag.String("serve", "", "Serve POST /validate and POST /scan over HTTP on this address (like :8080) instead of scanning")
+
+	// Check Flags For Daemon Mode, Which Queues Scans Requested By Other Processes
+	daemonPtr := flag.String("daemon", "", "Run as a daemon queueing scans requested over HTTP on this address (like :8080), or on a unix socket given as unix:/path/to.sock")
+	daemonScansPtr := flag.Int("daemon-scans", 2, "How many -daemon scans run at once, all sharing the concurrency limits")
+	daemonQueuePtr := flag.Int("daemon-queue", 100, "How many -daemon scans may wait to run before new ones are refused")
+
+	// Check Flag For gRPC Server Mode, Same Checks As -serve With Streamed Scan Results
+	grpcPtr := flag.String("grpc", "", "Serve the DecodeCheck gRPC service on this address (like :9090) instead of scanning")
+
+	// Check Flags For Prometheus Metrics, Scraped From Long Running Modes Or Pushed After A Scan
+	metricsPtr := flag.String("metrics", "", "Serve Prometheus metrics on /metrics at this address (like :9102), -serve also serves them on its own address")
+	pushgatewayPtr := flag.String("pushgateway", "", "Push Prometheus metrics of the scan to the Pushgateway at this URL when it completes")
+	pushgatewayJobPtr := flag.String("pushgateway-job", "decodeTest", "Job name metrics are grouped under in the Pushgateway")
+
+	// Check Flag For Language Server Mode, Talking LSP To An Editor Over Stdin And Stdout
+	lspPtr := flag.Bool("lsp", false, "Run as a Language Server Protocol server on stdin and stdout, publishing decode errors as diagnostics")
+
+	// Check Flag For Staged Mode, For Pre-Commit Hooks That Only Check What Is Being Committed
+	stagedPtr := flag.Bool("staged", false, "Only check files staged in git under -path, reading their staged content from the index")
+
+	// Check Flag For Changed Files Mode, For CI That Only Checks What A Branch Touches
+	changedSincePtr := flag.String("changed-since", "", "Only check files under -path changed since the merge base with this git ref (like origin/main)")
+
+	// Check Flags For Reading The Files To Check From A List, Skipping The Walk
+	filesFromPtr := flag.String("files-from", "", "Only check the files listed in this file, one per line (- for stdin)")
+	nulPtr := flag.Bool("0", false, "File lists from -files-from are NUL separated, like git diff -z or find -print0")
+
+	// Check Flag For Checking Exactly The Files A Manifest Promises, With The Expectations Of Each
+	manifestPtr := flag.String("manifest", "", "Check exactly the files listed in this YAML or JSON manifest, with the format, schema, type and required keys of each entry, failing listed files that are missing")
+
+	// Check Flags For Decoding A Document Piped On Stdin Instead Of Files
+	stdinPtr := flag.Bool("stdin", false, "Decode the document read from stdin instead of searching for files")
+	stdinFormatPtr := flag.String("stdin-format", "yaml", "Decoder for -stdin, like json or yaml (decoders: "+strings.Join(decodecheck.DecoderNames(opts.Decoders), ", ")+")")
+
+	// Check Flag For Deep Merging The Files Given As Arguments In Order, The Way Terragrunt Merges Layers
+	mergePtr := flag.Bool("merge", false, "Deep merge the files given as arguments in order like terragrunt, later files overriding earlier ones, print the merged value as JSON and fail on type conflicts between them")
+
+	// Check Flags For Writing One Deep Merged Artifact Per Environment Directory After A Clean Run
+	mergeOutPtr := flag.String("merge-out", "", "After a run where every file passes, deep merge the files under each environment directory and write each as canonical JSON to this directory, like out/prod.json")
+	mergeDepthPtr := flag.Int("merge-depth", 1, "How many levels below -path the environment directories -merge-out merges are")
+	mergeTypes := stringSlice{"json", "yaml", "jsonc", "toml"}
+	flag.Var(&mergeTypes, "merge-types", "List of the decoders whose files -merge-out merges")
+
+	// Check Flag For The Config File, Found At The Top Of The Repository By Default
+	configPtr := flag.String("config", "", "Config file of flag defaults, YAML or HCL (default .decodetest.yaml, .decodetest.yml or .decodetest.hcl above the search path, none to skip)")
+
+	// Check Flag For The Report Format, Printed To Stdout After The Run
+	outputPtr := flag.String("output", "text", "Report format: text logs, or json to also print the full report to stdout")
+
+	// Check Flags For Posting A Summary To A Slack Or Teams Webhook When The Scan Finishes
+	notifyWebhookPtr := flag.String("notify-webhook", "", "Post a summary of the scan to this Slack or Teams incoming webhook URL when it finishes")
+	notifyReportURLPtr := flag.String("notify-report-url", "", "Link to the full report in -notify-webhook messages and -run-task-result (default the CI job's URL, when known)")
+	notifyOnPtr := flag.String("notify-on", "always", "When to post to -notify-webhook: always, or failure for only runs that fail")
+
+	// Check Flag For A Standalone HTML Report, For CI Artifacts
+	htmlPtr := flag.String("html", "", "Also write the report to this file as a standalone HTML page, with charts of the errors by type and directory and a sortable file table")
+
+	// Check Flag For A SQLite Database Recording Every Run, For decodeTest report trends
+	historyPtr := flag.String("history", "", "Record each run and its per-file results in this SQLite database, which decodeTest report trends summarizes")
+
+	// Check Flag For A CSV Or TSV Export Of Every Result, For Spreadsheets And Data Warehouses
+	csvPtr := flag.String("csv", "", "Also write one row per file (path, extension, size, status, error, duration and more) to this file, tab separated if it ends in .tsv")
+
+	// Check Flags For A Markdown Comment And A Run Task Result, For Atlantis And Terraform Cloud
+	markdownPtr := flag.String("markdown", "", "Also write the report to this file, or - for stdout, as a Markdown comment with a collapsed section for each failed file")
+	runTaskResultPtr := flag.String("run-task-result", "", "Also write a Terraform Cloud run task result passing or failing the run to this file, or - for stdout, linking to -notify-report-url")
+
+	// Check Flags For Progress Reporting, Live On A Terminal Or Heartbeat Lines In CI
+	progressPtr := flag.Bool("progress", true, "Show progress on stderr while scanning, a live status line on a terminal or a line every -heartbeat otherwise")
+	heartbeatPtr := flag.Duration("heartbeat", 30*time.Second, "How often to log progress when stderr is not a terminal (0 = never)")
+
+	// Check Flag For Breaking The Summary Down By Directory, Like One Line Per Environment Folder
+	summaryDepthPtr := flag.Int("summary-depth", 0, "Break the summary down by directory this many levels below each path, 1 for each top level directory (0 = no breakdown)")
+
+	// Check Flag For Logging The Type Each File Decodes To, To Compare With The Variable Types Inputs Are Meant For
+	typeSignaturesPtr := flag.Bool("type-signatures", false, "Log the cty type of the value each file decodes to, like object({regions=tuple([string])})")
+
+	// Check Flags For Document Shape Metrics, And Limits Warning About Inputs Files That Have Grown Out Of Control
+	shapePtr := flag.Bool("shape", false, "Log the top level key count, leaf count and nesting depth of each file's value")
+	shapeMaxKeysPtr := flag.Int("shape-max-keys", 0, "Warn about files with more top level keys than this (0 = no limit)")
+	shapeMaxLeavesPtr := flag.Int("shape-max-leaves", 0, "Warn about files with more leaf values than this (0 = no limit)")
+	shapeMaxDepthPtr := flag.Int("shape-max-depth", 0, "Warn about files nested deeper than this (0 = no limit)")
+
+	// Check Flags For Failing Files Not In Canonical Format, Like terraform fmt -check, And Showing The Difference
+	checkFormatPtr := flag.Bool("check-format", false, "Fail JSON and YAML files that differ from their canonical form, keys in order with two space indents, see -convert to fix them")
+	diffPtr := flag.Bool("diff", false, "Print a unified diff to stdout for each file -check-format fails")
+
+	// Check Flags For Converting Decoded Files To JSON Or YAML With Keys In Order, Normalizing Them
+	convertPtr := flag.String("convert", "", "Convert each file that decodes without errors to json or yaml with keys in order and two space indents, needs -write or -stdout")
+	writePtr := flag.Bool("write", false, "Write -convert output next to each file with the new extension, or over it when the format is unchanged")
+	stdoutPtr := flag.Bool("stdout", false, "Print -convert output to stdout")
+
+	// Check Flag For Printing The Value At A Path In Each File, So Scripts Need Not Parse YAML Themselves
+	queryPtr := flag.String("query", "", "Print the value at this path in each decoded file to stdout, like .vpc.subnets[0].cidr, strings unquoted and other values as JSON")
+
+	// Check Flag For Finding Files That Decode To The Same Value, Like Copy Pasted Environment Configs
+	identicalPtr := flag.Bool("identical", false, "Log the groups of files that decode to the same value after the scan, ignoring formatting, key order and comments")
+
+	// Check Flag For A Histogram Of File Sizes, To Spot Multi-Megabyte Dumps Committed As Inputs
+	sizeHistogramPtr := flag.Bool("size-histogram", false, "Log how many files of each type fall in each size range after the scan")
+
+	// Check Flags For Decode Time Statistics And The Slowest Files, To Find What Dominates The Runtime
+	timingPtr := flag.Bool("timing", false, "Log the total, median and 95th percentile decode times after the scan")
+	benchPtr := flag.Bool("bench", false, "Log how fast files were found and checked after the scan, and how long walkers and decodes waited on the main loop, to check how a scan scales")
+	slowestPtr := flag.Int("slowest", 0, "List this many of the slowest files to decode after the scan, implies -timing")
+
+	// Check Flags For Profiling, So Slow Runs On Giant Trees Can Be Investigated Without Rebuilding
+	cpuProfilePtr := flag.String("cpuprofile", "", "Write a CPU profile of the run to this file, for go tool pprof")
+	memProfilePtr := flag.String("memprofile", "", "Write a heap profile to this file when the run ends, for go tool pprof")
+	tracePtr := flag.String("trace", "", "Write an execution trace of the run to this file, for go tool trace")
+	pprofAddrPtr := flag.String("pprof-addr", "", "Serve the net/http/pprof endpoints on this address (like localhost:6060), for profiling -serve and -watch while they run")
+
+	// Check Flag For Logging Results As Files Complete, Rather Than Sorted By Path After The Scan
+	streamPtr := flag.Bool("stream", false, "Log each file's result as soon as it is checked, in completion order, instead of sorted by path after the scan")
+
+	// Check Flag For The Log Format, JSON Lines Let Log Aggregation Index Failures Per File
+	logFormatPtr := flag.String("log-format", "text", "Log format on stderr: text, or json for one JSON object per line with time, level, event, file and error")
+
+	// Check Flag For Printing The Version And Supported Features
+	versionPtr := flag.Bool("version", false, "Print the version, commit, dependency versions, decoders and output formats as JSON and exit, same as the version command")
+
+	flag.Parse()
+
+	// decodeTest version Or -version Describes The Binary, Before Any Config Is Read
+	if *versionPtr || (flag.NArg() == 1 && flag.Arg(0) == "version") {
+		printJSON(getBuildInfo(opts))
+		return
+	}
+
+	// decodeTest completion <shell> Writes A Completion Script For The Flags Defined Above
+	if flag.Arg(0) == "completion" {
+		if flag.NArg() != 2 {
+			fatalf("Usage: decodeTest completion bash|zsh|fish|powershell")
+		}
+		if err := writeCompletion(os.Stdout, flag.Arg(1), opts); err != nil {
+			fatalf("%v", err)
+		}
+		return
+	}
+
+	// decodeTest diff <before> <after> Compares Two Trees Instead Of Scanning, Flags May Follow The Command
+	diffTrees := flag.Arg(0) == "diff"
+	if diffTrees {
+		if err := flag.CommandLine.Parse(flag.Args()[1:]); err != nil {
+			fatalf("%v", err)
+		}
+		if flag.NArg() != 2 {
+			fatalf("Usage: decodeTest [flags] diff <before> <after>")
+		}
+	}
+
+	// decodeTest report trends Summarizes The -history Database Instead Of Scanning, Flags May Follow The Command
+	reportTrends := flag.Arg(0) == "report"
+	if reportTrends {
+		if err := flag.CommandLine.Parse(flag.Args()[1:]); err != nil {
+			fatalf("%v", err)
+		}
+		if flag.Arg(0) != "trends" {
+			fatalf("Usage: decodeTest -history runs.db report trends")
+		}
+		if err := flag.CommandLine.Parse(flag.Args()[1:]); err != nil {
+			fatalf("%v", err)
+		}
+		if flag.NArg() != 0 {
+			fatalf("Usage: decodeTest -history runs.db report trends")
+		}
+	}
+
+	// Load Defaults From DECODETEST_* Variables Then The Config File, Flags Given On The Command Line Win
+	commandLine := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		commandLine[f.Name] = true
+	})
+	fromEnv, err := applyEnv(commandLine)
+	if err != nil {
+		fatalf("Cannot Apply Environment: %v", err)
+	}
+	configPath := *configPtr
+	if configPath == "" {
+		searchPath := *pathPtr
+		if flag.NArg() > 0 {
+			searchPath = flag.Arg(0)
+		}
+		configPath, _ = findConfig(searchPath)
+	}
+	if configPath != "" && configPath != "none" {
+		settings, err := readConfig(configPath)
+		if err != nil {
+			fatalf("Cannot Read Config File: %v", err)
+		}
+		if err := applyConfig(configPath, settings, fromEnv); err != nil {
+			fatalf("Cannot Apply Config File: %v", err)
+		}
+	}
+	if err := setupLogging(*logFormatPtr, *quietPtr, *vPtr || *verbosePtr, *vvPtr, *noColorPtr); err != nil {
+		fatalf("%v", err)
+	}
+	if *outputPtr != "text" && *outputPtr != "json" {
+		fatalf("Unknown -output %q, Must Be text Or json", *outputPtr)
+	}
+	if reportTrends {
+		if *historyPtr == "" {
+			fatalf("decodeTest report trends Needs -history")
+		}
+		if err := printTrends(*historyPtr, *outputPtr); err != nil {
+			fatalf("Cannot Read History %s: %v", *historyPtr, err)
+		}
+		return
+	}
+	if err := decodecheck.RegisterPlugins(opts.Decoders, plugins); err != nil {
+		fatalf("Cannot Register Plugins: %v", err)
+	}
+	if err := decoderMappings.check(); err != nil {
+		fatalf("Invalid -decoder: %v", err)
+	}
+	if *notifyOnPtr != "always" && *notifyOnPtr != "failure" {
+		fatalf("Unknown -notify-on %q, Must Be always Or failure", *notifyOnPtr)
+	}
+	if *mergeOutPtr != "" && *mergeDepthPtr < 1 {
+		fatalf("Invalid -merge-depth %d, Environment Directories Are At Least 1 Level Below -path", *mergeDepthPtr)
+	}
+	if *tuiPtr {
+		if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+			fatalf("-tui Needs A Terminal")
+		}
+		if *outputPtr == "json" || *watchPtr {
+			fatalf("-tui Cannot Be Used With -output json Or -watch")
+		}
+	}
+
+	// Paths Given As Arguments Are All Searched, Otherwise Search -path
+	roots := flag.Args()
+	if len(roots) == 0 {
+		roots = []string{*pathPtr}
+	} else if commandLine["path"] {
+		fatalf("-path Cannot Be Used With Path Arguments")
+	}
+	if *terragruntHookPtr {
+		if *tuiPtr || *watchPtr {
+			fatalf("-terragrunt-hook Cannot Be Used With -tui Or -watch")
+		}
+		hookLog = true
+		roots = hookRoots(roots)
+	}
+
+	// Regular Expressions Alone Replace The Default Glob Patterns Rather Than Adding To Them
+	if len(matchRegex) > 0 && !flagSet("matchpatterns") {
+		matchPatterns = nil
+	}
+
+	// Swap In A Custom CSV Decoder When Not Using csvdecode Defaults
+	if *csvDelimiterPtr != "," || !*csvHeaderPtr {
+		comma, err := parseDelimiter(*csvDelimiterPtr)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		opts.Decoders["csv"] = decodecheck.NewCSVDecodeFunc(comma, *csvHeaderPtr)
+	}
+
+	// Swap In The Stream Decoder When YAML Files May Hold Multiple Documents
+	if *yamlMultiDocPtr {
+		opts.Decoders["yaml"] = decodecheck.YAMLStreamDecodeFunc
+		opts.StreamDecoders = append(opts.StreamDecoders, "yaml")
+	}
+
+	// Swap In The Exporting CUE Decoder When CUE Files Must Be Concrete
+	if *cueExportPtr {
+		opts.Decoders["cue"] = decodecheck.CUEExportFunc
+	}
+
+	// Add Rules For The Optional Markdown, Jsonnet And Terraform Decoders When Requested, Terraform Plans Ahead Of *.json
+	if *frontMatterPtr {
+		opts.Rules = append(opts.Rules, decodecheck.DecoderRule{Pattern: "*.md", Decoder: "frontmatter"})
+		if !contains(matchPatterns, "*.md") {
+			matchPatterns = append(matchPatterns, "*.md")
+		}
+	}
+	if *jsonnetPtr {
+		for _, pattern := range []string{"*.jsonnet", "*.libsonnet"} {
+			opts.Rules = append(opts.Rules, decodecheck.DecoderRule{Pattern: pattern, Decoder: "jsonnet"})
+			if !contains(matchPatterns, pattern) {
+				matchPatterns = append(matchPatterns, pattern)
+			}
+		}
+	}
+
+	if *terraformFilesPtr {
+		terraformRules := []decodecheck.DecoderRule{{Pattern: "*.tfstate", Decoder: "tfstate"}, {Pattern: "*.tfstate.backup", Decoder: "tfstate"}, {Pattern: "*.tfplan.json", Decoder: "tfplan-json"}, {Pattern: "tfplan.json", Decoder: "tfplan-json"}}
+		opts.Rules = append(terraformRules, opts.Rules...)
+		for _, rule := range terraformRules {
+			if !contains(matchPatterns, rule.Pattern) {
+				matchPatterns = append(matchPatterns, rule.Pattern)
+			}
+		}
+	}
+
+	// Put Mapped Patterns Ahead Of The Default Rules, And Match Files For Them
+	opts.Rules = append(decoderMappings.rules, opts.Rules...)
+	for _, rule := range decoderMappings.rules {
+		if !contains(matchPatterns, rule.Pattern) {
+			matchPatterns = append(matchPatterns, rule.Pattern)
+		}
+	}
+
+	// Search Root Recursively, Logging Problems As Each File Completes
+	opts.Roots = roots
+	opts.MatchPatterns = matchPatterns
+	if *skipHiddenPtr && *includeHiddenPtr {
+		fatalf("-skip-hidden And -include-hidden Cannot Be Used Together")
+	}
+	opts.ExcludeDirs = nil
+	for _, dir := range excludeDirs {
+		if !*includeHiddenPtr || !strings.HasPrefix(dir, ".") {
+			opts.ExcludeDirs = append(opts.ExcludeDirs, dir)
+		}
+	}
+	if *terragruntHookPtr && !contains(opts.ExcludeDirs, terragruntCache) {
+		opts.ExcludeDirs = append(opts.ExcludeDirs, terragruntCache) // Copies Of Files Checked Where They Live
+	}
+	opts.SkipHidden = *skipHiddenPtr
+	if logEnabled(slog.LevelDebug) {
+		opts.OnSkip = func(path, reason string) {
+			logEvent(slog.LevelDebug, "skipped", fmt.Sprintf("skipped %s: %s", path, reason), "file", path, "reason", reason)
+		}
+	}
+	if logEnabled(levelTrace) {
+		opts.OnDir = func(path string, entries int) {
+			logEvent(levelTrace, "directory", fmt.Sprintf("walking directory %s, %d entries", path, entries), "file", path, "entries", entries)
+		}
+		opts.OnDecoder = func(path, decoder, pattern string) {
+			logEvent(levelTrace, "decoder", fmt.Sprintf("decoding file %s as %s, matched by %s", path, decoder, pattern), "file", path, "type", decoder, "pattern", pattern)
+		}
+	}
+	opts.FollowSymlinks = *followSymlinksPtr
+	opts.DedupeLinks = *dedupeLinksPtr
+	opts.Archives = *archivesPtr
+	opts.MaxDepth = *maxDepthPtr
+	for _, size := range []struct {
+		flag  string
+		value string
+		opt   *int64
+	}{{"min-size", *minSizePtr, &opts.MinSize}, {"max-size", *maxSizePtr, &opts.MaxSize}, {"syntax-only-above", *syntaxOnlyAbovePtr, &opts.SyntaxOnlyAbove}} {
+		bytes, err := parseSize(size.value)
+		if err != nil {
+			fatalf("Invalid -%s: %v", size.flag, err)
+		}
+		*size.opt = bytes
+	}
+	opts.ReportOversize = *reportOversizePtr
+	if *newerThanPtr != "" {
+		newerThan, err := parseNewerThan(*newerThanPtr, time.Now())
+		if err != nil {
+			fatalf("Invalid -newer-than: %v", err)
+		}
+		opts.NewerThan = newerThan
+	}
+	for _, patterns := range [][]string{matchPatterns, excludePatterns} {
+		if err := decodecheck.ValidatePatterns(patterns); err != nil {
+			fatalf("%v", err)
+		}
+	}
+	for _, exprs := range [][]string{matchRegex, excludeRegex} {
+		if err := decodecheck.ValidateRegexps(exprs); err != nil {
+			fatalf("%v", err)
+		}
+	}
+	opts.MatchRegex = matchRegex
+	opts.ExcludeRegex = excludeRegex
+	opts.ExcludePatterns = excludePatterns
+	opts.CaseSensitiveExtensions = *caseSensitiveExtPtr
+	opts.IgnorePathCase = *ignorePathCasePtr
+	if !*decodeIgnorePtr {
+		opts.IgnoreFiles = nil
+	}
+	if *gitIgnorePtr {
+		opts.IgnoreFiles = append(opts.IgnoreFiles, ".gitignore")
+	}
+	opts.Checks = append(schemas.rules, schemaMappings.rules...)
+	opts.Checks = append(opts.Checks, validations.rules...)
+	opts.Checks = append(opts.Checks, wasmChecks.rules...)
+	opts.Checks = append(opts.Checks, wasmMappings.rules...)
+	if *typePtr != "" {
+		rule, err := decodecheck.TypeRule("*", *typePtr)
+		if err != nil {
+			fatalf("Invalid -type: %v", err)
+		}
+		opts.Checks = append(opts.Checks, rule)
+	}
+	if len(required) > 0 {
+		opts.Checks = append(opts.Checks, decodecheck.RequireRule("*", required))
+	}
+	for _, assertion := range assertions {
+		rule, err := decodecheck.AssertRule("*", assertion)
+		if err != nil {
+			fatalf("Invalid -assert: %v", err)
+		}
+		opts.Checks = append(opts.Checks, rule)
+	}
+	for _, expression := range celExpressions {
+		rule, err := decodecheck.CELRule("*", expression)
+		if err != nil {
+			fatalf("Invalid -cel: %v", err)
+		}
+		opts.Checks = append(opts.Checks, rule)
+	}
+	for _, policyDir := range policyDirs {
+		rule, err := decodecheck.PolicyRule("*", policyDir)
+		if err != nil {
+			fatalf("Invalid -policy-dir: %v", err)
+		}
+		opts.Checks = append(opts.Checks, rule)
+	}
+	for _, uniquePath := range uniquePaths {
+		key, err := decodecheck.ParseValuePath(uniquePath)
+		if err != nil {
+			fatalf("Invalid -unique: %v", err)
+		}
+		opts.Unique = append(opts.Unique, key)
+	}
+	opts.CheckFormat = *checkFormatPtr
+	opts.SOPSDecrypt = *sopsDecryptPtr
+	if *snapshotDirPtr != "" {
+		opts.Snapshots = decodecheck.NewSnapshots(*snapshotDirPtr, *updateSnapshotsPtr)
+	} else if *updateSnapshotsPtr {
+		fatalf("-update-snapshots Requires -snapshot-dir")
+	}
+	if *diffPtr && !*checkFormatPtr {
+		fatalf("-diff Needs -check-format")
+	}
+	if *convertPtr != "" {
+		if !contains(decodecheck.ConvertFormats, *convertPtr) {
+			fatalf("Unknown -convert %q, Must Be One Of %s", *convertPtr, strings.Join(decodecheck.ConvertFormats, ", "))
+		}
+		if *writePtr == *stdoutPtr {
+			fatalf("-convert Needs One Of -write Or -stdout")
+		}
+		opts.Convert = *convertPtr
+	} else if *writePtr || *stdoutPtr {
+		fatalf("-write And -stdout Need -convert")
+	}
+	if *queryPtr != "" {
+		query, err := decodecheck.ParseValuePath(*queryPtr)
+		if err != nil {
+			fatalf("Invalid -query: %v", err)
+		}
+		opts.Query = &query
+	}
+	if *scanSecretsPtr {
+		opts.Checks = append(opts.Checks, decodecheck.SecretsRule("*"))
+	}
+	if *checkKeyNamesPtr {
+		rule, err := decodecheck.KeyNamingRule("*", *keyConventionPtr)
+		if err != nil {
+			fatalf("Invalid -key-convention: %v", err)
+		}
+		opts.Checks = append(opts.Checks, rule)
+	}
+	if *maxNestingPtr > 0 {
+		opts.Checks = append(opts.Checks, decodecheck.NestingRule("*", *maxNestingPtr))
+	}
+	maxStringBytes, err := parseSize(*maxStringBytesPtr)
+	if err != nil {
+		fatalf("Invalid -max-string-bytes: %v", err)
+	}
+	if *maxElementsPtr > 0 || maxStringBytes > 0 {
+		opts.Checks = append(opts.Checks, decodecheck.BudgetRule("*", *maxElementsPtr, maxStringBytes))
+	}
+	if *moduleVarsPtr != "" {
+		rule, err := decodecheck.VariablesRule("*", *moduleVarsPtr)
+		if err != nil {
+			fatalf("Invalid -module-vars: %v", err)
+		}
+		opts.Checks = append(opts.Checks, rule)
+	}
+	opts.RelaxedJSON = *relaxedJSONPtr
+	opts.StrictKeys = *strictKeysPtr
+	opts.LintYAML = *lintYAMLPtr
+	opts.StrictYAML = *yamlStrictPtr
+	opts.LintText = *lintTextPtr
+	opts.LintCRLF = *lintCRLFPtr
+	opts.LintFinalNewline = *lintFinalNewlinePtr
+	opts.CheckEmpty = *checkEmptyPtr
+	opts.TerragruntRefs = *terragruntRefsPtr
+	opts.MaxYAMLAliases = *yamlMaxAliasesPtr
+	opts.MaxYAMLNodes = *yamlMaxNodesPtr
+	opts.Concurrency = *concurrencyPtr
+	opts.IOConcurrency = *ioConcurrencyPtr
+	opts.DecodeConcurrency = *decodeConcurrencyPtr
+	opts.DecodeTimeout = *decodeTimeoutPtr
+	opts.Retries = *retriesPtr
+	opts.RetryBackoff = *retryBackoffPtr
+	opts.OnRetry = func(path string, retry int, err error) {
+		logEvent(slog.LevelWarn, "retry", fmt.Sprintf("Retrying %s (retry %d of %d): %v", path, retry, opts.Retries, err), "file", path, "retry", retry, "error", err.Error())
+	}
+	opts.MaxErrors = *maxErrorsPtr
+	opts.MaxFiles = *maxFilesPtr
+	if opts.MaxTotalBytes, err = parseSize(*maxTotalBytesPtr); err != nil {
+		fatalf("Invalid -max-total-bytes: %v", err)
+	}
+	opts.SummaryDepth = *summaryDepthPtr
+	opts.TypeSignatures = *typeSignaturesPtr
+	opts.Shapes = *shapePtr
+	opts.ValueHashes = *identicalPtr
+	opts.ShapeLimits = decodecheck.ShapeLimits{Keys: *shapeMaxKeysPtr, Leaves: *shapeMaxLeavesPtr, Depth: *shapeMaxDepthPtr}
+	opts.Severities = severities
+	opts.IgnoreErrors = append(ignoreErrors.filters, ignoreErrorMappings.filters...)
+	opts.FilenameRules = filenameRules
+	if *failFastPtr && opts.MaxErrors == 0 {
+		opts.MaxErrors = 1
+	}
+	opts.OnWalkError = printWalkError
+	if *streamPtr {
+		opts.OnResult = printResult
+	}
+
+	// Cancel The Walk On SIGINT Or SIGTERM, A Second Signal Kills The Process As Usual
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	defer runAtExit()
+	startProfiling(*cpuProfilePtr, *memProfilePtr, *tracePtr, *pprofAddrPtr)
+
+	// Export Spans Of The Scan Over OTLP When An Endpoint Is Configured, Flushed On Exit
+	if tracingConfigured() {
+		tracedCtx, shutdown, err := setupTracing(ctx)
+		if err != nil {
+			logEvent(slog.LevelWarn, "tracing", fmt.Sprintf("Cannot Export Traces: %v", err), "error", err.Error())
+		} else {
+			ctx = tracedCtx
+			atExit = append(atExit, shutdown)
+		}
+	}
+
+	// Fetch URL Roots Into A Temp Dir, Cloning Repositories And Downloading Files, Removed On Exit
+	if slices.ContainsFunc(roots, decodecheck.IsRemote) {
+		if *stagedPtr || *changedSincePtr != "" || *watchPtr {
+			fatalf("URL Paths Cannot Be Used With -staged, -changed-since Or -watch")
+		}
+		tempDir, err := os.MkdirTemp("", "decodeTest-")
+		if err != nil {
+			fatalf("Cannot Create Temp Dir: %v", err)
+		}
+		atExit = append(atExit, func() { os.RemoveAll(tempDir) })
+		for i, root := range roots {
+			if !decodecheck.IsRemote(root) {
+				continue
+			}
+			dir := filepath.Join(tempDir, strconv.Itoa(i))
+			if err := os.Mkdir(dir, 0o700); err != nil {
+				fatalf("Cannot Create Temp Dir: %v", err)
+			}
+			local, err := decodecheck.FetchRemote(ctx, root, dir)
+			if err != nil {
+				fatalf("Cannot Fetch %s: %v", root, err)
+			}
+			logf("fetch", "Fetched %s Into %s", root, local)
+			roots[i] = local
+		}
+		opts.Roots = roots
+	}
+
+	// Check Staged Content Instead Of Walking The Working Tree
+	if *stagedPtr {
+		if len(roots) > 1 {
+			fatalf("-staged Takes A Single Path")
+		}
+		fsys, files, err := decodecheck.StagedFiles(ctx, roots[0], opts.IgnoreFiles)
+		if err != nil {
+			fatalf("Cannot List Staged Files: %v", err)
+		}
+		opts.FS = fsys
+		opts.Files = files
+		opts.Roots = []string{"."}
+		if len(files) == 0 {
+			logf("no-files", "No Staged Files")
+		}
+	}
+
+	// Search s3:// Roots In Their Bucket Instead Of The OS Filesystem
+	if slices.ContainsFunc(roots, func(root string) bool { return strings.HasPrefix(root, decodecheck.S3Scheme) }) {
+		if *stagedPtr || *changedSincePtr != "" || *watchPtr {
+			fatalf("s3:// Paths Cannot Be Used With -staged, -changed-since Or -watch")
+		}
+		var bucket string
+		prefixes := make([]string, len(roots))
+		for i, root := range roots {
+			rootBucket, prefix, err := decodecheck.ParseS3URL(root)
+			if err != nil {
+				fatalf("Invalid Path: %v", err)
+			}
+			if bucket != "" && rootBucket != bucket {
+				fatalf("s3:// Paths Must All Be In The Same Bucket, Not %s And %s", bucket, rootBucket)
+			}
+			bucket, prefixes[i] = rootBucket, prefix
+		}
+		fsys, err := decodecheck.NewS3FS(ctx, bucket)
+		if err != nil {
+			fatalf("Cannot Read S3 Bucket: %v", err)
+		}
+		opts.FS = fsys
+		opts.Roots = prefixes
+	}
+
+	// Check Only Files Changed Since The Ref, Read From The Working Tree
+	if *changedSincePtr != "" {
+		if *stagedPtr {
+			fatalf("-staged And -changed-since Cannot Be Used Together")
+		}
+		files := []string{}
+		for _, root := range roots {
+			changed, err := decodecheck.ChangedFiles(ctx, root, *changedSincePtr)
+			if err != nil {
+				fatalf("Cannot List Changed Files: %v", err)
+			}
+			files = append(files, changed...)
+		}
+		opts.Files = files
+		if len(files) == 0 {
+			logf("no-files", "No Changed Files Since %s", *changedSincePtr)
+		}
+	}
+
+	// Check Only The Listed Files, Read From The Working Tree
+	if *filesFromPtr != "" {
+		if *stagedPtr || *changedSincePtr != "" {
+			fatalf("-files-from Cannot Be Used With -staged Or -changed-since")
+		}
+		files, err := readFileList(*filesFromPtr, *nulPtr)
+		if err != nil {
+			fatalf("Cannot Read File List: %v", err)
+		}
+		opts.Files = files
+		if len(files) == 0 {
+			logf("no-files", "No Files Listed In %s", *filesFromPtr)
+		}
+	}
+
+	// Check Exactly The Files Of The Manifest, Whatever The Patterns And Excludes
+	if *manifestPtr != "" {
+		if *filesFromPtr != "" || *stagedPtr || *changedSincePtr != "" {
+			fatalf("-manifest Cannot Be Used With -files-from, -staged Or -changed-since")
+		}
+		manifest, err := decodecheck.LoadManifest(*manifestPtr)
+		if err != nil {
+			fatalf("Cannot Read Manifest %s: %v", *manifestPtr, err)
+		}
+		if err := manifest.Apply(&opts); err != nil {
+			fatalf("Invalid Manifest %s: %v", *manifestPtr, err)
+		}
+	}
+
+	// Open The Cache, Keyed By The Flags That Change How Files Decode
+	if *cachePtr || *cacheFilePtr != "" {
+		cachePath := *cacheFilePtr
+		if cachePath == "" {
+			var err error
+			if cachePath, err = decodecheck.DefaultCachePath(); err != nil {
+				fatalf("Cannot Locate Cache Directory: %v", err)
+			}
+		}
+		cache, err := decodecheck.OpenCache(cachePath)
+		if err != nil {
+			logEvent(slog.LevelWarn, "cache", fmt.Sprintf("Ignoring Unreadable Cache File %s: %v", cachePath, err), "error", err.Error())
+		}
+		opts.Cache = cache
+		opts.CacheTag = fmt.Sprintf("yaml-multidoc=%t csv-delimiter=%q csv-header=%t plugins=%q", *yamlMultiDocPtr, *csvDelimiterPtr, *csvHeaderPtr, plugins.String())
+	}
+
+	// Load The Baseline Of Accepted Failures, Recording One From This Run If It Is Missing
+	recordBaseline := *updateBaselinePtr
+	if recordBaseline && *baselinePtr == "" {
+		fatalf("-update-baseline Requires -baseline")
+	}
+	var previous *decodecheck.PreviousReport
+	if *comparePtr != "" {
+		if previous, err = decodecheck.LoadPreviousReport(*comparePtr); err != nil {
+			fatalf("Cannot Read Report %s: %v", *comparePtr, err)
+		}
+	}
+	if *baselinePtr != "" && !recordBaseline {
+		baseline, err := decodecheck.LoadBaseline(*baselinePtr)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			recordBaseline = true
+		case err != nil:
+			fatalf("Cannot Read Baseline %s: %v", *baselinePtr, err)
+		}
+		opts.Baseline = baseline
+	}
+
+	// Decode Stdin On Its Own, Exiting Non-Zero If It Fails
+	if *stdinPtr {
+		checkStdin(ctx, opts, *stdinFormatPtr, *outputPtr)
+		return
+	}
+
+	// Compare Two Trees On Their Own, Exiting Non-Zero When They Differ
+	if diffTrees {
+		compareTrees(ctx, opts, roots[0], roots[1], *outputPtr)
+		return
+	}
+
+	// Merge The Files Given On Their Own, Exiting Non-Zero On Conflicts
+	if *mergePtr {
+		mergeFiles(opts, flag.Args(), *outputPtr)
+		return
+	}
+
+	// Record Metrics For Prometheus When Serving Or Pushing Them
+	if *servePtr != "" || *daemonPtr != "" || *metricsPtr != "" || *pushgatewayPtr != "" {
+		opts.Metrics = decodecheck.NewMetrics()
+	}
+	if *metricsPtr != "" {
+		go serveMetrics(ctx, *metricsPtr, opts.Metrics)
+	}
+
+	// Serve Until Interrupted, Scans Are Limited To The Search Path
+	if *servePtr != "" {
+		serve(ctx, *servePtr, opts)
+		saveCache(opts.Cache)
+		return
+	}
+	if *daemonPtr != "" {
+		runDaemon(ctx, *daemonPtr, decodecheck.NewDaemon(opts, *daemonScansPtr, *daemonQueuePtr))
+		saveCache(opts.Cache)
+		return
+	}
+	if *lspPtr {
+		if err := decodecheck.ServeLSP(ctx, os.Stdin, os.Stdout, opts); err != nil {
+			fatalf("%v", err)
+		}
+		return
+	}
+	if *grpcPtr != "" {
+		serveGRPC(ctx, *grpcPtr, opts)
+		saveCache(opts.Cache)
+		return
+	}
+
+	// List What A Scan Would Check, And How, Without Reading Any Files
+	if *listOnlyPtr {
+		opts.ListOnly = true
+		report := runScan(ctx, opts, false, 0, false)
+		report.Sort()
+		if *outputPtr == "json" {
+			printJSON(report)
+		} else {
+			printListing(report)
+		}
+		return
+	}
+
+	// Limit Only The Scan To -timeout, Not Watching Or Pushing Metrics After It
+	scanCtx := ctx
+	if *timeoutPtr > 0 {
+		var cancel context.CancelFunc
+		scanCtx, cancel = context.WithTimeout(ctx, *timeoutPtr)
+		defer cancel()
+	}
+	started := time.Now()
+	report := runScan(scanCtx, opts, *progressPtr && !*quietPtr && !*terragruntHookPtr, *heartbeatPtr, *streamPtr)
+
+	// Browse The Failures Instead Of Logging Them, Exiting On What Still Fails Once Done
+	if *tuiPtr && !report.Interrupted {
+		saveCache(opts.Cache)
+		failing, err := browseResults(ctx, opts, report)
+		if err != nil {
+			fatalf("Cannot Run -tui: %v", err)
+		}
+		if failing > 0 {
+			fatalf("%d Files Still Failing", failing)
+		}
+		logf("exit", "No Files Failing")
+		return
+	}
+	if *terragruntHookPtr {
+		report.Sort()
+		reportHook(report)
+	} else {
+		if !*streamPtr {
+			report.Sort()
+			for _, err := range report.WalkErrors {
+				printWalkError(err)
+			}
+			for _, result := range report.Results {
+				printResult(result)
+			}
+		}
+		printFileCounts(report.Counts) // final totals
+		printDirectoryCounts(report.Directories)
+		printAliases(report)
+		if *identicalPtr {
+			printIdentical(report)
+		}
+		if *sizeHistogramPtr {
+			printSizeHistogram(report)
+		}
+		if *timingPtr || *slowestPtr > 0 {
+			printTiming(report, *slowestPtr)
+		}
+		if *benchPtr {
+			printBench(report)
+		}
+	}
+	if *outputPtr == "json" {
+		printJSON(report)
+	} else if opts.Query != nil {
+		printQueryResults(report)
+	}
+	if *htmlPtr != "" {
+		if err := writeHTMLReport(*htmlPtr, roots, report); err != nil {
+			fatalf("Cannot Write HTML Report: %v", err)
+		}
+	}
+	if *csvPtr != "" {
+		if err := writeCSVReport(*csvPtr, report, *strictPtr); err != nil {
+			fatalf("Cannot Write CSV Report: %v", err)
+		}
+	}
+	reportURL := *notifyReportURLPtr
+	if reportURL == "" {
+		reportURL = ciRunURL()
+	}
+	if *markdownPtr != "" {
+		if err := writeMarkdownReport(*markdownPtr, report); err != nil {
+			fatalf("Cannot Write Markdown Report: %v", err)
+		}
+	}
+	if *runTaskResultPtr != "" {
+		if err := writeRunTaskResult(*runTaskResultPtr, reportURL, report); err != nil {
+			fatalf("Cannot Write Run Task Result: %v", err)
+		}
+	}
+	if *diffPtr && *outputPtr != "json" {
+		for _, result := range report.Results {
+			fmt.Print(result.FormatDiff)
+		}
+	}
+	if opts.Convert != "" {
+		writeConverted(report, opts.Convert, *writePtr)
+	}
+
+	if opts.Cache != nil {
+		printCacheHits(report)
+	}
+
+	// Push Metrics Of The Scan, A Pushgateway Being Down Does Not Fail The Run
+	if *pushgatewayPtr != "" {
+		if err := opts.Metrics.Push(ctx, *pushgatewayPtr, *pushgatewayJobPtr); err != nil {
+			logEvent(slog.LevelWarn, "metrics", fmt.Sprintf("Cannot Push Metrics To %s: %v", *pushgatewayPtr, err), "error", err.Error())
+		}
+	}
+
+	// Tell The Webhook How The Scan Went, A Chat Service Being Down Does Not Fail The Run
+	if *notifyWebhookPtr != "" && (*notifyOnPtr == "always" || report.Failed()) {
+		if err := notifyWebhook(ctx, *notifyWebhookPtr, reportURL, roots, report); err != nil {
+			logEvent(slog.LevelWarn, "notify", fmt.Sprintf("Cannot Post To Webhook: %v", err), "error", err.Error())
+		}
+	}
+
+	// Keep Watching Until Interrupted, Reporting Every Changed File As It Settles
+	if *watchPtr && !report.Interrupted {
+		logf("watch", "Watching For Changes, Press Ctrl-C To Stop")
+		opts.OnResult = printWatchResult
+		if err := decodecheck.NewRunner(opts).Watch(ctx); err != nil {
+			fatalf("Cannot Watch Files: %v", err)
+		}
+		saveCache(opts.Cache)
+		return
+	}
+
+	// Save Cached Results Before Exiting, Exit Paths Below Skip Deferred Calls
+	saveCache(opts.Cache)
+
+	// If Interrupted, Counts Only Cover Part Of The Tree So Exit With A Distinct Code
+	if report.Interrupted {
+		for _, path := range report.Unscanned {
+			logEvent(slog.LevelDebug, "unscanned", "Not Scanned: "+path, "path", path)
+		}
+		if len(report.Unscanned) > 0 {
+			logEvent(slog.LevelWarn, "unscanned", fmt.Sprintf("%d Paths Were Not Scanned", len(report.Unscanned)), "count", len(report.Unscanned))
+		}
+		if report.TimedOut {
+			logEvent(slog.LevelWarn, "interrupted", fmt.Sprintf("Timed Out After %v, Counts Above Are Partial", *timeoutPtr), "timeout", timeoutPtr.String())
+			exit(exitTimedOut)
+		}
+		logEvent(slog.LevelWarn, "interrupted", "Interrupted, Counts Above Are Partial")
+		exit(exitInterrupted)
+	}
+
+	// An Aborted Scan Says Nothing About The Files It Left, So Exit With Its Own Code
+	if report.OverBudget {
+		limit := fmt.Sprintf("-max-files %d", opts.MaxFiles)
+		if opts.MaxTotalBytes > 0 && report.Counts.Bytes() > opts.MaxTotalBytes {
+			limit = "-max-total-bytes " + *maxTotalBytesPtr
+		}
+		logEvent(slog.LevelError, "over-budget", fmt.Sprintf("Aborted After Finding More To Check Than %s Allows, Counts Above Are Partial", limit), "code", codes["over-budget"])
+		exit(codes["over-budget"])
+	}
+
+	// Record The Run For Trends, Only Whole Scans So Runs Compare Like With Like
+	if *historyPtr != "" && !report.Stopped {
+		if runID, err := recordHistory(*historyPtr, started, roots, report, *strictPtr); err != nil {
+			logEvent(slog.LevelWarn, "history", fmt.Sprintf("Cannot Record Run In History %s: %v", *historyPtr, err), "error", err.Error())
+		} else {
+			logEvent(slog.LevelDebug, "history", fmt.Sprintf("Recorded Run %d In History %s", runID, *historyPtr), "run", runID)
+		}
+	}
+
+	// Record The Failures As Accepted, Only From A Scan Of The Whole Tree
+	if recordBaseline {
+		if report.Stopped {
+			fatalf("Cannot Record Baseline %s From A Scan Stopped At The Error Limit", *baselinePtr)
+		}
+		count, err := decodecheck.SaveBaseline(*baselinePtr, report.Results)
+		if err != nil {
+			fatalf("Cannot Write Baseline %s: %v", *baselinePtr, err)
+		}
+		logf("baseline", "Recorded %d Failures In Baseline %s", count, *baselinePtr)
+		return
+	}
+	// Write The Merged Artifacts Only From A Whole Scan Where Every File Passed
+	if *mergeOutPtr != "" {
+		if report.Failed() || report.Stopped {
+			logEvent(slog.LevelWarn, "merge", "Not Writing Merged Artifacts, Files Failed")
+		} else {
+			writeMergedArtifacts(*mergeOutPtr, *mergeDepthPtr, mergeTypes, opts, report)
+		}
+	}
+	if opts.Snapshots != nil {
+		printSnapshots(opts.Snapshots, *snapshotDirPtr)
+	}
+	if opts.Baseline != nil {
+		printBaselined(report, *baselinePtr)
+	}
+	if previous != nil {
+		exitCompared(previous.Compare(report.Results), *comparePtr, codes)
+	}
+
+	// Counts Only Cover Part Of The Tree When The Error Limit Stopped The Scan
+	if report.Stopped {
+		logEvent(slog.LevelWarn, "stopped", fmt.Sprintf("Stopped At The Error Limit Of %d, Counts Above Are Partial", opts.MaxErrors))
+	}
+
+	// Exit With The Code Mapped To The Worst Outcome, Decode Errors Exit 1 By Default
+	exitWith(report, codes, *strictPtr)
+}
+
+// runScan runs the scan, reporting progress while it runs when show is
+// set: live on a terminal, or every heartbeat otherwise.   Unless stream is
+// set, walk errors are left for the caller to log in order with the results.
+func runScan(ctx context.Context, opts decodecheck.Options, show bool, heartbeat time.Duration, stream bool) *decodecheck.Report {
+	if !stream {
+		opts.OnWalkError = func(error) {}
+	}
+	live := !jsonLog && isTerminal(os.Stderr)
+	if !show || (!live && heartbeat <= 0) {
+		return decodecheck.NewRunner(opts).Run(ctx)
+	}
+	progress := startProgress(live, heartbeat)
+	opts.OnProgress = progress.update
+	report := decodecheck.NewRunner(opts).Run(ctx)
+	progress.stop()
+	return report
+}
+
+// readFileList returns the paths listed in the file name, or stdin when name
+// is -, separated by newlines or by NUL bytes when nul is set.   Blank entries
+// are dropped, and so are carriage returns ending lines.
+func readFileList(name string, nul bool) ([]string, error) {
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sep := "\n"
+	if nul {
+		sep = "\x00"
+	}
+	files := []string{}
+	for _, file := range strings.Split(string(data), sep) {
+		if !nul {
+			file = strings.TrimSuffix(file, "\r")
+		}
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// checkStdin decodes the document on stdin with the named decoder, logging the
+// outcome like a single file (and printing its JSON for -output json) and
+// exiting 1 if it fails.
+func checkStdin(ctx context.Context, opts decodecheck.Options, decoderName, output string) {
+	if _, ok := opts.Decoders[decoderName]; !ok {
+		fatalf("Unknown -stdin-format %q, Must Be One Of %s", decoderName, strings.Join(decodecheck.DecoderNames(opts.Decoders), ", "))
+	}
+	src, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		fatalf("Cannot Read Stdin: %v", err)
+	}
+
+	result, ok := decodecheck.NewRunner(opts).Validate(ctx, "<stdin>", decoderName, src)
+	if !ok {
+		logEvent(slog.LevelWarn, "interrupted", "Interrupted")
+		exit(exitInterrupted)
+	}
+	printResult(result)
+	if output == "json" {
+		printJSON(result)
+	}
+	if result.Failed() {
+		fatalf("Decode Errors Found In Stdin")
+	}
+	logf("exit", "Stdin Decoded Successfully")
+}
+
+// compareTrees prints the semantic differences between the files of the trees
+// before and after, or with -output json the whole comparison, exiting 1 when
+// they differ like diff does.
+func compareTrees(ctx context.Context, opts decodecheck.Options, before, after, output string) {
+	diff, ok := decodecheck.CompareTrees(ctx, opts, before, after)
+	if !ok {
+		logEvent(slog.LevelWarn, "interrupted", "Interrupted")
+		exit(exitInterrupted)
+	}
+	if output == "json" {
+		printJSON(diff)
+	} else {
+		printTreeDiff(diff)
+	}
+	if diff.Failed() {
+		fatalf("Files Failed To Decode, %s And %s Cannot Be Fully Compared", before, after)
+	}
+	if len(diff.Files) > 0 {
+		logEvent(slog.LevelInfo, "exit", fmt.Sprintf("%d Files Differ Between %s And %s", len(diff.Files), before, after), "files", len(diff.Files))
+		exit(1)
+	}
+	logf("exit", "No Differences Between %s And %s", before, after)
+}
+
+// printTreeDiff writes each file that differs between two trees to stdout,
+// with its changed values marked ~, added ones + and removed ones -.
+func printTreeDiff(diff *decodecheck.TreeDiff) {
+	for _, file := range diff.Files {
+		if file.Kind == "failed" {
+			logEvent(slog.LevelError, "decode-failed", fmt.Sprintf("error decoding file %s", file.Error), "file", file.Path, "error", file.Error)
+			continue
+		}
+		fmt.Printf("%s %s\n", colorize(diffColor(file.Kind), file.Kind), file.Path)
+		for _, change := range file.Changes {
+			path := cmp.Or(change.Path, ".")
+			var line string
+			switch change.Kind {
+			case "added":
+				line = fmt.Sprintf("  + %s: %s", path, change.After)
+			case "removed":
+				line = fmt.Sprintf("  - %s: %s", path, change.Before)
+			default:
+				line = fmt.Sprintf("  ~ %s: %s -> %s", path, change.Before, change.After)
+			}
+			fmt.Println(colorize(diffColor(change.Kind), line))
+		}
+	}
+}
+
+// diffColor returns the color of an added, removed or changed line.
+func diffColor(kind string) string {
+	switch kind {
+	case "added":
+		return colorGreen
+	case "removed":
+		return colorRed
+	}
+	return colorYellow
+}
+
+// mergeFiles deep merges files in order and prints the merged value, or with
+// -output json the merge report, failing if any layer replaced a value with one
+// of another kind.
+func mergeFiles(opts decodecheck.Options, files []string, output string) {
+	if len(files) == 0 {
+		fatalf("-merge Needs The Files To Merge As Arguments, In Order")
+	}
+	report, err := decodecheck.NewRunner(opts).Merge(files)
+	if err != nil {
+		fatalf("Cannot Merge Files: %v", err)
+	}
+	for _, conflict := range report.Conflicts {
+		logEvent(slog.LevelError, "merge-conflict", fmt.Sprintf("type conflict merging %s", conflict), "key", conflict.Path,
+			"file", conflict.File, "kind", conflict.Kind, "previousFile", conflict.PreviousFile, "previousKind", conflict.PreviousKind)
+	}
+	if output == "json" {
+		printJSON(report)
+	} else {
+		printJSON(ctyjson.SimpleJSONValue{Value: report.Value})
+	}
+	if len(report.Conflicts) > 0 {
+		fatalf("Type Conflicts Found Merging %d Files", len(files))
+	}
+	logf("exit", "%d Files Merged Without Conflicts", len(files))
+}
+
+// printJSON writes v to stdout as indented JSON, for -output json.
+func printJSON(v any) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		logEvent(slog.LevelError, "report", fmt.Sprintf("error writing report: %v", err), "error", err.Error())
+	}
+}
+
+// parseDelimiter turns the -csv-delimiter flag into the rune for csv.Reader,
+// accepting a literal \t for tab separated files.
+func parseDelimiter(delimiter string) (rune, error) {
+	if delimiter == `\t` {
+		return '\t', nil
+	}
+	runes := []rune(delimiter)
+	if len(runes) != 1 || runes[0] == '"' || runes[0] == '\r' || runes[0] == '\n' {
+		return 0, fmt.Errorf("invalid CSV delimiter %q, must be a single character", delimiter)
+	}
+	return runes[0], nil
+}
+
+// parseSize turns a -min-size or -max-size flag into bytes, accepting plain
+// byte counts or a KB, MB or GB suffix (powers of 1000, like the summary).
+func parseSize(size string) (int64, error) {
+	number, multiplier := strings.ToUpper(strings.TrimSpace(size)), int64(1)
+	for _, unit := range []struct {
+		suffix     string
+		multiplier int64
+	}{{"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9}, {"B", 1}} {
+		if strings.HasSuffix(number, unit.suffix) {
+			number, multiplier = strings.TrimSpace(strings.TrimSuffix(number, unit.suffix)), unit.multiplier
+			break
+		}
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q, expected bytes or a number with a KB, MB or GB suffix", size)
+	}
+	return int64(value * float64(multiplier)), nil
+}
+
+// parseNewerThan turns the -newer-than flag into a time, accepting a duration
+// before now (with d for days as well as the time.ParseDuration units), an
+// RFC 3339 timestamp or a 2006-01-02 date in local time.
+func parseNewerThan(value string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.ParseFloat(days, 64); err == nil && n >= 0 {
+			return now.Add(-time.Duration(n * float64(24*time.Hour))), nil
+		}
+	}
+	if duration, err := time.ParseDuration(value); err == nil && duration >= 0 {
+		return now.Add(-duration), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, expected a duration like 24h or 7d, an RFC 3339 timestamp or a date", value)
+}
+
+// flagSet reports whether the named flag was given on the command line.
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
 }
 
 func contains(slice []string, item string) bool {
@@ -0,0 +1,103 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/JasonPodgorny/terraformDecodeTest/pkg/decodecheck"
+)
+
+// exitCodes maps the outcomes of a run to the exit codes reported for them,
+// set with -exit-codes 'outcome=code'.   Walk errors and warnings exit 0 unless
+// mapped, so existing pipelines keep passing on them.
+type exitCodes map[string]int
+
+// exitOutcomes are the outcomes -exit-codes can map, from worst to best.
+// over-budget is a run aborted by -max-files or -max-total-bytes, which never
+// gets as far as telling the others apart.
+var exitOutcomes = []string{"over-budget", "failed", "walk-error", "warnings", "clean"}
+
+// defaultExitCodes returns the exit codes used when -exit-codes does not map
+// an outcome.
+func defaultExitCodes() exitCodes {
+	return exitCodes{"over-budget": 3, "failed": 1, "walk-error": 0, "warnings": 0, "clean": 0}
+}
+
+func (ec exitCodes) String() string {
+	codes := make([]string, 0, len(ec))
+	for outcome, code := range ec {
+		codes = append(codes, outcome+"="+strconv.Itoa(code))
+	}
+	sort.Strings(codes)
+	return strings.Join(codes, ",")
+}
+
+// Set takes a comma separated list of outcome=code mappings.
+func (ec exitCodes) Set(value string) error {
+	for _, mapping := range strings.Split(value, ",") {
+		outcome, code, ok := strings.Cut(strings.TrimSpace(mapping), "=")
+		if !ok || !contains(exitOutcomes, outcome) {
+			return fmt.Errorf("expected outcome=code with outcome one of %s, got %q", strings.Join(exitOutcomes, ", "), mapping)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(code))
+		if err != nil || n < 0 || n > 125 {
+			return fmt.Errorf("invalid exit code %q, must be 0 to 125", code)
+		}
+		ec[outcome] = n
+	}
+	return nil
+}
+
+// atExit holds the functions run before exiting, like removing fetched remote
+// sources, since os.Exit skips deferred calls.
+var atExit []func()
+
+// exit runs the atExit functions, latest first, and exits with code, which
+// with -terragrunt-hook is 1 for every failure, as terragrunt only tells zero
+// from non-zero.
+func exit(code int) {
+	if hookLog && code > 1 {
+		code = 1
+	}
+	runAtExit()
+	os.Exit(code)
+}
+
+// runAtExit runs the atExit functions once, latest first, for exit and for
+// main returning normally.
+func runAtExit() {
+	for i := len(atExit) - 1; i >= 0; i-- {
+		atExit[i]()
+	}
+	atExit = nil
+}
+
+// exitWith logs the worst outcome of report and exits with its code.   With
+// strict set, walk errors and warnings count as failures.
+func exitWith(report *decodecheck.Report, codes exitCodes, strict bool) {
+	walkErrors := len(report.WalkErrors)
+	warnings := report.Counts.WarningFiles("total")
+	switch {
+	case report.Failed():
+		logEvent(slog.LevelError, "exit", "Decode Errors Found In Files", "code", codes["failed"])
+		exit(codes["failed"])
+	case strict && (walkErrors > 0 || warnings > 0):
+		logEvent(slog.LevelError, "exit", fmt.Sprintf("Failing Strict Run: %d Unreadable Paths, %d Files With Warnings", walkErrors, warnings), "code", codes["failed"])
+		exit(codes["failed"])
+	case walkErrors > 0:
+		logEvent(slog.LevelWarn, "exit", fmt.Sprintf("%d Paths Could Not Be Read", walkErrors), "code", codes["walk-error"])
+		exit(codes["walk-error"])
+	case warnings > 0:
+		logEvent(slog.LevelWarn, "exit", fmt.Sprintf("All Files Decoded Successfully, %d With Warnings", warnings), "code", codes["warnings"])
+		exit(codes["warnings"])
+	}
+	logEvent(slog.LevelInfo, "exit", colorize(colorGreen, "All Files Decoded Successfully"), "code", codes["clean"])
+	exit(codes["clean"])
+}
@@ -0,0 +1,277 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JasonPodgorny/terraformDecodeTest/pkg/decodecheck"
+	_ "modernc.org/sqlite"
+)
+
+// trendRuns is how many of the latest runs decodeTest report trends lists.
+const trendRuns = 20
+
+// trendFiles is how many files each list of decodeTest report trends names.
+const trendFiles = 20
+
+// trendErrorWidth is how much of each error decodeTest report trends shows in
+// its tables, JSON having them in full.
+const trendErrorWidth = 80
+
+// historySchema creates the tables of a -history database: one row per run,
+// and one per file checked in each run.
+const historySchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id INTEGER PRIMARY KEY,
+	started TEXT NOT NULL,
+	duration_ms REAL NOT NULL,
+	roots TEXT NOT NULL,
+	version TEXT NOT NULL,
+	files INTEGER NOT NULL,
+	errors INTEGER NOT NULL,
+	warnings INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS results (
+	run_id INTEGER NOT NULL REFERENCES runs(id) ON DELETE CASCADE,
+	path TEXT NOT NULL,
+	type TEXT NOT NULL,
+	size INTEGER NOT NULL,
+	status TEXT NOT NULL,
+	failed INTEGER NOT NULL,
+	error TEXT NOT NULL,
+	duration_ms REAL NOT NULL,
+	PRIMARY KEY (run_id, path)
+);
+CREATE INDEX IF NOT EXISTS results_path ON results (path, run_id);
+`
+
+// openHistory opens the SQLite database at name, creating it and its tables
+// if needed.
+func openHistory(name string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", "file:"+name+"?_pragma=busy_timeout(10000)&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(historySchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// recordHistory adds report to the -history database at name as a run that
+// started at started, with a row for each file it checked and each path the
+// walk could not read, failed when strict makes them fail the run.
+func recordHistory(name string, started time.Time, roots []string, report *decodecheck.Report, strict bool) (int64, error) {
+	db, err := openHistory(name)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	run, err := tx.Exec("INSERT INTO runs (started, duration_ms, roots, version, files, errors, warnings) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		started.UTC().Format(time.RFC3339), milliseconds(time.Since(started)), strings.Join(roots, ","), version,
+		report.Counts.Files("total"), report.Counts.Errors("total"), report.Counts.WarningFiles("total"))
+	if err != nil {
+		return 0, err
+	}
+	runID, err := run.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	insert, err := tx.Prepare("INSERT OR REPLACE INTO results (run_id, path, type, size, status, failed, error, duration_ms) VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return 0, err
+	}
+	defer insert.Close()
+	for _, result := range report.Results {
+		var message string
+		if result.Err != nil {
+			message = oneLine(result.Err.Error())
+		}
+		if result.Line > 0 {
+			message = fmt.Sprintf("line %d, column %d: %s", result.Line, result.Column, message) // results have no position columns
+		}
+		if _, err := insert.Exec(runID, result.Path, result.Type, result.Size, result.Status.String(), result.Failed(), message, milliseconds(result.Duration)); err != nil {
+			return 0, err
+		}
+	}
+	for _, walkErr := range report.WalkErrors {
+		if _, err := insert.Exec(runID, walkErrorPath(walkErr), "", 0, walkFailedStatus, strict, oneLine(walkErr.Error()), 0); err != nil {
+			return 0, err
+		}
+	}
+	return runID, tx.Commit()
+}
+
+// milliseconds returns d in fractional milliseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// historyRun is a run recorded in a -history database.
+type historyRun struct {
+	ID       int64  `json:"id"`
+	Started  string `json:"started"`
+	Roots    string `json:"roots"`
+	Files    int    `json:"files"`
+	Errors   int    `json:"errors"`
+	Warnings int    `json:"warnings"`
+}
+
+// failingFile is a file failing in the latest run, with the run it has been
+// failing since.
+type failingFile struct {
+	Path         string `json:"path"`
+	Error        string `json:"error"`
+	FailingSince string `json:"failingSince"`
+	Runs         int    `json:"runs"`
+}
+
+// trends is what decodeTest report trends shows: the error counts of the
+// latest runs, the files failing in the latest run that passed or were not
+// checked in the run before it, and the files that have failed for the most
+// runs in a row.
+type trends struct {
+	Runs           []historyRun  `json:"runs"`
+	NewlyFailing   []failingFile `json:"newlyFailing"`
+	LongestFailing []failingFile `json:"longestFailing"`
+}
+
+// readTrends reads the trends of the -history database at name.
+func readTrends(name string) (*trends, error) {
+	if _, err := os.Stat(name); err != nil {
+		return nil, err
+	}
+	db, err := openHistory(name)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT id, started, roots, files, errors, warnings FROM runs ORDER BY id DESC LIMIT ?", trendRuns)
+	if err != nil {
+		return nil, err
+	}
+	t := &trends{Runs: []historyRun{}, NewlyFailing: []failingFile{}, LongestFailing: []failingFile{}}
+	for rows.Next() {
+		var run historyRun
+		if err := rows.Scan(&run.ID, &run.Started, &run.Roots, &run.Files, &run.Errors, &run.Warnings); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		t.Runs = append([]historyRun{run}, t.Runs...)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(t.Runs) == 0 {
+		return t, err
+	}
+	latest := t.Runs[len(t.Runs)-1].ID
+
+	// How Long Each File Failing Now Has Failed, Counting Back To The Last Run It Passed In
+	rows, err = db.Query(`
+		SELECT r.path, r.error,
+			(SELECT MIN(f.run_id) FROM results f WHERE f.path = r.path AND f.run_id > COALESCE(
+				(SELECT MAX(p.run_id) FROM results p WHERE p.path = r.path AND p.failed = 0), 0)) AS since,
+			(SELECT COUNT(*) FROM results f WHERE f.path = r.path AND f.run_id > COALESCE(
+				(SELECT MAX(p.run_id) FROM results p WHERE p.path = r.path AND p.failed = 0), 0)) AS runs,
+			EXISTS (SELECT 1 FROM results b WHERE b.path = r.path AND b.failed = 1 AND b.run_id = (SELECT MAX(id) FROM runs WHERE id < ?)) AS before
+		FROM results r WHERE r.run_id = ? AND r.failed = 1
+		ORDER BY since, runs DESC, r.path`, latest, latest)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var file failingFile
+		var since int64
+		var before bool
+		if err := rows.Scan(&file.Path, &file.Error, &since, &file.Runs, &before); err != nil {
+			return nil, err
+		}
+		if err := db.QueryRow("SELECT started FROM runs WHERE id = ?", since).Scan(&file.FailingSince); err != nil {
+			return nil, err
+		}
+		if !before && len(t.NewlyFailing) < trendFiles {
+			t.NewlyFailing = append(t.NewlyFailing, file)
+		}
+		if len(t.LongestFailing) < trendFiles {
+			t.LongestFailing = append(t.LongestFailing, file)
+		}
+	}
+	return t, rows.Err()
+}
+
+// printTrends prints the trends of the -history database at name, as tables
+// or as JSON for -output json.
+func printTrends(name, output string) error {
+	t, err := readTrends(name)
+	if err != nil {
+		return err
+	}
+	if output == "json" {
+		printJSON(t)
+		return nil
+	}
+	if len(t.Runs) == 0 {
+		fmt.Printf("No runs recorded in %s\n", name)
+		return nil
+	}
+
+	fmt.Printf("Latest %d runs:\n", len(t.Runs))
+	rows := make([][]tableCell, len(t.Runs))
+	for i, run := range t.Runs {
+		change := ""
+		if i > 0 {
+			if delta := run.Errors - t.Runs[i-1].Errors; delta != 0 {
+				change = fmt.Sprintf("%+d", delta)
+			}
+		}
+		rows[i] = []tableCell{
+			{text: strconv.FormatInt(run.ID, 10)},
+			{text: run.Started},
+			countCell(run.Files, ""),
+			countCell(run.Errors, colorRed),
+			{text: change},
+			countCell(run.Warnings, colorYellow),
+		}
+	}
+	printTable(os.Stdout, []string{"RUN", "STARTED", "FILES", "ERRORS", "CHANGE", "WARNINGS"}, rows)
+
+	for _, list := range []struct {
+		title string
+		files []failingFile
+	}{
+		{"Newly failing in the latest run", t.NewlyFailing},
+		{"Failing the longest", t.LongestFailing},
+	} {
+		fmt.Printf("\n%s:\n", list.title)
+		if len(list.files) == 0 {
+			fmt.Println("none")
+			continue
+		}
+		rows := make([][]tableCell, len(list.files))
+		for i, file := range list.files {
+			message := file.Error
+			if len(message) > trendErrorWidth {
+				message = message[:trendErrorWidth-3] + "..."
+			}
+			rows[i] = []tableCell{{text: file.Path}, {text: file.FailingSince}, countCell(file.Runs, ""), {text: message}}
+		}
+		printTable(os.Stdout, []string{"FILE", "FAILING SINCE", "RUNS", "ERROR"}, rows)
+	}
+	return nil
+}
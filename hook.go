@@ -0,0 +1,110 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/JasonPodgorny/terraformDecodeTest/pkg/decodecheck"
+)
+
+// terragruntCache is the directory terragrunt copies units and their modules
+// into to run terraform.
+const terragruntCache = ".terragrunt-cache"
+
+// hookRoots returns roots with each inside a terragrunt cache replaced by the
+// unit directory holding the cache, since the cache only has copies of files
+// checked there.   Hooks run in the cache by default, so -path . checks the
+// unit rather than its copy.
+func hookRoots(roots []string) []string {
+	hooked := make([]string, 0, len(roots))
+	for _, root := range roots {
+		abs, err := filepath.Abs(root)
+		if err == nil {
+			parts := strings.Split(abs, string(filepath.Separator))
+			if i := slices.Index(parts, terragruntCache); i > 0 {
+				root = strings.Join(parts[:i], string(filepath.Separator))
+				if root == "" {
+					root = string(filepath.Separator)
+				}
+			}
+		}
+		if !contains(hooked, root) {
+			hooked = append(hooked, root)
+		}
+	}
+	return hooked
+}
+
+// reportHook writes the results of report for -terragrunt-hook: every failure,
+// warning and unreadable path in full to a temporary file, and to stderr one
+// line for each failed file and one naming the file, so terragrunt's output
+// stays readable.   A clean run prints a single line.
+func reportHook(report *decodecheck.Report) {
+	var details []string
+	for _, err := range report.WalkErrors {
+		details = append(details, "unreadable: "+err.Error())
+	}
+	var failed []string
+	for _, result := range report.Results {
+		for _, warning := range result.Warnings {
+			details = append(details, fmt.Sprintf("warning: %s: %s", result.Path, warning))
+		}
+		if !result.Failed() {
+			continue
+		}
+		message := firstLine(result.Err)
+		if message == "" {
+			message = result.Status.String()
+		}
+		failed = append(failed, fmt.Sprintf("decodeTest: %s: %s", result.Location(), message))
+		full := result.Status.String()
+		if result.Err != nil {
+			full = result.Err.Error()
+		}
+		details = append(details, fmt.Sprintf("error: %s: %s", result.Location(), full))
+	}
+
+	files := report.Counts.Files("total")
+	partial := ""
+	switch {
+	case report.TimedOut:
+		partial = " (timed out, partial)"
+	case report.Interrupted:
+		partial = " (interrupted, partial)"
+	case report.Stopped:
+		partial = " (stopped at the error limit, partial)"
+	case report.OverBudget:
+		partial = " (aborted over budget, partial)"
+	}
+	if len(details) == 0 {
+		fmt.Fprintf(os.Stderr, "decodeTest: %d files passed%s\n", files, partial)
+		return
+	}
+	for _, line := range failed {
+		fmt.Fprintln(os.Stderr, line)
+	}
+
+	summary := fmt.Sprintf("decodeTest: %d of %d files failed", len(failed), files)
+	if len(failed) == 0 {
+		summary = fmt.Sprintf("decodeTest: %d files passed with warnings", files)
+	}
+	summary += partial
+	detailsFile, err := os.CreateTemp("", "decodeTest-*.log")
+	if err == nil {
+		_, err = detailsFile.WriteString(strings.Join(details, "\n") + "\n")
+		if closeErr := detailsFile.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s, cannot write details: %v\n", summary, err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s, details in %s\n", summary, detailsFile.Name())
+}
@@ -0,0 +1,319 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JasonPodgorny/terraformDecodeTest/pkg/decodecheck"
+)
+
+// excerptLines is how many lines either side of an error the -html report
+// shows.
+const excerptLines = 2
+
+// htmlChartRows is the most bars a chart of the -html report shows, the
+// directories with the most errors.
+const htmlChartRows = 15
+
+// htmlReport is what the -html template renders.
+type htmlReport struct {
+	Generated   string
+	Roots       string
+	Files       int
+	Size        string
+	Failed      int
+	Warnings    int
+	Interrupted bool
+	TimedOut    bool
+	Stopped     bool
+	OverBudget  bool
+	Unscanned   int
+	WalkErrors  []string
+	ByType      []htmlBar
+	ByDirectory []htmlBar
+	Results     []htmlResult
+}
+
+// htmlBar is one bar of a chart, the errors among the files of a type or
+// directory, with their widths as percentages of the largest bar.
+type htmlBar struct {
+	Label       string
+	Files       int
+	Errors      int
+	FilesWidth  float64
+	ErrorsWidth float64
+}
+
+// htmlResult is one row of the file table.
+type htmlResult struct {
+	decodecheck.Result
+	Class   string // failed, warning or passed
+	Error   string
+	Excerpt []htmlLine
+}
+
+// htmlLine is a numbered line of an error excerpt.
+type htmlLine struct {
+	Number int
+	Text   string
+	Error  bool
+}
+
+// writeHTMLReport writes report to name as a single HTML page, with no
+// scripts or styles from elsewhere, so it can be kept as a CI artifact and
+// opened anywhere.
+func writeHTMLReport(name string, roots []string, report *decodecheck.Report) error {
+	page := htmlReport{
+		Generated:   time.Now().Format(time.RFC1123),
+		Roots:       strings.Join(roots, ", "),
+		Files:       report.Counts.Files("total"),
+		Size:        fmt.Sprintf("%.1f MB", float64(report.Counts.Bytes())/1e6),
+		Failed:      report.Counts.Errors("total"),
+		Warnings:    report.Counts.WarningFiles("total"),
+		Interrupted: report.Interrupted,
+		TimedOut:    report.TimedOut,
+		Stopped:     report.Stopped,
+		OverBudget:  report.OverBudget,
+		Unscanned:   len(report.Unscanned),
+	}
+	for _, err := range report.WalkErrors {
+		page.WalkErrors = append(page.WalkErrors, err.Error())
+	}
+
+	for _, fileType := range report.Counts.Types() {
+		page.ByType = append(page.ByType, htmlBar{Label: fileType, Files: report.Counts.Files(fileType), Errors: report.Counts.Errors(fileType)})
+	}
+	page.ByType = scaleBars(page.ByType)
+
+	// Errors By Directory, The -summary-depth Breakdown When There Is One
+	directories := map[string]*htmlBar{}
+	if len(report.Directories) > 0 {
+		for _, dir := range report.Directories {
+			directories[dir.Path] = &htmlBar{Label: dir.Path, Files: dir.Files, Errors: dir.Errors}
+		}
+	} else {
+		for _, result := range report.Results {
+			dir := filepath.Dir(result.Path)
+			if directories[dir] == nil {
+				directories[dir] = &htmlBar{Label: dir}
+			}
+			directories[dir].Files++
+			if result.Failed() {
+				directories[dir].Errors++
+			}
+		}
+	}
+	for _, bar := range directories {
+		if bar.Errors > 0 {
+			page.ByDirectory = append(page.ByDirectory, *bar)
+		}
+	}
+	sort.Slice(page.ByDirectory, func(i, j int) bool {
+		a, b := page.ByDirectory[i], page.ByDirectory[j]
+		if a.Errors != b.Errors {
+			return a.Errors > b.Errors
+		}
+		return a.Label < b.Label
+	})
+	if len(page.ByDirectory) > htmlChartRows {
+		page.ByDirectory = page.ByDirectory[:htmlChartRows]
+	}
+	page.ByDirectory = scaleBars(page.ByDirectory)
+
+	for _, result := range report.Results {
+		row := htmlResult{Result: result, Class: "passed"}
+		if result.Err != nil {
+			row.Error = result.Err.Error()
+		}
+		switch {
+		case result.Failed():
+			row.Class = "failed"
+			if result.Line > 0 {
+				row.Excerpt = errorExcerpt(result)
+			}
+		case len(result.Warnings) > 0 || result.Severity == decodecheck.SeverityWarning:
+			row.Class = "warning"
+		}
+		page.Results = append(page.Results, row)
+	}
+
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := htmlTemplate.Execute(file, page); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// scaleBars sets the widths of bars against the most files any of them has.
+func scaleBars(bars []htmlBar) []htmlBar {
+	most := 0
+	for _, bar := range bars {
+		most = max(most, bar.Files)
+	}
+	for i := range bars {
+		if most > 0 {
+			bars[i].FilesWidth = 100 * float64(bars[i].Files) / float64(most)
+			bars[i].ErrorsWidth = 100 * float64(bars[i].Errors) / float64(most)
+		}
+	}
+	return bars
+}
+
+// errorExcerpt returns the lines of result's file around its error, or none
+// when it cannot be read again, like files in archives.
+func errorExcerpt(result decodecheck.Result) []htmlLine {
+	first := max(1, result.Line-excerptLines)
+	lines, err := readLines(result.Path, first, result.Line+excerptLines)
+	if err != nil {
+		return nil
+	}
+	excerpt := make([]htmlLine, len(lines))
+	for i, text := range lines {
+		excerpt[i] = htmlLine{Number: first + i, Text: text, Error: first+i == result.Line}
+	}
+	return excerpt
+}
+
+// readLines returns lines first to last of the file name, numbered from 1, or
+// as many of them as it has.
+func readLines(name string, first, last int) ([]string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	for number := 1; number <= last && scanner.Scan(); number++ {
+		if number >= first {
+			lines = append(lines, scanner.Text())
+		}
+	}
+	return lines, scanner.Err()
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"size": sizeLabel,
+	"ms": func(d time.Duration) string {
+		return fmt.Sprintf("%.1f", float64(d)/float64(time.Millisecond))
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>decodeTest report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1 { margin-bottom: 0.2em; }
+.meta { color: #666; margin-bottom: 1.5em; }
+.totals { display: flex; gap: 1em; margin-bottom: 1.5em; }
+.totals div { border: 1px solid #ddd; border-radius: 6px; padding: 0.6em 1.2em; }
+.totals b { display: block; font-size: 1.6em; }
+.notice { background: #fff4d6; border: 1px solid #e6c86e; border-radius: 6px; padding: 0.6em 1em; margin-bottom: 1em; }
+.charts { display: flex; flex-wrap: wrap; gap: 3em; margin-bottom: 2em; }
+.chart { flex: 1; min-width: 320px; }
+.bar { display: flex; align-items: center; margin: 3px 0; font-size: 0.9em; }
+.bar .label { width: 14em; overflow: hidden; text-overflow: ellipsis; white-space: nowrap; }
+.bar .track { flex: 1; position: relative; height: 1.1em; background: #f3f3f3; }
+.bar .files { position: absolute; height: 100%; background: #b7d7b0; }
+.bar .errors { position: absolute; height: 100%; background: #d9534f; }
+.bar .count { width: 7em; text-align: right; }
+table { border-collapse: collapse; width: 100%; font-size: 0.9em; }
+th { text-align: left; background: #f3f3f3; cursor: pointer; user-select: none; }
+th, td { padding: 4px 8px; border-bottom: 1px solid #eee; vertical-align: top; }
+th.sorted-up::after { content: " \25B2"; }
+th.sorted-down::after { content: " \25BC"; }
+tr.failed td.status { color: #c9302c; font-weight: bold; }
+tr.warning td.status { color: #b8860b; }
+tr.passed td.status { color: #3c763d; }
+.only-failed tr.passed, .only-failed tr.warning { display: none; }
+pre { background: #f8f8f8; padding: 0.5em; margin: 0.3em 0; overflow-x: auto; }
+pre .error { background: #f9d6d5; display: block; }
+</style>
+</head>
+<body>
+<h1>decodeTest report</h1>
+<div class="meta">{{.Roots}}, generated {{.Generated}}</div>
+{{if .TimedOut}}<div class="notice">The scan timed out, so these results are partial. {{.Unscanned}} paths were not scanned.</div>
+{{else if .Interrupted}}<div class="notice">The scan was interrupted, so these results are partial. {{.Unscanned}} paths were not scanned.</div>
+{{else if .Stopped}}<div class="notice">The scan stopped at the error limit, so these results are partial.</div>
+{{else if .OverBudget}}<div class="notice">The scan was aborted for finding more files than its budget allows, so these results are partial.</div>{{end}}
+<div class="totals">
+<div><b>{{.Files}}</b>files</div>
+<div><b>{{.Size}}</b>scanned</div>
+<div><b>{{.Failed}}</b>failed</div>
+<div><b>{{.Warnings}}</b>with warnings</div>
+</div>
+{{if .WalkErrors}}<h2>Unreadable Paths</h2>
+<ul>{{range .WalkErrors}}<li>{{.}}</li>{{end}}</ul>{{end}}
+<div class="charts">
+<div class="chart">
+<h2>Errors By Type</h2>
+{{range .ByType}}<div class="bar"><span class="label" title="{{.Label}}">{{.Label}}</span><span class="track"><span class="files" style="width: {{printf "%.1f" .FilesWidth}}%"></span><span class="errors" style="width: {{printf "%.1f" .ErrorsWidth}}%"></span></span><span class="count">{{.Errors}} / {{.Files}}</span></div>
+{{end}}</div>
+<div class="chart">
+<h2>Errors By Directory</h2>
+{{range .ByDirectory}}<div class="bar"><span class="label" title="{{.Label}}">{{.Label}}</span><span class="track"><span class="files" style="width: {{printf "%.1f" .FilesWidth}}%"></span><span class="errors" style="width: {{printf "%.1f" .ErrorsWidth}}%"></span></span><span class="count">{{.Errors}} / {{.Files}}</span></div>
+{{else}}<p>No errors.</p>
+{{end}}</div>
+</div>
+<h2>Files</h2>
+<p><label><input type="checkbox" id="only-failed"{{if .Failed}} checked{{end}}> Only show failed files</label></p>
+<table id="files"{{if .Failed}} class="only-failed"{{end}}>
+<thead><tr><th>Path</th><th>Type</th><th>Status</th><th data-numeric>Size</th><th data-numeric>Decode ms</th><th>Error</th></tr></thead>
+<tbody>
+{{range .Results}}<tr class="{{.Class}}"><td>{{.Location}}</td><td>{{.Type}}</td><td class="status">{{.Status}}</td><td data-value="{{.Size}}">{{size .Size}}</td><td>{{ms .Duration}}</td><td>{{if .Excerpt}}<details><summary>{{.Error}}</summary><pre>{{range .Excerpt}}<span{{if .Error}} class="error"{{end}}>{{printf "%4d" .Number}} | {{.Text}}</span>
+{{end}}</pre></details>{{else}}{{.Error}}{{end}}{{range .Warnings}}<div>warning: {{.}}</div>{{end}}</td></tr>
+{{end}}</tbody>
+</table>
+<script>
+(function () {
+  var table = document.getElementById("files");
+  document.getElementById("only-failed").addEventListener("change", function (e) {
+    table.classList.toggle("only-failed", e.target.checked);
+  });
+  var headers = table.tHead.rows[0].cells;
+  for (var i = 0; i < headers.length; i++) {
+    headers[i].addEventListener("click", sortBy.bind(null, i));
+  }
+  function cellValue(row, column, numeric) {
+    var cell = row.cells[column];
+    var text = cell.getAttribute("data-value") || cell.textContent;
+    return numeric ? parseFloat(text) || 0 : text.toLowerCase();
+  }
+  function sortBy(column) {
+    var header = headers[column];
+    var numeric = header.hasAttribute("data-numeric");
+    var up = !header.classList.contains("sorted-up");
+    for (var i = 0; i < headers.length; i++) {
+      headers[i].classList.remove("sorted-up", "sorted-down");
+    }
+    header.classList.add(up ? "sorted-up" : "sorted-down");
+    var body = table.tBodies[0];
+    var rows = Array.prototype.slice.call(body.rows);
+    rows.sort(function (a, b) {
+      var x = cellValue(a, column, numeric), y = cellValue(b, column, numeric);
+      return (x < y ? -1 : x > y ? 1 : 0) * (up ? 1 : -1);
+    });
+    rows.forEach(function (row) { body.appendChild(row); });
+  }
+})();
+</script>
+</body>
+</html>
+`))
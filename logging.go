@@ -0,0 +1,141 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+
+	"github.com/JasonPodgorny/terraformDecodeTest/pkg/decodecheck"
+)
+
+// levelTrace is the level of the -vv log lines, below slog.LevelDebug for -v.
+const levelTrace = slog.LevelDebug - 4
+
+// logLevel is the lowest level logged, lowered by -v and -vv.
+var logLevel = new(slog.LevelVar)
+
+// quietLog is set by -q, which drops every line below slog.LevelError except
+// the summaryEvents.
+var quietLog bool
+
+// summaryEvents are the events still logged with -q, the final summary and
+// how the run ended.
+var summaryEvents = map[string]bool{"summary": true, "merge": true, "type-summary": true, "directory-summary": true, "identical": true, "size-histogram": true, "timing": true, "slow-file": true, "bench": true, "list": true, "interrupted": true, "stopped": true, "compare": true, "exit": true}
+
+// hookLog is set by -terragrunt-hook, which drops every line but fatal
+// errors, reportHook writing the results instead.
+var hookLog bool
+
+// jsonLog is set when logging in the json format, where the summary is logged
+// as events rather than printed as a table.
+var jsonLog bool
+
+// logger is where decodeTest logs, in the -log-format chosen by setupLogging.
+// It logs text until then, so flag errors read the same either way.
+var logger = slog.New(textHandler{})
+
+// setupLogging switches logger to format, text for the log lines decodeTest
+// has always written or json for one JSON object per line on stderr, logging
+// at the verbosity from -q, -v and -vv.   Text logs on a terminal are colored
+// unless noColor is set.
+func setupLogging(format string, quiet, verbose, veryVerbose, noColor bool) error {
+	if quiet && (verbose || veryVerbose) {
+		return fmt.Errorf("-q cannot be used with -v or -vv")
+	}
+	quietLog = quiet
+	switch {
+	case veryVerbose:
+		logLevel.Set(levelTrace)
+	case verbose:
+		logLevel.Set(slog.LevelDebug)
+	}
+
+	switch format {
+	case "text":
+		logger = slog.New(textHandler{})
+		useColor = colorEnabled(noColor)
+	case "json":
+		jsonLog = true
+		logger = slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel, ReplaceAttr: traceLevelName}))
+	default:
+		return fmt.Errorf("unknown -log-format %q, must be text or json", format)
+	}
+	return nil
+}
+
+// traceLevelName names levelTrace TRACE in json logs, rather than DEBUG-4.
+func traceLevelName(groups []string, attr slog.Attr) slog.Attr {
+	if attr.Key == slog.LevelKey && len(groups) == 0 && attr.Value.Any() == levelTrace {
+		attr.Value = slog.StringValue("TRACE")
+	}
+	return attr
+}
+
+// textHandler writes each record's message through the log package, with its
+// timestamp and nothing else, errors in red and warnings in yellow when
+// useColor is set.   Attributes are only kept by the json format.
+type textHandler struct{}
+
+func (textHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= logLevel.Level()
+}
+
+func (textHandler) Handle(_ context.Context, r slog.Record) error {
+	if color := levelColor(r.Level); color != "" {
+		log.Print(colorize(color, r.Message))
+		return nil
+	}
+	log.Print(r.Message)
+	return nil
+}
+
+func (h textHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h textHandler) WithGroup(string) slog.Handler { return h }
+
+// logEvent logs msg at level as the named event, like decode-failed or summary,
+// with attrs as key value pairs for the json format.
+func logEvent(level slog.Level, event, msg string, attrs ...any) {
+	if quietLog && level < slog.LevelError && !summaryEvents[event] {
+		return
+	}
+	if hookLog && event != "fatal" {
+		return
+	}
+	logger.Log(context.Background(), level, msg, append([]any{"event", event}, attrs...)...)
+}
+
+// logEnabled reports whether lines at level are logged, so callbacks that only
+// log can be left unset.
+func logEnabled(level slog.Level) bool {
+	return logger.Enabled(context.Background(), level)
+}
+
+// logf logs a formatted informational message as the named event.
+func logf(event, format string, args ...any) {
+	logEvent(slog.LevelInfo, event, fmt.Sprintf(format, args...))
+}
+
+// fatalf logs a formatted error and exits 1, like log.Fatalf.
+func fatalf(format string, args ...any) {
+	logEvent(slog.LevelError, "fatal", fmt.Sprintf(format, args...))
+	exit(1)
+}
+
+// logResult logs msg about result at level, with its status as the event and
+// its file, position and error as fields.
+func logResult(level slog.Level, msg string, result decodecheck.Result) {
+	attrs := []any{"file", result.Path, "type", result.Type}
+	if result.Line > 0 {
+		attrs = append(attrs, "line", result.Line, "column", result.Column)
+	}
+	if result.Err != nil {
+		attrs = append(attrs, "error", result.Err.Error())
+	}
+	logEvent(level, result.Status.String(), msg, attrs...)
+}
@@ -0,0 +1,146 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/JasonPodgorny/terraformDecodeTest/pkg/decodecheck"
+)
+
+// markdownMaxBytes is about how long a -markdown comment may get before the
+// remaining failed files are only counted, under the 65536 characters GitHub
+// allows in a comment.
+const markdownMaxBytes = 60000
+
+// writeMarkdownReport writes report to name, or stdout for -, as a Markdown
+// comment for Atlantis, Terraform Cloud or a pull request: a headline, the
+// counts by type and a collapsed section for each failed file, with its error
+// and the lines around it fenced.
+func writeMarkdownReport(name string, report *decodecheck.Report) error {
+	var b strings.Builder
+	icon := "✅"
+	if report.Failed() {
+		icon = "❌"
+	}
+	fmt.Fprintf(&b, "### %s %s\n\n", icon, notifyTitle(report))
+
+	b.WriteString("| Type | Files | Passed | Errors | Warnings |\n| --- | ---: | ---: | ---: | ---: |\n")
+	for _, fileType := range append(report.Counts.Types(), "total") {
+		label := fileType
+		if fileType == "total" {
+			label = "**total**"
+		}
+		files, errors := report.Counts.Files(fileType), report.Counts.Errors(fileType)
+		fmt.Fprintf(&b, "| %s | %d | %d | %d | %d |\n", label, files, files-errors, errors, report.Counts.WarningFiles(fileType))
+	}
+	if len(report.WalkErrors) > 0 {
+		fmt.Fprintf(&b, "\n%d paths could not be read:\n\n%s", len(report.WalkErrors), fenced("text", joinErrors(report.WalkErrors)))
+	}
+
+	// A Collapsed Section For Each Failed File, Counting Those Past The Size Limit
+	var omitted int
+	for _, result := range report.Results {
+		if !result.Failed() {
+			continue
+		}
+		if b.Len() > markdownMaxBytes {
+			omitted++
+			continue
+		}
+		message := firstLine(result.Err)
+		full := message
+		if result.Err != nil {
+			full = result.Err.Error()
+		}
+		if message == "" {
+			message, full = result.Status.String(), result.Status.String()
+		}
+		fmt.Fprintf(&b, "\n<details><summary><code>%s</code> %s</summary>\n\n", html.EscapeString(result.Location()), html.EscapeString(message))
+		b.WriteString(fenced("text", full))
+		if result.Line > 0 {
+			if excerpt := errorExcerpt(result); len(excerpt) > 0 {
+				lines := make([]string, len(excerpt))
+				for i, line := range excerpt {
+					marker := " "
+					if line.Error {
+						marker = ">"
+					}
+					lines[i] = fmt.Sprintf("%s %4d | %s", marker, line.Number, line.Text)
+				}
+				b.WriteString("\n" + fenced(result.Type, strings.Join(lines, "\n")))
+			}
+		}
+		b.WriteString("\n</details>\n")
+	}
+	if omitted > 0 {
+		fmt.Fprintf(&b, "\nAnd %d more failed files.\n", omitted)
+	}
+
+	return writeOutput(name, b.String())
+}
+
+// fenced returns text in a Markdown code block with the info string lang,
+// fenced with more backticks than any run of them in text.
+func fenced(lang, text string) string {
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + strings.TrimRight(text, "\n") + "\n" + fence + "\n"
+}
+
+// joinErrors returns the messages of errs, one per line.
+func joinErrors(errs []error) string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// runTaskResult is the body of the PATCH to a Terraform Cloud run task's
+// callback URL, passing or failing the run.
+type runTaskResult struct {
+	Data struct {
+		Type       string `json:"type"`
+		Attributes struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+			URL     string `json:"url,omitempty"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+// writeRunTaskResult writes the Terraform Cloud run task result for report to
+// name, or stdout for -, linking to reportURL when set.
+func writeRunTaskResult(name, reportURL string, report *decodecheck.Report) error {
+	var result runTaskResult
+	result.Data.Type = "task-results"
+	result.Data.Attributes.Status = "passed"
+	if report.Failed() {
+		result.Data.Attributes.Status = "failed"
+	}
+	result.Data.Attributes.Message = notifyTitle(report)
+	result.Data.Attributes.URL = reportURL
+	body, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeOutput(name, string(body)+"\n")
+}
+
+// writeOutput writes text to the file name, or to stdout for -.
+func writeOutput(name, text string) error {
+	if name == "-" {
+		_, err := io.WriteString(os.Stdout, text)
+		return err
+	}
+	return os.WriteFile(name, []byte(text), 0o644)
+}
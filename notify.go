@@ -0,0 +1,199 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/JasonPodgorny/terraformDecodeTest/pkg/decodecheck"
+)
+
+// notifyTopFiles is how many failed files a -notify-webhook message names.
+const notifyTopFiles = 10
+
+// notifyTimeout is how long posting to a -notify-webhook may take.
+const notifyTimeout = 30 * time.Second
+
+// ciRunURL returns the URL of the CI job running decodeTest, from the variables
+// GitHub Actions, GitLab CI, Jenkins, CircleCI, Buildkite and Azure Pipelines
+// set, or "" outside CI.
+func ciRunURL() string {
+	switch {
+	case os.Getenv("GITHUB_RUN_ID") != "":
+		return fmt.Sprintf("%s/%s/actions/runs/%s", os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID"))
+	case os.Getenv("CI_JOB_URL") != "":
+		return os.Getenv("CI_JOB_URL")
+	case os.Getenv("BUILD_URL") != "":
+		return os.Getenv("BUILD_URL")
+	case os.Getenv("CIRCLE_BUILD_URL") != "":
+		return os.Getenv("CIRCLE_BUILD_URL")
+	case os.Getenv("BUILDKITE_BUILD_URL") != "":
+		return os.Getenv("BUILDKITE_BUILD_URL")
+	case os.Getenv("SYSTEM_COLLECTIONURI") != "" && os.Getenv("BUILD_BUILDID") != "":
+		return fmt.Sprintf("%s%s/_build/results?buildId=%s", os.Getenv("SYSTEM_COLLECTIONURI"), url.PathEscape(os.Getenv("SYSTEM_TEAMPROJECT")), os.Getenv("BUILD_BUILDID"))
+	}
+	return ""
+}
+
+// webhookMarkup is how a chat service formats messages.
+type webhookMarkup struct {
+	escape    func(string) string
+	bold      func(string) string
+	link      func(text, url string) string
+	lineBreak string
+}
+
+var (
+	slackMarkup = webhookMarkup{
+		escape:    strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace,
+		bold:      func(s string) string { return "*" + s + "*" },
+		link:      func(text, url string) string { return "<" + url + "|" + text + ">" },
+		lineBreak: "\n",
+	}
+	teamsMarkup = webhookMarkup{
+		escape:    func(s string) string { return s },
+		bold:      func(s string) string { return "**" + s + "**" },
+		link:      func(text, url string) string { return "[" + text + "](" + url + ")" },
+		lineBreak: "\n\n",
+	}
+)
+
+// notifyWebhook posts a summary of report to the Slack or Teams incoming
+// webhook at webhookURL: whether it passed, the error counts by type, the first
+// failed files and a link to reportURL when set.   Teams webhooks are told
+// apart by their host, Workflows ones get an Adaptive Card and the older
+// connectors a MessageCard, anything else gets Slack's format, which Mattermost
+// and Rocket.Chat take too.
+func notifyWebhook(ctx context.Context, webhookURL, reportURL string, roots []string, report *decodecheck.Report) error {
+	parsed, err := url.Parse(webhookURL)
+	if err != nil {
+		return err
+	}
+	host := parsed.Hostname()
+
+	var payload any
+	switch {
+	case strings.HasSuffix(host, ".logic.azure.com") || strings.HasSuffix(host, ".powerplatform.com"):
+		payload = map[string]any{
+			"type": "message",
+			"attachments": []any{map[string]any{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content": map[string]any{
+					"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+					"type":    "AdaptiveCard",
+					"version": "1.4",
+					"body":    []any{map[string]any{"type": "TextBlock", "text": notifyText(teamsMarkup, reportURL, roots, report), "wrap": true}},
+				},
+			}},
+		}
+	case strings.HasSuffix(host, ".office.com"):
+		color := "2EB886"
+		if report.Failed() {
+			color = "D9534F"
+		}
+		payload = map[string]any{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    notifyTitle(report),
+			"themeColor": color,
+			"text":       notifyText(teamsMarkup, reportURL, roots, report),
+		}
+	default:
+		payload = map[string]any{"text": notifyText(slackMarkup, reportURL, roots, report)}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, strings.Join(strings.Fields(string(message)), " "))
+	}
+	return nil
+}
+
+// notifyTitle is the first line of a -notify-webhook message.
+func notifyTitle(report *decodecheck.Report) string {
+	files := report.Counts.Files("total")
+	title := fmt.Sprintf("decodeTest passed, %d files", files)
+	if report.Failed() {
+		title = fmt.Sprintf("decodeTest failed, %d of %d files", report.Counts.Errors("total"), files)
+	}
+	switch {
+	case report.TimedOut:
+		title += " (timed out, partial)"
+	case report.Interrupted:
+		title += " (interrupted, partial)"
+	case report.Stopped:
+		title += " (stopped at the error limit, partial)"
+	case report.OverBudget:
+		title += " (aborted over budget, partial)"
+	}
+	return title
+}
+
+// notifyText is a -notify-webhook message in markup.
+func notifyText(markup webhookMarkup, reportURL string, roots []string, report *decodecheck.Report) string {
+	lines := []string{markup.bold(notifyTitle(report)) + " in `" + markup.escape(strings.Join(roots, "`, `")) + "`"}
+
+	var counts []string
+	for _, fileType := range report.Counts.Types() {
+		if errors := report.Counts.Errors(fileType); errors > 0 {
+			counts = append(counts, fmt.Sprintf("%s %d of %d", fileType, errors, report.Counts.Files(fileType)))
+		}
+	}
+	if len(counts) > 0 {
+		lines = append(lines, "Errors by type: "+strings.Join(counts, ", "))
+	}
+	if len(report.WalkErrors) > 0 {
+		lines = append(lines, fmt.Sprintf("%d paths could not be read", len(report.WalkErrors)))
+	}
+
+	// The First Failed Files By Path, With The First Line Of Each Error
+	var failed []string
+	for _, result := range report.Results {
+		if !result.Failed() {
+			continue
+		}
+		if len(failed) == notifyTopFiles {
+			failed = append(failed, fmt.Sprintf("and %d more", report.Counts.Errors("total")-notifyTopFiles))
+			break
+		}
+		message := firstLine(result.Err)
+		if message == "" {
+			message = result.Status.String()
+		}
+		failed = append(failed, "• `"+markup.escape(result.Location())+"` "+markup.escape(message))
+	}
+	if len(failed) > 0 {
+		lines = append(lines, strings.Join(failed, markup.lineBreak))
+	}
+
+	if reportURL != "" {
+		lines = append(lines, markup.link("Full report", reportURL))
+	}
+	return strings.Join(lines, markup.lineBreak)
+}
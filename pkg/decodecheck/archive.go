@@ -0,0 +1,215 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	"testing/fstest"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// archiveSuffixes are the file names opened as archives with Options.Archives.
+var archiveSuffixes = []string{".zip", ".tar", ".tar.gz", ".tgz"}
+
+// isArchive reports whether the file name is an archive Options.Archives opens.
+func isArchive(name string) bool {
+	name = strings.ToLower(name)
+	for _, suffix := range archiveSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxArchiveBytes caps how many bytes an archive may expand to when
+// Options.MaxTotalBytes does not, so a small compressed bomb cannot exhaust
+// memory.
+const maxArchiveBytes = 1 << 30
+
+// openArchive reads the archive src, named name, as a filesystem.   Zip
+// entries are decompressed as they are read, tar entries all at once.   The
+// entries may expand to at most maxTotal bytes between them, and tar entries
+// over maxEntry bytes, if positive, are kept only as their size, to be skipped
+// or reported as TooLarge like files over Options.MaxSize.
+func openArchive(name string, src []byte, maxEntry, maxTotal int64) (fs.FS, error) {
+	if strings.HasSuffix(strings.ToLower(name), ".zip") {
+		zipReader, err := zip.NewReader(bytes.NewReader(src), int64(len(src)))
+		if err != nil {
+			return nil, err
+		}
+
+		// Zip Readers Fail Entries Expanding Past The Size In Their Header, So The Headers Can Be Trusted
+		var total uint64
+		for _, file := range zipReader.File {
+			if maxEntry > 0 && file.UncompressedSize64 > uint64(maxEntry) {
+				continue
+			}
+			if total += file.UncompressedSize64; total > uint64(maxTotal) {
+				return nil, fmt.Errorf("archive expands to more than %d bytes", maxTotal)
+			}
+		}
+		return zipReader, nil
+	}
+
+	var reader io.Reader = bytes.NewReader(src)
+	if !strings.HasSuffix(strings.ToLower(name), ".tar") {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+	tarReader := tar.NewReader(reader)
+	fsys := archiveFS{files: fstest.MapFS{}, oversize: map[string]int64{}}
+	var total int64
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return fsys, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := path.Clean(strings.TrimPrefix(header.Name, "/"))
+		if header.Typeflag != tar.TypeReg || !fs.ValidPath(name) {
+			continue // directories are implied, links and devices are not checked
+		}
+		if maxEntry > 0 && header.Size > maxEntry {
+			fsys.files[name] = &fstest.MapFile{Mode: 0o444, ModTime: header.ModTime}
+			fsys.oversize[name] = header.Size
+			continue
+		}
+		content, err := io.ReadAll(io.LimitReader(tarReader, maxTotal-total+1))
+		if err != nil {
+			return nil, err
+		}
+		if total += int64(len(content)); total > maxTotal {
+			return nil, fmt.Errorf("archive expands to more than %d bytes", maxTotal)
+		}
+		fsys.files[name] = &fstest.MapFile{Data: content, Mode: 0o444, ModTime: header.ModTime}
+	}
+}
+
+// archiveFS is the files of a tar archive, those in oversize known by their
+// size alone, never having been read.   Opening them fails, walks see them at
+// their size in the archive so they are skipped or reported as TooLarge.
+type archiveFS struct {
+	files    fstest.MapFS
+	oversize map[string]int64
+}
+
+func (fsys archiveFS) Open(name string) (fs.File, error) {
+	if err := fsys.unread(name); err != nil {
+		return nil, err
+	}
+	return fsys.files.Open(name)
+}
+
+func (fsys archiveFS) ReadFile(name string) ([]byte, error) {
+	if err := fsys.unread(name); err != nil {
+		return nil, err
+	}
+	return fsys.files.ReadFile(name)
+}
+
+func (fsys archiveFS) Stat(name string) (fs.FileInfo, error) {
+	info, err := fsys.files.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	return fsys.sized(name, info), nil
+}
+
+func (fsys archiveFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fsys.files.ReadDir(name)
+	for i, entry := range entries {
+		if _, ok := fsys.oversize[path.Join(name, entry.Name())]; !ok {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = fs.FileInfoToDirEntry(fsys.sized(path.Join(name, entry.Name()), info))
+	}
+	return entries, err
+}
+
+// unread returns the error opening name fails with if it is oversize.
+func (fsys archiveFS) unread(name string) error {
+	if size, ok := fsys.oversize[name]; ok {
+		return &fs.PathError{Op: "open", Path: name, Err: fmt.Errorf("file is %d bytes, too large to read from the archive", size)}
+	}
+	return nil
+}
+
+// sized returns info with the size of name in the archive, if it is oversize.
+func (fsys archiveFS) sized(name string, info fs.FileInfo) fs.FileInfo {
+	if size, ok := fsys.oversize[name]; ok {
+		return oversizeInfo{FileInfo: info, size: size}
+	}
+	return info
+}
+
+// oversizeInfo describes an oversize archiveFS file.
+type oversizeInfo struct {
+	fs.FileInfo
+	size int64
+}
+
+func (fi oversizeInfo) Size() int64 { return fi.size }
+
+// walkArchive opens the archive name in root and walks the files in it like a
+// directory, displaying them as archive.zip!inner/path.yaml.   Archives that
+// cannot be opened are reported as ReadFailed.
+func (r *Runner) walkArchive(ctx context.Context, root searchRoot, name string, size int64, n *sync.WaitGroup, events chan<- event) {
+	defer n.Done()
+	ctx, span := tracer.Start(ctx, "open archive", trace.WithAttributes(attribute.String("decodetest.path", root.display(name))))
+	defer span.End()
+
+	if !acquire(ctx, r.ioSema) {
+		r.notScanned(root, name)
+		return
+	}
+	src, err := r.readFile(ctx, root, name)
+	var fsys fs.FS
+	if err == nil {
+		maxTotal := r.opts.MaxTotalBytes
+		if maxTotal <= 0 {
+			maxTotal = maxArchiveBytes
+		}
+		if fsys, err = openArchive(name, src, r.opts.MaxSize, maxTotal); err != nil {
+			err = fmt.Errorf("reading archive: %v", err)
+		}
+	} else {
+		err = displayError(root, err)
+	}
+	release(r.ioSema)
+
+	if err != nil {
+		if !r.sendFound(ctx, events, size) {
+			r.notScanned(root, name)
+			return
+		}
+		r.sendResult(events, Result{Path: root.display(name), Size: size, Type: "archive", Status: ReadFailed, Err: err})
+		return
+	}
+
+	n.Add(1)
+	r.walkDir(ctx, searchRoot{fsys: fsys, outer: &root, archive: name}, ".", nil, n, events)
+}
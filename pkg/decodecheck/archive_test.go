@@ -0,0 +1,127 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// bombSize is how many bytes of zeros the large entry in each test archive
+// expands to, from a few kilobytes compressed.
+const bombSize = 8 << 20
+
+// testTarGz returns a tar.gz of a small valid JSON file and a large one.
+func testTarGz(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, data := range map[string][]byte{"ok.json": []byte(`{"a": 1}`), "big.json": make([]byte, bombSize)} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// testZip returns a zip of a small valid JSON file and a large one.
+func testZip(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range map[string][]byte{"ok.json": []byte(`{"a": 1}`), "big.json": make([]byte, bombSize)} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestArchiveLimits(t *testing.T) {
+	fsys := fstest.MapFS{
+		"bundle.tgz": {Data: testTarGz(t)},
+		"bundle.zip": {Data: testZip(t)},
+	}
+	tests := []struct {
+		name  string
+		setup func(*Options)
+		want  map[string]Status
+		err   string
+	}{
+		{
+			name: "over the total budget",
+			setup: func(opts *Options) {
+				opts.MaxTotalBytes = 1 << 20
+			},
+			want: map[string]Status{"bundle.tgz": ReadFailed, "bundle.zip": ReadFailed},
+			err:  "archive expands to more than 1048576 bytes",
+		},
+		{
+			name: "over the file size",
+			setup: func(opts *Options) {
+				opts.MaxSize = 1 << 20
+				opts.ReportOversize = true
+			},
+			want: map[string]Status{
+				"bundle.tgz!ok.json": Passed, "bundle.tgz!big.json": TooLarge,
+				"bundle.zip!ok.json": Passed, "bundle.zip!big.json": TooLarge,
+			},
+		},
+		{
+			name: "over the file size skipped",
+			setup: func(opts *Options) {
+				opts.MaxSize = 1 << 20
+			},
+			want: map[string]Status{"bundle.tgz!ok.json": Passed, "bundle.zip!ok.json": Passed},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.FS = fsys
+			opts.Archives = true
+			tt.setup(&opts)
+			report := NewRunner(opts).Run(context.Background())
+
+			got := map[string]Status{}
+			for _, result := range report.Results {
+				got[result.Path] = result.Status
+				if tt.err != "" && (result.Err == nil || !strings.Contains(result.Err.Error(), tt.err)) {
+					t.Errorf("%s: err = %v, want it to contain %q", result.Path, result.Err, tt.err)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("results = %v, want %v", got, tt.want)
+			}
+			for path, status := range tt.want {
+				if gotStatus, ok := got[path]; !ok || gotStatus != status {
+					t.Errorf("%s: status = %v, want %v", path, gotStatus, status)
+				}
+			}
+		})
+	}
+}
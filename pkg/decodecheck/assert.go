@@ -0,0 +1,210 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// RequireRule returns a CheckRule requiring the values of files matching
+// pattern to be objects with every one of keys at the top level, a lightweight
+// policy for files that do not warrant a schema.
+func RequireRule(pattern string, keys []string) CheckRule {
+	return CheckRule{
+		Pattern:  pattern,
+		Category: "require",
+		Name:     "require=" + strings.Join(keys, ","),
+		Check: func(path string, value cty.Value) error {
+			return requireKeys(value, keys)
+		},
+	}
+}
+
+// requireKeys reports the keys missing from value, in the order given.
+func requireKeys(value cty.Value, keys []string) error {
+	if !value.Type().IsObjectType() && !value.Type().IsMapType() {
+		return fmt.Errorf("expected an object with keys %s, got %s", strings.Join(keys, ", "), value.Type().FriendlyName())
+	}
+	present := value.AsValueMap()
+	var missing []string
+	for _, key := range keys {
+		if _, ok := present[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("missing required keys: %s", strings.Join(missing, ", "))
+}
+
+// assertKinds are the broad kinds an assertion can name in place of a type
+// constraint, each reporting whether a type is of that kind.
+var assertKinds = map[string]func(cty.Type) bool{
+	"string": func(ty cty.Type) bool { return ty == cty.String },
+	"number": func(ty cty.Type) bool { return ty == cty.Number },
+	"bool":   func(ty cty.Type) bool { return ty == cty.Bool },
+	"list":   func(ty cty.Type) bool { return ty.IsListType() || ty.IsTupleType() || ty.IsSetType() },
+	"map":    func(ty cty.Type) bool { return ty.IsMapType() || ty.IsObjectType() },
+	"object": func(ty cty.Type) bool { return ty.IsMapType() || ty.IsObjectType() },
+	"any":    func(ty cty.Type) bool { return true },
+}
+
+// AssertRule returns a CheckRule for an assertion like vpc.cidr=string or
+// subnets[0].azs=list(string), requiring the value at the path to exist and be
+// of the kind named, one of string, number, bool, list, map, object or any, or
+// otherwise to convert to the Terraform type constraint given.
+func AssertRule(pattern, assertion string) (CheckRule, error) {
+	pathExpr, typeExpr, ok := strings.Cut(assertion, "=")
+	pathExpr, typeExpr = strings.TrimSpace(pathExpr), strings.TrimSpace(typeExpr)
+	if !ok || pathExpr == "" || typeExpr == "" {
+		return CheckRule{}, fmt.Errorf("expected path=type, got %q", assertion)
+	}
+	steps, err := parseAssertPath(pathExpr)
+	if err != nil {
+		return CheckRule{}, err
+	}
+
+	var check func(cty.Value) error
+	if isKind, ok := assertKinds[typeExpr]; ok {
+		check = func(value cty.Value) error {
+			if !isKind(value.Type()) {
+				return fmt.Errorf("want %s, got %s", typeExpr, value.Type().FriendlyName())
+			}
+			return nil
+		}
+	} else {
+		ty, defaults, err := ParseType(typeExpr)
+		if err != nil {
+			return CheckRule{}, err
+		}
+		check = func(value cty.Value) error {
+			return conformsTo(value, ty, defaults, typeExpr)
+		}
+	}
+
+	return CheckRule{
+		Pattern:  pattern,
+		Category: "assert",
+		Name:     "assert=" + assertion,
+		Check: func(path string, value cty.Value) error {
+			value, err := lookupPath(value, steps)
+			if err == nil {
+				err = check(value)
+			}
+			if err != nil {
+				return fmt.Errorf("assertion %s failed: %v", assertion, err)
+			}
+			return nil
+		},
+	}, nil
+}
+
+// assertStep is one step of an assertion path, an attribute or map key, or an
+// index into a list when index is at least 0.
+type assertStep struct {
+	key   string
+	index int
+}
+
+// parseAssertPath parses a dotted path with optional [n] indexes, like
+// subnets[0].cidr, into its steps.   A leading dot is allowed, like jq, and a
+// lone dot is the whole value.
+func parseAssertPath(pathExpr string) ([]assertStep, error) {
+	if pathExpr == "." {
+		return nil, nil
+	}
+	var steps []assertStep
+	for _, part := range strings.Split(strings.TrimPrefix(pathExpr, "."), ".") {
+		key, indexes, _ := strings.Cut(part, "[")
+		if key != "" {
+			steps = append(steps, assertStep{key: key, index: -1})
+		} else if len(steps) == 0 || !strings.Contains(part, "[") {
+			return nil, fmt.Errorf("invalid path %q", pathExpr)
+		}
+		for indexes != "" {
+			index, rest, ok := strings.Cut(indexes, "]")
+			n, err := strconv.Atoi(index)
+			if !ok || err != nil || n < 0 || (rest != "" && rest[0] != '[') {
+				return nil, fmt.Errorf("invalid path %q", pathExpr)
+			}
+			steps = append(steps, assertStep{index: n})
+			indexes = strings.TrimPrefix(rest, "[")
+		}
+	}
+	return steps, nil
+}
+
+// lookupPath follows steps from value, returning the value they lead to or an
+// error naming the first step that could not be followed.
+func lookupPath(value cty.Value, steps []assertStep) (cty.Value, error) {
+	var at strings.Builder
+	for _, step := range steps {
+		if step.index < 0 {
+			if at.Len() > 0 {
+				at.WriteByte('.')
+			}
+			at.WriteString(step.key)
+		} else {
+			fmt.Fprintf(&at, "[%d]", step.index)
+		}
+
+		ty := value.Type()
+		switch {
+		case value.IsNull():
+			return cty.NilVal, fmt.Errorf("%s: not found", at.String())
+		case step.index < 0 && (ty.IsObjectType() || ty.IsMapType()):
+			next, ok := value.AsValueMap()[step.key]
+			if !ok {
+				return cty.NilVal, fmt.Errorf("%s: not found", at.String())
+			}
+			value = next
+		case step.index >= 0 && (ty.IsListType() || ty.IsTupleType()):
+			if step.index >= value.LengthInt() {
+				return cty.NilVal, fmt.Errorf("%s: not found, only %d elements", at.String(), value.LengthInt())
+			}
+			value = value.Index(cty.NumberIntVal(int64(step.index)))
+		default:
+			return cty.NilVal, fmt.Errorf("%s: cannot look into %s", at.String(), ty.FriendlyName())
+		}
+	}
+	return value, nil
+}
+
+// ValuePath is a path into decoded values, like vpc.subnets[0].cidr, for
+// Options.Unique and Options.Query.
+type ValuePath struct {
+	// Path is the path as given.
+	Path  string
+	steps []assertStep
+}
+
+// ParseValuePath parses a dotted path with optional [n] indexes, like
+// account_id or .subnets[0].cidr, in the syntax of AssertRule.
+func ParseValuePath(pathExpr string) (ValuePath, error) {
+	steps, err := parseAssertPath(pathExpr)
+	if err != nil {
+		return ValuePath{}, err
+	}
+	return ValuePath{Path: pathExpr, steps: steps}, nil
+}
+
+// lookupJSON returns the value at p in value normalized to JSON, with object
+// keys in order, or false if value has nothing there.
+func (p ValuePath) lookupJSON(value cty.Value) (string, bool) {
+	at, err := lookupPath(value, p.steps)
+	if err != nil || at.IsNull() || !at.IsWhollyKnown() {
+		return "", false
+	}
+	normalized, err := ctyjson.SimpleJSONValue{Value: at}.MarshalJSON()
+	if err != nil {
+		return "", false
+	}
+	return string(normalized), true
+}
@@ -0,0 +1,117 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"regexp"
+	"sort"
+)
+
+// baselineVersion is the format version written to baseline files.
+const baselineVersion = 1
+
+// Baseline is a set of accepted failures, by file path and a fingerprint of
+// the failure, so a tree with known bad files can be checked for new ones.
+type Baseline struct {
+	failures map[string]map[string]bool // path to fingerprints
+}
+
+// baselineFile is the JSON form of a Baseline.   Errors are kept alongside the
+// fingerprints so reviewers can see what was accepted.
+type baselineFile struct {
+	Version  int               `json:"version"`
+	Failures []baselineFailure `json:"failures"`
+}
+
+type baselineFailure struct {
+	Path        string `json:"path"`
+	Fingerprint string `json:"fingerprint"`
+	Status      Status `json:"status"`
+	Error       string `json:"error,omitempty"`
+}
+
+// LoadBaseline reads the baseline file at path.
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file baselineFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	baseline := &Baseline{failures: make(map[string]map[string]bool)}
+	for _, failure := range file.Failures {
+		baseline.add(failure.Path, failure.Fingerprint)
+	}
+	return baseline, nil
+}
+
+// SaveBaseline writes every failure in results, including those already
+// accepted by a baseline, to a baseline file at path, returning how many.
+func SaveBaseline(path string, results []Result) (int, error) {
+	file := baselineFile{Version: baselineVersion, Failures: []baselineFailure{}}
+	for _, result := range results {
+		if !result.Failed() && !result.Baselined {
+			continue
+		}
+		failure := baselineFailure{Path: result.Path, Fingerprint: fingerprint(result), Status: result.Status}
+		if result.Err != nil {
+			failure.Error = result.Err.Error()
+		}
+		file.Failures = append(file.Failures, failure)
+	}
+	sort.Slice(file.Failures, func(i, j int) bool {
+		if file.Failures[i].Path != file.Failures[j].Path {
+			return file.Failures[i].Path < file.Failures[j].Path
+		}
+		return file.Failures[i].Fingerprint < file.Failures[j].Fingerprint
+	})
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	return len(file.Failures), os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+func (b *Baseline) add(path, fingerprint string) {
+	if b.failures[path] == nil {
+		b.failures[path] = make(map[string]bool)
+	}
+	b.failures[path][fingerprint] = true
+}
+
+// accepts reports whether the failure in result is in the baseline.
+func (b *Baseline) accepts(result Result) bool {
+	return b.failures[result.Path][fingerprint(result)]
+}
+
+// fileLinePattern finds file:line:column positions, as in CUE errors.
+var fileLinePattern = regexp.MustCompile(`:\d+:\d+`)
+
+// fingerprint identifies the failure in result by its status and error, with
+// line and column numbers left out so edits elsewhere in the file do not make
+// an accepted failure look new.
+func fingerprint(result Result) string {
+	message := ""
+	if result.Err != nil {
+		message = linePattern.ReplaceAllString(result.Err.Error(), "line")
+		message = fileLinePattern.ReplaceAllString(message, "")
+	}
+	sum := sha256.Sum256([]byte(result.Status.String() + "\x00" + message))
+	return hex.EncodeToString(sum[:8])
+}
+
+// applyBaseline marks result Baselined if it failed the way Options.Baseline
+// accepts, so it no longer counts as failed.
+func (r *Runner) applyBaseline(result *Result) {
+	if r.opts.Baseline != nil && result.Failed() && r.opts.Baseline.accepts(*result) {
+		result.Baselined = true
+	}
+}
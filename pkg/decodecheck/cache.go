@@ -0,0 +1,161 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// cacheVersion is mixed into every content hash, bump it whenever a decoder
+// changes what it accepts or how it words errors so stale results are not
+// reused.
+const cacheVersion = "5"
+
+// Cache remembers decode results by file path and content hash, so repeated
+// runs only decode files that changed.   It is safe for concurrent use.
+type Cache struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]cacheEntry
+	dirty   bool
+}
+
+// cacheEntry is the stored result for one path.
+type cacheEntry struct {
+	Hash      string            `json:"sha256"`
+	Status    Status            `json:"status"`
+	Error     string            `json:"error,omitempty"`
+	Line      int               `json:"line,omitempty"`
+	Column    int               `json:"column,omitempty"`
+	CtyType   string            `json:"ctyType,omitempty"`
+	Shape     *Shape            `json:"shape,omitempty"`
+	Terraform *TerraformSummary `json:"terraform,omitempty"`
+	Value     string            `json:"valueHash,omitempty"`
+	Unique    map[string]string `json:"uniqueValues,omitempty"`
+	Query     string            `json:"query,omitempty"`
+	Diff      string            `json:"formatDiff,omitempty"`
+	Warnings  []string          `json:"warnings,omitempty"`
+}
+
+// DefaultCachePath returns the cache file used when none is given,
+// results.json under decodetest in the user cache directory.
+func DefaultCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "decodetest", "results.json"), nil
+}
+
+// OpenCache loads the cache stored at path, a missing file is an empty cache.
+// If the file cannot be parsed the returned cache is empty, along with the
+// error, and will overwrite the file when saved.
+func OpenCache(path string) (*Cache, error) {
+	cache := &Cache{path: path, entries: make(map[string]cacheEntry)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return cache, err
+	}
+	if err := json.Unmarshal(data, &cache.entries); err != nil {
+		cache.entries = make(map[string]cacheEntry)
+		return cache, err
+	}
+	return cache, nil
+}
+
+// NewCache returns an empty cache kept only in memory, which Save leaves alone,
+// for long running processes like a daemon.
+func NewCache() *Cache {
+	return &Cache{entries: make(map[string]cacheEntry)}
+}
+
+// Save writes the cache back to its file if anything changed, creating the
+// directory if needed.   The file is replaced atomically so an interrupted save
+// never leaves a truncated cache behind.
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty || c.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+// lookup sets the stored status, error, error position and warnings for key
+// on result if its hash matches.
+func (c *Cache) lookup(key, hash string, result *Result) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || entry.Hash != hash {
+		return false
+	}
+	result.Status, result.Err, result.Warnings = entry.Status, nil, entry.Warnings
+	result.Line, result.Column = entry.Line, entry.Column
+	result.TypeSignature, result.Shape, result.Terraform = entry.CtyType, entry.Shape, entry.Terraform
+	result.ValueHash, result.UniqueValues, result.QueryResult = entry.Value, entry.Unique, entry.Query
+	result.FormatDiff = entry.Diff
+	if entry.Error != "" {
+		result.Err = errors.New(entry.Error)
+	}
+	return true
+}
+
+// store records the status, error, error position and warnings of result for
+// key, replacing any older entry.
+func (c *Cache) store(key, hash string, result Result) {
+	entry := cacheEntry{Hash: hash, Status: result.Status, Line: result.Line, Column: result.Column, CtyType: result.TypeSignature, Shape: result.Shape, Terraform: result.Terraform, Value: result.ValueHash, Unique: result.UniqueValues, Query: result.QueryResult, Diff: result.FormatDiff, Warnings: result.Warnings}
+	if result.Err != nil {
+		entry.Error = result.Err.Error()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = entry
+	c.dirty = true
+}
+
+// contentHash hashes src along with everything else that decides its result,
+// the decoder name and settings described by tag.
+func contentHash(decoder, tag string, src []byte) string {
+	h := sha256.New()
+	for _, part := range []string{cacheVersion, decoder, tag} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	h.Write(src)
+	return hex.EncodeToString(h.Sum(nil))
+}
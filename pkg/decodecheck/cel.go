@@ -0,0 +1,68 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// CELRule returns a CheckRule for a CEL expression like
+// has(doc.vpc) && doc.vpc.cidr.matches("^10\\."), which must be true of the
+// values of files matching pattern.   The expression sees the value as doc and
+// the file's path as path, and fails the file when it is false or cannot be
+// evaluated, like when it selects a key the value does not have.
+func CELRule(pattern, expression string) (CheckRule, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("doc", cel.DynType),
+		cel.Variable("path", cel.StringType),
+		ext.Strings(),
+	)
+	if err != nil {
+		return CheckRule{}, err
+	}
+	ast, issues := env.Compile(expression)
+	if issues.Err() != nil {
+		return CheckRule{}, issues.Err()
+	}
+	if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+		return CheckRule{}, fmt.Errorf("expression %s is a %s, not a bool", expression, ast.OutputType())
+	}
+	program, err := env.Program(ast)
+	if err != nil {
+		return CheckRule{}, err
+	}
+
+	return CheckRule{
+		Pattern:  pattern,
+		Category: "cel",
+		Name:     "cel=" + expression,
+		Check: func(path string, value cty.Value) error {
+			buf, err := ctyjson.Marshal(value, value.Type())
+			if err != nil {
+				return err
+			}
+			var doc any
+			if err := json.Unmarshal(buf, &doc); err != nil {
+				return err
+			}
+
+			out, _, err := program.Eval(map[string]any{"doc": doc, "path": path})
+			if err != nil {
+				return fmt.Errorf("expression %s failed: %v", expression, err)
+			}
+			if result, ok := out.Value().(bool); !ok {
+				return fmt.Errorf("expression %s failed: got %s, want bool", expression, out.Type())
+			} else if !result {
+				return fmt.Errorf("expression %s is false", expression)
+			}
+			return nil
+		},
+	}, nil
+}
@@ -0,0 +1,126 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ValueCheck inspects the value a file decoded to, with path the file's path as
+// shown in results, and returns an error describing what is wrong with it.
+type ValueCheck func(path string, value cty.Value) error
+
+// CheckRule runs Check on the decoded values of files matching Pattern, which
+// follows the same rules as MatchPatterns.   Name identifies the check in
+// cache keys, so it should change whenever the check's behaviour does.
+// Category, like schema, is the finding category its failures are reported
+// under in Options.Severities, check when empty.
+type CheckRule struct {
+	Pattern  string
+	Name     string
+	Category string
+	Check    ValueCheck
+}
+
+// compiledCheck is a CheckRule with its pattern compiled.
+type compiledCheck struct {
+	pattern pathPattern
+	rule    CheckRule
+}
+
+// compileChecks compiles the patterns of rules, skipping any that fail.
+func compileChecks(rules []CheckRule) []compiledCheck {
+	compiled := make([]compiledCheck, 0, len(rules))
+	for _, rule := range rules {
+		if pattern, err := compilePattern(rule.Pattern); err == nil {
+			compiled = append(compiled, compiledCheck{pattern: pattern, rule: rule})
+		}
+	}
+	return compiled
+}
+
+// checksFor returns every check rule whose pattern matches rel, the slash
+// separated path from the root.
+func (r *Runner) checksFor(rel string) []CheckRule {
+	rel = r.opts.canonicalName(rel)
+	var rules []CheckRule
+	for _, check := range r.checks {
+		if check.pattern.match(rel) {
+			rules = append(rules, check.rule)
+		}
+	}
+	return rules
+}
+
+// runChecks runs rules on value, decoded from the file path by decoderName,
+// returning the failures that Options.Severities makes warnings, and every
+// other failure joined into one error.   Values of StreamDecoders
+// are checked one document at a time and null values, like those of the syntax
+// only HCL decoder, are not checked at all.
+func (r *Runner) runChecks(rules []CheckRule, decoderName, path string, value cty.Value) ([]string, error) {
+	if len(rules) == 0 || value.IsNull() || !value.IsWhollyKnown() {
+		return nil, nil
+	}
+
+	documents := []cty.Value{value}
+	stream := contains(r.opts.StreamDecoders, decoderName) && value.Type().IsTupleType()
+	if stream {
+		documents = value.AsValueSlice()
+	}
+
+	severity := func(category string) Severity {
+		return r.opts.severity(category, SeverityError)
+	}
+	var errs []error
+	var warnings []string
+	for i, document := range documents {
+		if document.IsNull() {
+			continue
+		}
+		for _, rule := range rules {
+			err := rule.Check(path, document)
+			if err == nil {
+				continue
+			}
+
+			// Split Findings Of Several Categories, Otherwise The Rule's Category Decides
+			var warning error
+			var split splitError
+			if errors.As(err, &split) {
+				err, warning = split.split(r.opts.severity)
+			} else if severity(cmp.Or(rule.Category, "check")) == SeverityWarning {
+				err, warning = nil, err
+			}
+
+			if stream && err != nil {
+				err = fmt.Errorf("document %d: %w", i+1, err)
+			}
+			if stream && warning != nil {
+				warning = fmt.Errorf("document %d: %w", i+1, warning)
+			}
+			if err != nil {
+				errs = append(errs, err)
+			}
+			if warning != nil {
+				warnings = append(warnings, warning.Error())
+			}
+		}
+	}
+	return warnings, errors.Join(errs...)
+}
+
+// checksTag describes rules for cache keys, so cached results are only reused
+// under the same checks.
+func checksTag(rules []CheckRule) string {
+	names := make([]string, len(rules))
+	for i, rule := range rules {
+		names[i] = rule.Name
+	}
+	return strings.Join(names, " ")
+}
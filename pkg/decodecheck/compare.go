@@ -0,0 +1,226 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ValueChange is one difference between two decoded values.
+type ValueChange struct {
+	// Kind is added, removed or changed.
+	Kind string `json:"kind"`
+	// Path is the dotted path to the value, like vpc.cidr or azs[1], empty
+	// for the whole value.
+	Path string `json:"path"`
+	// Before and After are the values as JSON, Before unset when added and
+	// After when removed.
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+func (c ValueChange) String() string {
+	return c.Kind + " " + formatSteps([]string{c.Path})
+}
+
+// diffJSON returns the changes from before to after, both JSON documents.
+func diffJSON(before, after []byte) ([]ValueChange, error) {
+	var beforeValue, afterValue any
+	if err := unmarshalNumbers(before, &beforeValue); err != nil {
+		return nil, err
+	}
+	if err := unmarshalNumbers(after, &afterValue); err != nil {
+		return nil, err
+	}
+	return valueChanges(beforeValue, afterValue, ""), nil
+}
+
+// unmarshalNumbers decodes JSON into v keeping numbers as json.Number, so
+// large ones compare exactly.
+func unmarshalNumbers(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// valueChanges returns how after differs from before, decoded JSON values at
+// the dotted path at, comparing objects key by key and lists index by index.
+func valueChanges(before, after any, at string) []ValueChange {
+	beforeObject, isObject := before.(map[string]any)
+	afterObject, bothObjects := after.(map[string]any)
+	if isObject && bothObjects {
+		keys := make([]string, 0, len(beforeObject)+len(afterObject))
+		for key := range beforeObject {
+			keys = append(keys, key)
+		}
+		for key := range afterObject {
+			if _, ok := beforeObject[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		var changes []ValueChange
+		for _, key := range keys {
+			changes = append(changes, elementChanges(beforeObject, afterObject, key, strings.TrimPrefix(at+"."+key, "."))...)
+		}
+		return changes
+	}
+
+	beforeList, isList := before.([]any)
+	afterList, bothLists := after.([]any)
+	if isList && bothLists {
+		var changes []ValueChange
+		for i := range max(len(beforeList), len(afterList)) {
+			path := at + "[" + strconv.Itoa(i) + "]"
+			switch {
+			case i >= len(beforeList):
+				changes = append(changes, ValueChange{Kind: "added", Path: path, After: rawJSON(afterList[i])})
+			case i >= len(afterList):
+				changes = append(changes, ValueChange{Kind: "removed", Path: path, Before: rawJSON(beforeList[i])})
+			default:
+				changes = append(changes, valueChanges(beforeList[i], afterList[i], path)...)
+			}
+		}
+		return changes
+	}
+
+	beforeJSON, afterJSON := rawJSON(before), rawJSON(after)
+	if bytes.Equal(beforeJSON, afterJSON) {
+		return nil
+	}
+	return []ValueChange{{Kind: "changed", Path: at, Before: beforeJSON, After: afterJSON}}
+}
+
+// elementChanges returns the changes to key between the objects before and
+// after, at path.
+func elementChanges(before, after map[string]any, key, path string) []ValueChange {
+	beforeValue, inBefore := before[key]
+	afterValue, inAfter := after[key]
+	switch {
+	case !inBefore:
+		return []ValueChange{{Kind: "added", Path: path, After: rawJSON(afterValue)}}
+	case !inAfter:
+		return []ValueChange{{Kind: "removed", Path: path, Before: rawJSON(beforeValue)}}
+	}
+	return valueChanges(beforeValue, afterValue, path)
+}
+
+// rawJSON encodes a decoded JSON value back to JSON, with keys in order.
+func rawJSON(v any) json.RawMessage {
+	data, _ := json.Marshal(v)
+	return data
+}
+
+// FileChange is how one file differs between the trees of a TreeDiff.
+type FileChange struct {
+	// Path is the file's path below both roots.
+	Path string `json:"path"`
+	// Kind is added or removed for files only in the after or before tree,
+	// changed when their values differ, or failed when either did not decode.
+	Kind string `json:"kind"`
+	// Changes are the differences between the values of changed files.
+	Changes []ValueChange `json:"changes,omitempty"`
+	// Error says why a failed file did not decode.
+	Error string `json:"error,omitempty"`
+}
+
+// TreeDiff is the semantic difference between two trees, from CompareTrees.
+type TreeDiff struct {
+	Before string       `json:"before"`
+	After  string       `json:"after"`
+	Files  []FileChange `json:"files"`
+}
+
+// Failed reports whether any file could not be compared.
+func (d *TreeDiff) Failed() bool {
+	for _, file := range d.Files {
+		if file.Kind == "failed" {
+			return true
+		}
+	}
+	return false
+}
+
+// CompareTrees scans the trees before and after with opts and compares the
+// values of the files at the same path below each, like staging and prod
+// inputs, listing the files added, removed, changed or failing, in order.
+// Files whose values are the same are left out however their text differs.
+// The bool is false if ctx was cancelled before both scans finished.
+func CompareTrees(ctx context.Context, opts Options, before, after string) (*TreeDiff, bool) {
+	diff := &TreeDiff{Before: before, After: after, Files: []FileChange{}}
+	trees := make([]map[string]Result, 2)
+	for i, root := range []string{before, after} {
+		treeOpts := opts
+		treeOpts.Roots, treeOpts.Files, treeOpts.Convert = []string{root}, nil, "json"
+		treeOpts.OnResult, treeOpts.OnProgress = nil, nil
+		report := NewRunner(treeOpts).Run(ctx)
+		if report.Interrupted {
+			return diff, false
+		}
+		trees[i] = make(map[string]Result, len(report.Results))
+		for _, result := range report.Results {
+			rel, err := filepath.Rel(root, result.Path)
+			if err != nil {
+				rel = result.Path
+			}
+			trees[i][filepath.ToSlash(rel)] = result
+		}
+	}
+
+	for rel, beforeResult := range trees[0] {
+		afterResult, ok := trees[1][rel]
+		if !ok {
+			diff.Files = append(diff.Files, FileChange{Path: rel, Kind: "removed"})
+			continue
+		}
+		if change, changed := compareResults(rel, beforeResult, afterResult); changed {
+			diff.Files = append(diff.Files, change)
+		}
+	}
+	for rel := range trees[1] {
+		if _, ok := trees[0][rel]; !ok {
+			diff.Files = append(diff.Files, FileChange{Path: rel, Kind: "added"})
+		}
+	}
+	sort.Slice(diff.Files, func(i, j int) bool {
+		return diff.Files[i].Path < diff.Files[j].Path
+	})
+	return diff, true
+}
+
+// compareResults compares the converted values of the file rel in both trees,
+// returning false when they are the same.
+func compareResults(rel string, before, after Result) (FileChange, bool) {
+	for _, result := range []Result{before, after} {
+		if result.Failed() || (result.Converted == nil && result.Err != nil) {
+			return FileChange{Path: rel, Kind: "failed", Error: fmt.Sprintf("%s: %v", result.Location(), result.Err)}, true
+		}
+	}
+	if bytes.Equal(before.Converted, after.Converted) {
+		return FileChange{}, false
+	}
+	changes, err := diffJSON(orNull(before.Converted), orNull(after.Converted))
+	if err != nil {
+		return FileChange{Path: rel, Kind: "failed", Error: err.Error()}, true
+	}
+	if len(changes) == 0 {
+		return FileChange{}, false
+	}
+	return FileChange{Path: rel, Kind: "changed", Changes: changes}, true
+}
+
+// orNull returns converted, or null for files with no value to convert.
+func orNull(converted []byte) []byte {
+	if converted == nil {
+		return []byte("null")
+	}
+	return converted
+}
@@ -0,0 +1,110 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/rogpeppe/go-internal/diff"
+	ctyyaml "github.com/zclconf/go-cty-yaml"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+	"go.yaml.in/yaml/v3"
+)
+
+// ConvertFormats are the formats Convert can write.
+var ConvertFormats = []string{"json", "yaml"}
+
+// Convert encodes value in format, json indented by two spaces or yaml, with
+// object keys in order, so converting a file to its own format normalizes it.
+func Convert(value cty.Value, format string) ([]byte, error) {
+	switch format {
+	case "json":
+		compact, err := ctyjson.SimpleJSONValue{Value: value}.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		var out bytes.Buffer
+		if err := json.Indent(&out, compact, "", "  "); err != nil {
+			return nil, err
+		}
+		out.WriteByte('\n')
+		return out.Bytes(), nil
+	case "yaml":
+		var out bytes.Buffer
+		encoder := yaml.NewEncoder(&out)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(yamlNode(value)); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+		return out.Bytes(), nil
+	}
+	return nil, fmt.Errorf("unknown format %q, must be json or yaml", format)
+}
+
+// yamlNode returns value as a YAML node, quoting only the strings the yaml
+// decoder would otherwise read as something else, like yes or 1.10.
+func yamlNode(value cty.Value) *yaml.Node {
+	ty := value.Type()
+	switch {
+	case value.IsNull() || !value.IsKnown():
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	case ty.IsObjectType() || ty.IsMapType():
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for it := value.ElementIterator(); it.Next(); {
+			key, element := it.Element()
+			node.Content = append(node.Content, yamlNode(key), yamlNode(element))
+		}
+		return node
+	case ty.IsListType() || ty.IsTupleType() || ty.IsSetType():
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for it := value.ElementIterator(); it.Next(); {
+			_, element := it.Element()
+			node.Content = append(node.Content, yamlNode(element))
+		}
+		return node
+	case ty == cty.Bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(value.True())}
+	case ty == cty.Number:
+		number := value.AsBigFloat()
+		if n, accuracy := number.Int64(); accuracy == big.Exact {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.FormatInt(n, 10)}
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: number.Text('g', -1)}
+	}
+	node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value.AsString()}
+	if plain, err := ctyyaml.Standard.Unmarshal([]byte(node.Value), cty.DynamicPseudoType); err != nil || !plain.Type().Equals(cty.String) || plain.AsString() != node.Value {
+		node.Style = yaml.DoubleQuotedStyle
+	}
+	return node
+}
+
+// formatDecoders are the decoders Options.CheckFormat applies to, with the
+// format their files are canonically written in.
+var formatDecoders = map[string]string{
+	"json": "json",
+	"yaml": "yaml",
+}
+
+// canonicalDiff returns a unified diff from src, the file name, to its value
+// converted back to the format of decoderName, or "" when src is already in
+// that canonical form or decoderName has none.
+func canonicalDiff(decoderName, name string, src []byte, value cty.Value) string {
+	format, ok := formatDecoders[decoderName]
+	if !ok || value.IsNull() {
+		return ""
+	}
+	canonical, err := Convert(value, format)
+	if err != nil {
+		return ""
+	}
+	return string(diff.Diff(name, src, name+" (canonical)", canonical))
+}
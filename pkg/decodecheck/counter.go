@@ -0,0 +1,116 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"sort"
+	"sync"
+)
+
+// SafeCounter keeps the overall byte count and per file type file, error,
+// non-strict and warning counts for a run.   The "total" key holds the totals
+// across types.
+type SafeCounter struct {
+	mu              sync.Mutex
+	nbytes          int64
+	fileCounts      map[string]int
+	errorCounts     map[string]int
+	nonStrictCounts map[string]int
+	warningCounts   map[string]int
+}
+
+// NewSafeCounter returns an empty SafeCounter.
+func NewSafeCounter() *SafeCounter {
+	return &SafeCounter{
+		fileCounts:      map[string]int{"total": 0},
+		errorCounts:     map[string]int{"total": 0},
+		nonStrictCounts: map[string]int{"total": 0},
+		warningCounts:   map[string]int{"total": 0},
+	}
+}
+
+func (sc *SafeCounter) AddBytes(size int64) {
+	sc.mu.Lock()
+	sc.nbytes += size
+	sc.mu.Unlock()
+}
+
+func (sc *SafeCounter) AddFile(extension string) {
+	sc.mu.Lock()
+	sc.fileCounts["total"]++
+	sc.fileCounts[extension]++
+	sc.mu.Unlock()
+}
+
+func (sc *SafeCounter) AddError(extension string) {
+	sc.mu.Lock()
+	sc.errorCounts["total"]++
+	sc.errorCounts[extension]++
+	sc.mu.Unlock()
+}
+
+func (sc *SafeCounter) AddNonStrict(extension string) {
+	sc.mu.Lock()
+	sc.nonStrictCounts["total"]++
+	sc.nonStrictCounts[extension]++
+	sc.mu.Unlock()
+}
+
+func (sc *SafeCounter) AddWarning(extension string) {
+	sc.mu.Lock()
+	sc.warningCounts["total"]++
+	sc.warningCounts[extension]++
+	sc.mu.Unlock()
+}
+
+// Bytes returns the total size of all counted files.
+func (sc *SafeCounter) Bytes() int64 {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.nbytes
+}
+
+// Files returns the number of files of the given type, or "total".
+func (sc *SafeCounter) Files(fileType string) int {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.fileCounts[fileType]
+}
+
+// Errors returns the number of decode errors for the given type, or "total".
+func (sc *SafeCounter) Errors(fileType string) int {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.errorCounts[fileType]
+}
+
+// NonStrictFiles returns the number of files of the given type, or "total",
+// that only decoded with relaxed syntax.
+func (sc *SafeCounter) NonStrictFiles(fileType string) int {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.nonStrictCounts[fileType]
+}
+
+// WarningFiles returns the number of files of the given type, or "total",
+// reported with warnings.
+func (sc *SafeCounter) WarningFiles(fileType string) int {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.warningCounts[fileType]
+}
+
+// Types returns the counted file types in sorted order, without "total".
+func (sc *SafeCounter) Types() []string {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	var types []string
+	for fileType := range sc.fileCounts {
+		if fileType != "total" {
+			types = append(types, fileType)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
@@ -0,0 +1,91 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// NewCSVDecodeFunc builds a CSV decoder for a delimiter other than a comma, or for
+// files without a header row.   With a header row it matches terraform's
+// csvdecode (stdlib.CSVDecodeFunc) and returns a list of objects, without one it
+// returns a list of rows, each a list of strings.
+func NewCSVDecodeFunc(comma rune, header bool) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "src",
+				Type: cty.String,
+			},
+		},
+		Type: function.StaticReturnType(cty.DynamicPseudoType),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			cr := csv.NewReader(strings.NewReader(args[0].AsString()))
+			cr.Comma = comma
+
+			var headers []string
+			if header {
+				var err error
+				headers, err = cr.Read()
+				if err == io.EOF {
+					return cty.NilVal, fmt.Errorf("missing header line")
+				}
+				if err != nil {
+					return cty.NilVal, csvError(err)
+				}
+				seen := make(map[string]bool, len(headers))
+				for _, name := range headers {
+					if seen[name] {
+						return cty.NilVal, fmt.Errorf("duplicate column name %q", name)
+					}
+					seen[name] = true
+				}
+			}
+
+			var rows []cty.Value
+			for {
+				cols, err := cr.Read()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					return cty.NilVal, csvError(err)
+				}
+
+				if !header {
+					vals := make([]cty.Value, len(cols))
+					for i, col := range cols {
+						vals[i] = cty.StringVal(col)
+					}
+					rows = append(rows, cty.ListVal(vals))
+					continue
+				}
+				vals := make(map[string]cty.Value, len(cols))
+				for i, col := range cols {
+					vals[headers[i]] = cty.StringVal(col)
+				}
+				rows = append(rows, cty.ObjectVal(vals))
+			}
+
+			if len(rows) == 0 {
+				return cty.ListValEmpty(cty.DynamicPseudoType), nil
+			}
+			return cty.ListVal(rows), nil
+		},
+	})
+}
+
+// csvError adds the line number to CSV parse errors, worded like csvdecode.
+func csvError(err error) error {
+	if parseErr, ok := err.(*csv.ParseError); ok {
+		return fmt.Errorf("CSV parse error on line %d: %w", parseErr.Line, parseErr.Err)
+	}
+	return err
+}
@@ -0,0 +1,89 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// goToCty converts the generic Go values produced by third-party parsers
+// (map[string]interface{}, []interface{} and scalars) into the same cty shapes
+// jsondecode produces: objects for maps, tuples for lists.   cty numbers cannot
+// be NaN or infinite, so those fail naming the key they are at.
+func goToCty(v interface{}) (cty.Value, error) {
+	switch v := v.(type) {
+	case nil:
+		return cty.NullVal(cty.DynamicPseudoType), nil
+	case string:
+		return cty.StringVal(v), nil
+	case bool:
+		return cty.BoolVal(v), nil
+	case int:
+		return cty.NumberIntVal(int64(v)), nil
+	case int64:
+		return cty.NumberIntVal(v), nil
+	case uint64:
+		return cty.NumberUIntVal(v), nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return cty.NilVal, fmt.Errorf("%v is not representable as a number", v)
+		}
+		return cty.NumberFloatVal(v), nil
+	case *big.Float:
+		return cty.NumberVal(v), nil
+	case time.Time:
+		return cty.StringVal(formatTime(v)), nil
+	case map[string]interface{}:
+		attrs := make(map[string]cty.Value, len(v))
+		for key, elem := range v {
+			val, err := goToCty(elem)
+			if err != nil {
+				return cty.NilVal, fmt.Errorf("%s: %w", key, err)
+			}
+			attrs[key] = val
+		}
+		return cty.ObjectVal(attrs), nil
+	case []map[string]interface{}:
+		elems := make([]cty.Value, 0, len(v))
+		for _, elem := range v {
+			val, err := goToCty(elem)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			elems = append(elems, val)
+		}
+		return cty.TupleVal(elems), nil
+	case []interface{}:
+		elems := make([]cty.Value, 0, len(v))
+		for _, elem := range v {
+			val, err := goToCty(elem)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			elems = append(elems, val)
+		}
+		return cty.TupleVal(elems), nil
+	default:
+		return cty.NilVal, fmt.Errorf("unsupported value type %T", v)
+	}
+}
+
+// formatTime renders timestamps as strings, keeping the local date/time forms
+// TOML allows instead of inventing a timezone for them.
+func formatTime(t time.Time) string {
+	switch t.Location().String() {
+	case "date-local":
+		return t.Format("2006-01-02")
+	case "time-local":
+		return t.Format("15:04:05.999999999")
+	case "datetime-local":
+		return t.Format("2006-01-02T15:04:05.999999999")
+	}
+	return t.Format(time.RFC3339Nano)
+}
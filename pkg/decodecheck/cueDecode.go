@@ -0,0 +1,101 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	cueerrors "cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/load"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// CUEDecodeFunc compiles and evaluates a CUE file, reporting syntax and
+// evaluation errors the same way `cue vet file.cue` does.   CUE files are often
+// schemas with no concrete value, so this only checks them and returns null.
+var CUEDecodeFunc = cueFunc(false)
+
+// CUEExportFunc additionally requires the CUE file to be fully concrete and
+// exports it to JSON, decoding the result with jsondecode like `cue export`.
+var CUEExportFunc = cueFunc(true)
+
+// cueFunc builds the CUE decode functions.   The optional path argument lets
+// the loader resolve package imports relative to the file.
+func cueFunc(export bool) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "src",
+				Type: cty.String,
+			},
+		},
+		VarParam: &function.Parameter{
+			Name: "path",
+			Type: cty.String,
+		},
+		Type: function.StaticReturnType(cty.DynamicPseudoType),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			val, err := cueValue(args)
+			if err != nil {
+				return cty.NilVal, err
+			}
+
+			if !export {
+				if err := val.Validate(); err != nil {
+					return cty.NilVal, cueError(err)
+				}
+				return cty.NullVal(cty.DynamicPseudoType), nil
+			}
+
+			if err := val.Validate(cue.Concrete(true)); err != nil {
+				return cty.NilVal, cueError(err)
+			}
+			exported, err := val.MarshalJSON()
+			if err != nil {
+				return cty.NilVal, cueError(err)
+			}
+			return stdlib.JSONDecode(cty.StringVal(string(exported)))
+		},
+	})
+}
+
+// cueValue builds the CUE value for the decode function arguments, loading the
+// file as a single file instance when its path is known and compiling the bare
+// source otherwise.
+func cueValue(args []cty.Value) (cue.Value, error) {
+	ctx := cuecontext.New()
+	src := args[0].AsString()
+	if len(args) < 2 {
+		val := ctx.CompileString(src)
+		return val, cueError(val.Err())
+	}
+
+	path, err := filepath.Abs(args[1].AsString())
+	if err != nil {
+		return cue.Value{}, err
+	}
+	insts := load.Instances([]string{filepath.Base(path)}, &load.Config{
+		Dir:     filepath.Dir(path),
+		Overlay: map[string]load.Source{path: load.FromString(src)},
+	})
+	if insts[0].Err != nil {
+		return cue.Value{}, cueError(insts[0].Err)
+	}
+	val := ctx.BuildInstance(insts[0])
+	return val, cueError(val.Err())
+}
+
+// cueError flattens CUE's error list (which carries positions) into one error.
+func cueError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.TrimSpace(cueerrors.Details(err, nil)))
+}
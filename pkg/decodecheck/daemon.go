@@ -0,0 +1,353 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// daemonKeepJobs is how many finished scans a Daemon keeps for GET
+// /scans/{id}, the oldest being dropped first.
+const daemonKeepJobs = 100
+
+// maxScanRequestBytes caps the size of a POST /scans request body.
+const maxScanRequestBytes = 1 << 20
+
+// ScanRequest is a scan queued on a Daemon.   Only the options choosing which
+// files are checked may differ between requests, so results cached for one
+// request hold for every other.
+type ScanRequest struct {
+	// Path is the directory to scan, relative to the first of the daemon's
+	// Roots like /scan, empty for the whole root.
+	Path string `json:"path"`
+	// Files, if not nil, are checked instead of walking Path, relative to it.
+	Files []string `json:"files,omitempty"`
+	// MatchPatterns, ExcludePatterns and ExcludeDirs replace the daemon's when
+	// set, see Options.
+	MatchPatterns   []string `json:"matchPatterns,omitempty"`
+	ExcludePatterns []string `json:"excludePatterns,omitempty"`
+	ExcludeDirs     []string `json:"excludeDirs,omitempty"`
+	// MaxDepth and MaxErrors replace the daemon's when positive.
+	MaxDepth  int `json:"maxDepth,omitempty"`
+	MaxErrors int `json:"maxErrors,omitempty"`
+}
+
+// ScanState is where a queued scan has got to.
+type ScanState string
+
+const (
+	// ScanQueued scans are waiting for one of the daemon's scan slots.
+	ScanQueued ScanState = "queued"
+	// ScanRunning scans are being run.
+	ScanRunning ScanState = "running"
+	// ScanDone scans have finished and have their Report.
+	ScanDone ScanState = "done"
+	// ScanCancelled scans were cancelled, queued or part way through, which
+	// then have a partial Report.
+	ScanCancelled ScanState = "cancelled"
+)
+
+// ScanJob is a scan a Daemon has accepted, with its Report once it is done.
+type ScanJob struct {
+	ID       string      `json:"id"`
+	Request  ScanRequest `json:"request"`
+	State    ScanState   `json:"state"`
+	Queued   time.Time   `json:"queued"`
+	Started  *time.Time  `json:"started,omitempty"`
+	Finished *time.Time  `json:"finished,omitempty"`
+	Report   *Report     `json:"report,omitempty"`
+
+	opts   Options
+	done   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Daemon queues scans requested over HTTP and runs a few at a time, sharing
+// one Budget so all of them together stay within the concurrency limits of
+// its Options, and one Cache so files unchanged since any earlier scan are not
+// decoded again.   Bots triggering validations can share one warmed up process
+// rather than each starting their own.
+type Daemon struct {
+	opts    Options
+	scans   int
+	queue   chan *ScanJob
+	mu      sync.Mutex
+	jobs    map[string]*ScanJob
+	history []string // IDs of finished jobs, oldest first
+	nextID  int
+}
+
+// NewDaemon returns a Daemon scanning with opts, running scans at a time and
+// queueing up to queueSize more.   opts gets a Budget and an in memory Cache if
+// it has none.
+func NewDaemon(opts Options, scans, queueSize int) *Daemon {
+	if opts.Budget == nil {
+		opts.Budget = NewBudget(opts)
+	}
+	if opts.Cache == nil {
+		opts.Cache = NewCache()
+	}
+	opts.OnResult, opts.OnProgress = nil, nil
+	return &Daemon{
+		opts:  opts,
+		scans: max(1, scans),
+		queue: make(chan *ScanJob, max(0, queueSize)),
+		jobs:  make(map[string]*ScanJob),
+	}
+}
+
+// Run runs queued scans until ctx is cancelled, cancelling those still
+// running or queued then.
+func (d *Daemon) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range d.scans {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case job := <-d.queue:
+					d.run(job)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	<-ctx.Done()
+
+	d.mu.Lock()
+	for _, job := range d.jobs {
+		job.cancel()
+	}
+	d.mu.Unlock()
+	wg.Wait()
+}
+
+// Submit queues a scan, returning an error if the request is not valid or
+// ErrQueueFull when the queue has no room for it.
+func (d *Daemon) Submit(req ScanRequest) (*ScanJob, error) {
+	opts, err := scanOptions(d.opts, req.Path)
+	if err != nil {
+		return nil, err
+	}
+	if req.Files != nil {
+		opts.Files = make([]string, len(req.Files))
+		for i, name := range req.Files {
+			if opts.Files[i], err = scanPath(opts, opts.Roots[0], name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if req.MatchPatterns != nil {
+		opts.MatchPatterns, opts.MatchRegex = req.MatchPatterns, nil
+	}
+	if req.ExcludePatterns != nil {
+		opts.ExcludePatterns = req.ExcludePatterns
+	}
+	if req.ExcludeDirs != nil {
+		opts.ExcludeDirs = req.ExcludeDirs
+	}
+	if req.MaxDepth > 0 {
+		opts.MaxDepth = req.MaxDepth
+	}
+	if req.MaxErrors > 0 {
+		opts.MaxErrors = req.MaxErrors
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.nextID++
+	job := &ScanJob{ID: strconv.Itoa(d.nextID), Request: req, State: ScanQueued, Queued: time.Now(), opts: opts, done: make(chan struct{})}
+	job.ctx, job.cancel = context.WithCancel(context.Background())
+	select {
+	case d.queue <- job:
+	default:
+		job.cancel()
+		return nil, ErrQueueFull
+	}
+	d.jobs[job.ID] = job
+	return job, nil
+}
+
+// ErrQueueFull is returned by Daemon.Submit when the queue has no room.
+var ErrQueueFull = errors.New("scan queue is full")
+
+// run runs job unless it was cancelled while queued.
+func (d *Daemon) run(job *ScanJob) {
+	d.mu.Lock()
+	if job.ctx.Err() != nil {
+		d.mu.Unlock()
+		d.finish(job, nil)
+		return
+	}
+	now := time.Now()
+	job.State, job.Started = ScanRunning, &now
+	d.mu.Unlock()
+
+	report := NewRunner(job.opts).Run(job.ctx)
+	report.Sort()
+	d.finish(job, report)
+}
+
+// finish records the outcome of job, dropping the oldest finished jobs past
+// daemonKeepJobs.
+func (d *Daemon) finish(job *ScanJob, report *Report) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	job.Finished, job.Report = &now, report
+	job.State = ScanDone
+	if report == nil || report.Interrupted {
+		job.State = ScanCancelled
+	}
+	job.cancel()
+	close(job.done)
+
+	d.history = append(d.history, job.ID)
+	if len(d.history) > daemonKeepJobs {
+		delete(d.jobs, d.history[0])
+		d.history = d.history[1:]
+	}
+}
+
+// Job returns a copy of the job with the given ID, as it is now.
+func (d *Daemon) Job(id string) (ScanJob, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	job, ok := d.jobs[id]
+	if !ok {
+		return ScanJob{}, false
+	}
+	return *job, true
+}
+
+// Cancel cancels the job with the given ID, queued or running.
+func (d *Daemon) Cancel(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	job, ok := d.jobs[id]
+	if ok {
+		job.cancel()
+	}
+	return ok
+}
+
+// wait waits for the job with the given ID to finish or ctx to be cancelled.
+func (d *Daemon) wait(ctx context.Context, id string) {
+	d.mu.Lock()
+	job, ok := d.jobs[id]
+	d.mu.Unlock()
+	if !ok {
+		return
+	}
+	select {
+	case <-job.done:
+	case <-ctx.Done():
+	}
+}
+
+// Handler returns an HTTP handler for the daemon, serving what NewHandler
+// does along with:
+//
+//	POST /scans          queues the ScanRequest in the body and responds 202
+//	                     with the ScanJob, or 503 when the queue is full.
+//	                     With wait=true it responds once the scan is done.
+//	GET /scans           lists the jobs queued, running and recently finished,
+//	                     without their reports.
+//	GET /scans/{id}      responds with the job, waiting for it with wait=true.
+//	DELETE /scans/{id}   cancels the job.
+func (d *Daemon) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/", NewHandler(d.opts))
+	mux.HandleFunc("POST /scans", func(w http.ResponseWriter, req *http.Request) {
+		var scan ScanRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxScanRequestBytes)).Decode(&scan); err != nil {
+			http.Error(w, "bad scan request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		job, err := d.Submit(scan)
+		switch {
+		case errors.Is(err, ErrQueueFull):
+			w.Header().Set("Retry-After", "5")
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Location", "/scans/"+job.ID)
+		d.respond(w, req, job.ID, http.StatusAccepted)
+	})
+	mux.HandleFunc("GET /scans", func(w http.ResponseWriter, req *http.Request) {
+		d.mu.Lock()
+		jobs := make([]ScanJob, 0, len(d.jobs))
+		for _, job := range d.jobs {
+			summary := *job
+			summary.Report = nil
+			jobs = append(jobs, summary)
+		}
+		d.mu.Unlock()
+		sortJobs(jobs)
+		writeJSON(w, http.StatusOK, jobs)
+	})
+	mux.HandleFunc("GET /scans/{id}", func(w http.ResponseWriter, req *http.Request) {
+		d.respond(w, req, req.PathValue("id"), http.StatusOK)
+	})
+	mux.HandleFunc("DELETE /scans/{id}", func(w http.ResponseWriter, req *http.Request) {
+		if !d.Cancel(req.PathValue("id")) {
+			http.NotFound(w, req)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
+
+// respond writes the job with the given ID, after waiting for it to finish
+// when the request asks to wait.   Finished jobs respond 200, others status.
+func (d *Daemon) respond(w http.ResponseWriter, req *http.Request, id string, status int) {
+	if wait, _ := strconv.ParseBool(req.URL.Query().Get("wait")); wait {
+		d.wait(req.Context(), id)
+	}
+	job, ok := d.Job(id)
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	if job.Finished != nil {
+		status = http.StatusOK
+	}
+	writeJSON(w, status, job)
+}
+
+// sortJobs orders jobs by the order they were queued in.
+func sortJobs(jobs []ScanJob) {
+	sort.Slice(jobs, func(i, j int) bool {
+		a, _ := strconv.Atoi(jobs[i].ID)
+		b, _ := strconv.Atoi(jobs[j].ID)
+		return a < b
+	})
+}
+
+// scanPath joins name onto dir, a scan's root, which it may not escape.
+func scanPath(opts Options, dir, name string) (string, error) {
+	if !filepath.IsLocal(name) {
+		return "", errors.New("files must be relative and stay within the scanned path")
+	}
+	if opts.FS != nil {
+		return path.Join(dir, filepath.ToSlash(name)), nil
+	}
+	return filepath.Join(dir, name), nil
+}
@@ -0,0 +1,154 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	ctyyaml "github.com/zclconf/go-cty-yaml"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// DefaultDecoders returns the built in decoders keyed by the names used in
+// decoder rules and in the summary counts.   A new map is returned on every call
+// so callers are free to add or swap decoders in it.
+func DefaultDecoders() map[string]function.Function {
+	return map[string]function.Function{
+		"json":        stdlib.JSONDecodeFunc,
+		"yaml":        ctyyaml.YAMLDecodeFunc,
+		"yaml-stream": YAMLStreamDecodeFunc,
+		"hcl":         HCLDecodeFunc,
+		"tfvars":      TFVarsDecodeFunc,
+		"tfvars-json": TFVarsJSONDecodeFunc,
+		"tfstate":     TFStateDecodeFunc,
+		"tfplan-json": TFPlanDecodeFunc,
+		"toml":        TOMLDecodeFunc,
+		"jsonc":       JSONCDecodeFunc,
+		"ndjson":      NDJSONDecodeFunc,
+		"frontmatter": FrontMatterDecodeFunc,
+		"jsonnet":     JsonnetDecodeFunc,
+		"cue":         CUEDecodeFunc,
+		"cue-export":  CUEExportFunc,
+		"xml":         XMLDecodeFunc,
+		"ini":         INIDecodeFunc,
+		"properties":  PropertiesDecodeFunc,
+		"csv":         stdlib.CSVDecodeFunc,
+	}
+}
+
+// DecoderRule maps a file glob pattern to a decoder name.   Patterns match the
+// same way as Options.MatchPatterns, so env/**/*.yaml only applies under env.
+type DecoderRule struct {
+	Pattern string
+	Decoder string
+}
+
+// DefaultRules returns the built in decoder rules.   Rules are checked in order
+// and the first pattern matching a file's name picks its decoder, so more
+// specific patterns like *.tfvars.json come before *.json.
+func DefaultRules() []DecoderRule {
+	return []DecoderRule{
+		{"*.tfvars.json", "tfvars-json"},
+		{"*.json", "json"},
+		{"*.yaml", "yaml"},
+		{"*.hcl", "hcl"},
+		{"*.tf", "hcl"},
+		{"*.tfvars", "tfvars"},
+		{"*.toml", "toml"},
+		{"*.jsonc", "jsonc"},
+		{"*.ndjson", "ndjson"},
+		{"*.jsonl", "ndjson"},
+		{"*.cue", "cue"},
+		{"*.xml", "xml"},
+		{"*.ini", "ini"},
+		{"*.properties", "properties"},
+		{"*.csv", "csv"},
+	}
+}
+
+// DecoderFor returns the name and function of the decoder for name, the slash
+// separated path from the root, from the first rule whose pattern matches it.
+// The extension is lower cased and aliased first, see ExtensionAliases.
+func (o *Options) DecoderFor(name string) (string, function.Function, bool) {
+	rules := compileRules(o.Rules)
+	if o.IgnorePathCase {
+		foldRules(rules)
+	}
+	return decoderFor(o.Decoders, rules, o.canonicalName(name))
+}
+
+// canonicalName returns the slash separated path name with its extension
+// lower cased (unless CaseSensitiveExtensions is set) and then replaced by its
+// alias from ExtensionAliases, the form patterns and rules are matched against.
+func (o *Options) canonicalName(name string) string {
+	ext := path.Ext(name)
+	if ext == "" {
+		return name
+	}
+	canonical := ext
+	if !o.CaseSensitiveExtensions {
+		canonical = strings.ToLower(ext)
+	}
+	if alias, ok := o.ExtensionAliases[canonical]; ok {
+		canonical = alias
+	}
+	return strings.TrimSuffix(name, ext) + canonical
+}
+
+// compiledRule is a DecoderRule with its pattern compiled.
+type compiledRule struct {
+	pattern pathPattern
+	decoder string
+}
+
+// compileRules compiles the patterns of rules, skipping any that fail.
+func compileRules(rules []DecoderRule) []compiledRule {
+	compiled := make([]compiledRule, 0, len(rules))
+	for _, rule := range rules {
+		if pattern, err := compilePattern(rule.Pattern); err == nil {
+			compiled = append(compiled, compiledRule{pattern: pattern, decoder: rule.Decoder})
+		}
+	}
+	return compiled
+}
+
+// foldRules makes the patterns of rules match without regard to case.
+func foldRules(rules []compiledRule) {
+	for i := range rules {
+		rules[i].pattern = rules[i].pattern.folded()
+	}
+}
+
+// decoderFor implements Options.DecoderFor over already compiled rules.
+func decoderFor(decoders map[string]function.Function, rules []compiledRule, name string) (string, function.Function, bool) {
+	rule, ok := ruleFor(rules, name)
+	if !ok {
+		return "", function.Function{}, false
+	}
+	decodeFunction, ok := decoders[rule.decoder]
+	return rule.decoder, decodeFunction, ok
+}
+
+// ruleFor returns the first of rules whose pattern matches name.
+func ruleFor(rules []compiledRule, name string) (compiledRule, bool) {
+	for _, rule := range rules {
+		if rule.pattern.match(name) {
+			return rule, true
+		}
+	}
+	return compiledRule{}, false
+}
+
+// DecoderNames returns the names of the given decoders in sorted order.
+func DecoderNames(decoders map[string]function.Function) []string {
+	names := make([]string, 0, len(decoders))
+	for name := range decoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,83 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DirectoryCounts are the counts for the files under one directory, see
+// Options.SummaryDepth.
+type DirectoryCounts struct {
+	// Path is the directory, joined onto the root it is under like Result.Path.
+	Path     string `json:"path"`
+	Files    int    `json:"files"`
+	Bytes    int64  `json:"bytes"`
+	Errors   int    `json:"errors"`
+	Warnings int    `json:"warnings,omitempty"`
+}
+
+// summaryDir returns the directory name in root is counted under, the one
+// Options.SummaryDepth levels below the root on the way to it, or the one it
+// is in when that is less deep.
+func (r *Runner) summaryDir(root searchRoot, name string) string {
+	dir := path.Dir(root.rel(name))
+	abs := strings.HasPrefix(dir, "/")
+	var parts []string
+	if trimmed := strings.TrimPrefix(dir, "/"); trimmed != "." && trimmed != "" {
+		parts = strings.Split(trimmed, "/")
+	}
+	if len(parts) > r.opts.SummaryDepth {
+		parts = parts[:r.opts.SummaryDepth]
+	}
+	dir = path.Join(parts...)
+
+	if root.listed {
+		if abs {
+			dir = "/" + dir
+		} else if dir == "" {
+			dir = "."
+		}
+		return filepath.FromSlash(dir)
+	}
+	if dir = path.Join(root.top, dir); dir == "" {
+		dir = "."
+	}
+	return root.display(dir)
+}
+
+// directoryCounter collects DirectoryCounts by path for a Reporter.
+type directoryCounter map[string]*DirectoryCounts
+
+// add counts result under its summary directory.
+func (dc directoryCounter) add(result Result) {
+	counts, ok := dc[result.summaryDir]
+	if !ok {
+		counts = &DirectoryCounts{Path: result.summaryDir}
+		dc[result.summaryDir] = counts
+	}
+	counts.Files++
+	counts.Bytes += result.Size
+	if result.Failed() {
+		counts.Errors++
+	}
+	if len(result.Warnings) > 0 {
+		counts.Warnings++
+	}
+}
+
+// sorted returns the counts ordered by path.
+func (dc directoryCounter) sorted() []DirectoryCounts {
+	dirs := make([]DirectoryCounts, 0, len(dc))
+	for _, counts := range dc {
+		dirs = append(dirs, *counts)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		return dirs[i].Path < dirs[j].Path
+	})
+	return dirs
+}
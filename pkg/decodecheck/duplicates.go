@@ -0,0 +1,149 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/tailscale/hujson"
+	"go.yaml.in/yaml/v3"
+)
+
+// duplicateKeyDecoders are the decoders StrictKeys applies to, by the syntax
+// their files are re-parsed in.
+var duplicateKeyDecoders = map[string]string{
+	"json":        "json",
+	"jsonc":       "json",
+	"ndjson":      "json",
+	"tfvars-json": "json",
+	"tfstate":     "json",
+	"tfplan-json": "json",
+	"yaml":        "yaml",
+	"yaml-stream": "yaml",
+}
+
+// duplicateKey is a mapping key given twice, with the positions of both.
+type duplicateKey struct {
+	key                 string
+	line, column        int
+	firstLine, firstCol int
+}
+
+func (d duplicateKey) Error() string {
+	return fmt.Sprintf("duplicate key %q at line %d, column %d, first defined at line %d, column %d", d.key, d.line, d.column, d.firstLine, d.firstCol)
+}
+
+// duplicateKeys re-parses src, decoded by decoderName, returning an error for
+// the first key repeated in the same object or mapping, or nil if there is none
+// or the decoder's syntax is not one StrictKeys checks.
+func duplicateKeys(decoderName string, src []byte) error {
+	switch duplicateKeyDecoders[decoderName] {
+	case "json":
+		return jsonDuplicateKeys(src)
+	case "yaml":
+		return yamlDuplicateKeys(src)
+	}
+	return nil
+}
+
+// jsonDuplicateKeys walks the tokens of every JSON value in src.   Comments and
+// trailing commas are blanked out first, which keeps the offsets of every key.
+func jsonDuplicateKeys(src []byte) error {
+	if standard, err := hujson.Standardize(bytes.Clone(src)); err == nil {
+		src = standard
+	}
+
+	type object struct {
+		keys      map[string]int64
+		expectKey bool
+	}
+	var stack []*object
+	dec := json.NewDecoder(bytes.NewReader(src))
+	for {
+		start := dec.InputOffset()
+		token, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			// Syntax Errors Are Left To The Decoder
+			return nil
+		}
+
+		var top *object
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+		if key, ok := token.(string); ok && top != nil && top.expectKey {
+			offset := start + int64(bytes.IndexByte(src[start:], '"'))
+			if first, ok := top.keys[key]; ok {
+				line, column, _ := offsetPosition(src, offset)
+				firstLine, firstCol, _ := offsetPosition(src, first)
+				return duplicateKey{key, line, column, firstLine, firstCol}
+			}
+			top.keys[key] = offset
+			top.expectKey = false
+			continue
+		}
+
+		switch token {
+		case json.Delim('{'):
+			stack = append(stack, &object{keys: map[string]int64{}, expectKey: true})
+			continue
+		case json.Delim('['):
+			stack = append(stack, nil)
+			continue
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+		}
+
+		// A Value Completed, So An Enclosing Object Expects A Key Next
+		if len(stack) > 0 && stack[len(stack)-1] != nil {
+			stack[len(stack)-1].expectKey = true
+		}
+	}
+}
+
+// yamlDuplicateKeys walks every document in src.   Merge keys are not
+// expanded, so a key overriding a merged one is not reported.
+func yamlDuplicateKeys(src []byte) error {
+	dec := yaml.NewDecoder(bytes.NewReader(src))
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); err != nil {
+			// Syntax Errors Are Left To The Decoder
+			return nil
+		}
+		if err := yamlNodeDuplicateKeys(&doc); err != nil {
+			return err
+		}
+	}
+}
+
+// yamlNodeDuplicateKeys checks the mappings in node and below it.
+func yamlNodeDuplicateKeys(node *yaml.Node) error {
+	if node.Kind == yaml.MappingNode {
+		seen := make(map[string]*yaml.Node, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if key.Kind != yaml.ScalarNode || key.Tag == "!!merge" {
+				continue
+			}
+			if first, ok := seen[key.Value]; ok {
+				return duplicateKey{key.Value, key.Line, key.Column, first.Line, first.Column}
+			}
+			seen[key.Value] = key
+		}
+	}
+	for _, child := range node.Content {
+		if err := yamlNodeDuplicateKeys(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
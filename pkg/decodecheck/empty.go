@@ -0,0 +1,65 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"fmt"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// valuelessDecoders decode some files to null without them being null
+// documents, like HCL, which is only syntax checked, or CUE that is not
+// concrete.
+var valuelessDecoders = []string{"hcl", "cue", "frontmatter"}
+
+// emptyFindings returns a finding if value, decoded by decoderName, is null
+// or an empty object or list, like files holding only ---, null or {}, or
+// for each document of a stream that is.   A stream with no documents is
+// reported too.
+func (r *Runner) emptyFindings(decoderName string, value cty.Value) []finding {
+	if contains(valuelessDecoders, decoderName) {
+		return nil
+	}
+	if !contains(r.opts.StreamDecoders, decoderName) || !value.Type().IsTupleType() {
+		if empty := emptyValue(value); empty != "" {
+			return []finding{{CategoryEmpty + "/" + empty, SeverityError, "decodes to " + emptyDescriptions[empty]}}
+		}
+		return nil
+	}
+	if value.LengthInt() == 0 {
+		return []finding{{CategoryEmpty + "/file", SeverityError, "holds no documents"}}
+	}
+	var findings []finding
+	for i, document := range value.AsValueSlice() {
+		if empty := emptyValue(document); empty != "" {
+			findings = append(findings, finding{CategoryEmpty + "/" + empty, SeverityError, fmt.Sprintf("document %d decodes to %s", i+1, emptyDescriptions[empty])})
+		}
+	}
+	return findings
+}
+
+// emptyDescriptions describe the kinds of empty value emptyValue returns.
+var emptyDescriptions = map[string]string{
+	"null":       "null",
+	"collection": "an empty object or list",
+}
+
+// emptyValue returns null if value is null, or the unknown value YAML files
+// without a document decode to, collection if it is an empty object, map, list
+// or set, and "" otherwise.
+func emptyValue(value cty.Value) string {
+	ty := value.Type()
+	switch {
+	case value.IsNull() || value.RawEquals(cty.DynamicVal):
+		return "null"
+	case !value.IsKnown():
+		return ""
+	case ty.IsObjectType() || ty.IsMapType() || ty.IsListType() || ty.IsTupleType() || ty.IsSetType():
+		if value.LengthInt() == 0 {
+			return "collection"
+		}
+	}
+	return ""
+}
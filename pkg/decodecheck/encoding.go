@@ -0,0 +1,55 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"bytes"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/encoding/unicode/utf32"
+)
+
+// byteOrderMarks are the encodings recognized by their byte order mark, longest
+// first so UTF-32LE is not taken for UTF-16LE.
+var byteOrderMarks = []struct {
+	bom      []byte
+	name     string
+	encoding encoding.Encoding
+}{
+	{[]byte{0x00, 0x00, 0xFE, 0xFF}, "UTF-32BE", utf32.UTF32(utf32.BigEndian, utf32.IgnoreBOM)},
+	{[]byte{0xFF, 0xFE, 0x00, 0x00}, "UTF-32LE", utf32.UTF32(utf32.LittleEndian, utf32.IgnoreBOM)},
+	{[]byte{0xEF, 0xBB, 0xBF}, "UTF-8 with a byte order mark", nil},
+	{[]byte{0xFE, 0xFF}, "UTF-16BE", unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM)},
+	{[]byte{0xFF, 0xFE}, "UTF-16LE", unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)},
+}
+
+// transcode returns src as UTF-8 without a byte order mark, with the name of
+// the encoding it was in, or "" if it was already.   UTF-16 without a byte
+// order mark is recognized by the zero byte of its first character, which
+// config files start with an ASCII one of.
+func transcode(src []byte) ([]byte, string, error) {
+	for _, mark := range byteOrderMarks {
+		if !bytes.HasPrefix(src, mark.bom) {
+			continue
+		}
+		src = src[len(mark.bom):]
+		if mark.encoding == nil {
+			return src, mark.name, nil
+		}
+		utf8, err := mark.encoding.NewDecoder().Bytes(src)
+		return utf8, mark.name, err
+	}
+	if len(src) >= 2 && len(src)%2 == 0 {
+		switch {
+		case src[0] != 0 && src[1] == 0:
+			utf8, err := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewDecoder().Bytes(src)
+			return utf8, "UTF-16LE", err
+		case src[0] == 0 && src[1] != 0:
+			utf8, err := unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM).NewDecoder().Bytes(src)
+			return utf8, "UTF-16BE", err
+		}
+	}
+	return src, "", nil
+}
@@ -0,0 +1,50 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import "regexp"
+
+// ErrorFilter downgrades the failures of files matching Pattern, which follows
+// the same rules as MatchPatterns, to warnings when their error matches
+// Regexp, for known errors a tree cannot fix yet, like those of a vendored
+// file.
+type ErrorFilter struct {
+	Pattern string
+	Regexp  *regexp.Regexp
+}
+
+// compiledFilter is an ErrorFilter with its pattern compiled.
+type compiledFilter struct {
+	pattern pathPattern
+	filter  ErrorFilter
+}
+
+// compileFilters compiles the patterns of filters, skipping any that fail.
+func compileFilters(filters []ErrorFilter) []compiledFilter {
+	compiled := make([]compiledFilter, 0, len(filters))
+	for _, filter := range filters {
+		if pattern, err := compilePattern(filter.Pattern); err == nil && filter.Regexp != nil {
+			compiled = append(compiled, compiledFilter{pattern: pattern, filter: filter})
+		}
+	}
+	return compiled
+}
+
+// applyIgnoreErrors makes result, for the file at rel from its root, a warning
+// when it failed with an error one of Options.IgnoreErrors matches, keeping
+// the error and its status so reports still show what was ignored.
+func (r *Runner) applyIgnoreErrors(rel string, result *Result) {
+	if len(r.ignoreErrors) == 0 || !result.Failed() || result.Err == nil {
+		return
+	}
+	rel = r.opts.canonicalName(rel)
+	message := result.Err.Error()
+	for _, ignore := range r.ignoreErrors {
+		if ignore.pattern.match(rel) && ignore.filter.Regexp.MatchString(message) {
+			result.Severity = SeverityWarning
+			result.Warnings = append(result.Warnings, message)
+			return
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// FilenameRule requires the names of files checked in directories matching
+// Dir, a glob following the same rules as MatchPatterns matched against the
+// directory's path from the root, to match Regexp in full, so a misnamed file
+// terragrunt would silently skip, like input.yaml for inputs-prod.yaml, is
+// reported.   Where several rules match a directory a name matching any of
+// them passes.
+type FilenameRule struct {
+	Dir    string
+	Regexp *regexp.Regexp
+}
+
+// compiledFilenameRule is a FilenameRule with its directory pattern and a
+// fully anchored copy of its expression compiled.
+type compiledFilenameRule struct {
+	dir  pathPattern
+	name *regexp.Regexp
+	rule FilenameRule
+}
+
+// compileFilenameRules compiles rules, skipping any that fail.
+func compileFilenameRules(rules []FilenameRule) []compiledFilenameRule {
+	compiled := make([]compiledFilenameRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.Regexp == nil {
+			continue
+		}
+		dir, err := compilePattern(rule.Dir)
+		if err != nil {
+			continue
+		}
+		name, err := regexp.Compile("^(?:" + rule.Regexp.String() + ")$")
+		if err != nil {
+			continue
+		}
+		compiled = append(compiled, compiledFilenameRule{dir: dir, name: name, rule: rule})
+	}
+	return compiled
+}
+
+// applyFilenameRules fails result, for the file at rel from its root, when its
+// directory has Options.FilenameRules and its name matches none of them, or
+// warns about it when Options.Severities makes the filename category a
+// warning.
+func (r *Runner) applyFilenameRules(rel string, result *Result) {
+	if len(r.filenameRules) == 0 {
+		return
+	}
+	dir, name := path.Split(rel)
+	dir = strings.TrimSuffix(dir, "/")
+	if dir == "" {
+		dir = "."
+	}
+	var expected []string
+	for _, rule := range r.filenameRules {
+		if !rule.dir.match(dir) {
+			continue
+		}
+		if rule.name.MatchString(name) {
+			return
+		}
+		expected = append(expected, rule.rule.Regexp.String())
+	}
+	if len(expected) == 0 {
+		return
+	}
+
+	err := fmt.Errorf("file name %s does not match %s, the names allowed in %s", name, strings.Join(expected, " or "), dir)
+	if r.opts.severity(CategoryFilename, SeverityError) == SeverityWarning {
+		result.Warnings = append(result.Warnings, err.Error())
+		return
+	}
+	if result.Err != nil {
+		err = errors.Join(result.Err, err)
+	}
+	if result.Status == Passed || result.Status == NonStrict {
+		result.Status = Invalid
+	}
+	result.Err = err
+}
@@ -0,0 +1,43 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"errors"
+	"strings"
+
+	ctyyaml "github.com/zclconf/go-cty-yaml"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// FrontMatterDecodeFunc pulls the "---" delimited YAML front matter out of a
+// markdown document and runs it through yamldecode.   Documents without front
+// matter decode to null, since plain markdown has nothing for terragrunt to read.
+var FrontMatterDecodeFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "src",
+			Type: cty.String,
+		},
+	},
+	Type: function.StaticReturnType(cty.DynamicPseudoType),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		lines := strings.SplitAfter(args[0].AsString(), "\n")
+		if len(lines) == 0 || strings.TrimRight(lines[0], "\r\n") != "---" {
+			return cty.NullVal(cty.DynamicPseudoType), nil
+		}
+
+		for i := 1; i < len(lines); i++ {
+			closing := strings.TrimRight(lines[i], "\r\n")
+			if closing != "---" && closing != "..." {
+				continue
+			}
+			// Keep The Opening Line Blank So Decoder Line Numbers Match The File
+			src := "\n" + strings.Join(lines[1:i], "")
+			return ctyyaml.YAMLDecodeFunc.Call([]cty.Value{cty.StringVal(src)})
+		}
+		return cty.NilVal, errors.New("front matter opened on line 1 is never closed with ---")
+	},
+})
@@ -0,0 +1,190 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gitBinary is the git executable run to list and read staged files.
+const gitBinary = "git"
+
+// StagedFiles returns the files staged in the git repository containing dir
+// (added, copied, modified or renamed, under dir only), and a filesystem holding
+// their staged content as read from the index rather than the working tree.
+// The filesystem also holds the staged copies of any ignoreFiles under dir, so
+// Options.IgnoreFiles apply as committed.   Paths are relative to dir, so they
+// can be used as Options.FS and Options.Files.
+func StagedFiles(ctx context.Context, dir string, ignoreFiles []string) (fs.FS, []string, error) {
+	// Check For A Work Tree First, Outside One git diff Prints Its Whole Usage
+	if _, err := git(ctx, dir, nil, "rev-parse", "--is-inside-work-tree"); err != nil {
+		return nil, nil, err
+	}
+	names, err := gitNames(ctx, dir, "--cached")
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(names) == 0 {
+		return memFS{}, names, nil
+	}
+
+	// Read Ignore Files Along With The Staged Files, They Are Only Consulted
+	blobs := append([]string(nil), names...)
+	if len(ignoreFiles) > 0 {
+		args := []string{"ls-files", "-z", "--"}
+		for _, name := range ignoreFiles {
+			args = append(args, ":(glob)**/"+name)
+		}
+		out, err := git(ctx, dir, nil, args...)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, name := range strings.Split(strings.TrimRight(string(out), "\x00"), "\x00") {
+			if name != "" && !contains(names, name) {
+				blobs = append(blobs, name)
+			}
+		}
+	}
+
+	// Read Every Staged Blob In One cat-file Call, Paths Are Relative To dir
+	var objects bytes.Buffer
+	for _, name := range blobs {
+		fmt.Fprintf(&objects, ":./%s\n", name)
+	}
+	out, err := git(ctx, dir, &objects, "cat-file", "--batch")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fsys := make(memFS, len(blobs))
+	files := make([]string, 0, len(names))
+	reader := bufio.NewReader(bytes.NewReader(out))
+	for i, name := range blobs {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, nil, fmt.Errorf("git cat-file: %v", err)
+		}
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			continue // missing, like a submodule
+		}
+		size, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, nil, fmt.Errorf("git cat-file: bad header %q", header)
+		}
+		content := make([]byte, size+1) // trailing newline
+		if _, err := io.ReadFull(reader, content); err != nil {
+			return nil, nil, fmt.Errorf("git cat-file: %v", err)
+		}
+		fsys[name] = content[:size]
+		if i < len(names) {
+			files = append(files, name)
+		}
+	}
+	return fsys, files, nil
+}
+
+// ChangedFiles returns the files under dir that were added, copied, modified or
+// renamed since the merge base of ref and HEAD, including uncommitted changes,
+// so on a branch it lists what the branch touches.   Paths are joined onto dir
+// for use as Options.Files.
+func ChangedFiles(ctx context.Context, dir, ref string) ([]string, error) {
+	if _, err := git(ctx, dir, nil, "rev-parse", "--is-inside-work-tree"); err != nil {
+		return nil, err
+	}
+	names, err := gitNames(ctx, dir, "--merge-base", ref, "--")
+	if err != nil {
+		return nil, err
+	}
+	for i, name := range names {
+		names[i] = filepath.Join(dir, filepath.FromSlash(name))
+	}
+	return names, nil
+}
+
+// gitNames runs git diff with args in dir and returns the added, copied,
+// modified or renamed paths under dir, relative to it.   The list is never nil.
+func gitNames(ctx context.Context, dir string, args ...string) ([]string, error) {
+	out, err := git(ctx, dir, nil, append([]string{"diff", "--name-only", "--relative", "-z", "--diff-filter=ACMR"}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, name := range strings.Split(strings.TrimRight(string(out), "\x00"), "\x00") {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// git runs git in dir with stdin, returning its output or an error carrying
+// what git printed to stderr.
+func git(ctx context.Context, dir string, stdin io.Reader, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, gitBinary, append([]string{"-C", dir}, args...)...)
+	cmd.Stdin = stdin
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %v", args[0], err)
+	}
+	return out, nil
+}
+
+// memFS is a read only filesystem of file contents by slash separated path,
+// enough to check files that do not exist on disk.   It has no directories, so
+// it is used with Options.Files rather than walked.
+type memFS map[string][]byte
+
+func (m memFS) Open(name string) (fs.File, error) {
+	content, ok := m[name]
+	if !ok || !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &memFile{Reader: bytes.NewReader(content), info: memFileInfo{name: name, size: int64(len(content))}}, nil
+}
+
+func (m memFS) ReadFile(name string) ([]byte, error) {
+	content, ok := m[name]
+	if !ok || !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return bytes.Clone(content), nil
+}
+
+// memFile is an open memFS file.
+type memFile struct {
+	*bytes.Reader
+	info memFileInfo
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *memFile) Close() error               { return nil }
+
+// memFileInfo describes a memFS file.
+type memFileInfo struct {
+	name string
+	size int64
+}
+
+func (fi memFileInfo) Name() string       { return fi.name[strings.LastIndex(fi.name, "/")+1:] }
+func (fi memFileInfo) Size() int64        { return fi.size }
+func (fi memFileInfo) Mode() fs.FileMode  { return 0o444 }
+func (fi memFileInfo) ModTime() time.Time { return time.Time{} }
+func (fi memFileInfo) IsDir() bool        { return false }
+func (fi memFileInfo) Sys() interface{}   { return nil }
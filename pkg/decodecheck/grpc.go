@@ -0,0 +1,108 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"context"
+
+	"github.com/JasonPodgorny/terraformDecodeTest/pkg/decodecheckpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RegisterGRPC registers the DecodeCheck service on s, serving checks over opts
+// the same way NewHandler does over HTTP.   Scans stream each Result as it
+// completes, then a Summary.
+func RegisterGRPC(s grpc.ServiceRegistrar, opts Options) {
+	decodecheckpb.RegisterDecodeCheckServer(s, &grpcServer{opts: opts, runner: NewRunner(opts)})
+}
+
+// grpcServer implements decodecheckpb.DecodeCheckServer.
+type grpcServer struct {
+	decodecheckpb.UnimplementedDecodeCheckServer
+	opts   Options
+	runner *Runner
+}
+
+func (gs *grpcServer) Validate(ctx context.Context, req *decodecheckpb.ValidateRequest) (*decodecheckpb.Result, error) {
+	if req.GetDecoder() == "" && req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "no decoder: set decoder or name")
+	}
+	result, ok := gs.runner.Validate(ctx, req.GetName(), req.GetDecoder(), req.GetContent())
+	if !ok {
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+	return resultProto(result), nil
+}
+
+func (gs *grpcServer) Scan(req *decodecheckpb.ScanRequest, stream grpc.ServerStreamingServer[decodecheckpb.ScanResponse]) error {
+	scanOpts, err := scanOptions(gs.opts, req.GetPath())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// Stop The Scan If The Client Goes Away Or A Send Fails
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	var sendErr error
+	scanOpts.OnResult = func(result Result) {
+		if sendErr != nil {
+			return
+		}
+		sendErr = stream.Send(&decodecheckpb.ScanResponse{Event: &decodecheckpb.ScanResponse_Result{Result: resultProto(result)}})
+		if sendErr != nil {
+			cancel()
+		}
+	}
+
+	report := NewRunner(scanOpts).Run(ctx)
+	if sendErr != nil {
+		return sendErr
+	}
+	return stream.Send(&decodecheckpb.ScanResponse{Event: &decodecheckpb.ScanResponse_Summary{Summary: summaryProto(report)}})
+}
+
+// resultProto converts a Result to its protobuf message.   Status values are
+// offset by one since proto enums reserve zero for unspecified.
+func resultProto(result Result) *decodecheckpb.Result {
+	msg := &decodecheckpb.Result{
+		Path:     result.Path,
+		Size:     result.Size,
+		Type:     result.Type,
+		Status:   decodecheckpb.Status(result.Status + 1),
+		Match:    result.Match,
+		Cached:   result.Cached,
+		Warnings: result.Warnings,
+		Line:     int32(result.Line),
+		Column:   int32(result.Column),
+		CtyType:  result.TypeSignature,
+	}
+	if result.Err != nil {
+		msg.Error = result.Err.Error()
+	}
+	return msg
+}
+
+// summaryProto converts the totals and per type counts of a Report.
+func summaryProto(report *Report) *decodecheckpb.Summary {
+	msg := &decodecheckpb.Summary{
+		Files:       int64(report.Counts.Files("total")),
+		Bytes:       report.Counts.Bytes(),
+		Errors:      int64(report.Counts.Errors("total")),
+		Failed:      report.Failed(),
+		Interrupted: report.Interrupted,
+		Stopped:     report.Stopped,
+		Types:       make(map[string]*decodecheckpb.TypeCounts),
+	}
+	for _, fileType := range report.Counts.Types() {
+		msg.Types[fileType] = &decodecheckpb.TypeCounts{
+			Files:     int64(report.Counts.Files(fileType)),
+			Errors:    int64(report.Counts.Errors(fileType)),
+			NonStrict: int64(report.Counts.NonStrictFiles(fileType)),
+			Warnings:  int64(report.Counts.WarningFiles(fileType)),
+		}
+	}
+	return msg
+}
@@ -0,0 +1,38 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JasonPodgorny/terraformDecodeTest/pkg/decodecheckpb"
+)
+
+func TestGRPCValidateRefusesJsonnetImports(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("secret-content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultOptions()
+	gs := &grpcServer{opts: opts, runner: NewRunner(opts)}
+
+	result, err := gs.Validate(context.Background(), &decodecheckpb.ValidateRequest{
+		Name:    filepath.Join(dir, "main.jsonnet"),
+		Decoder: "jsonnet",
+		Content: []byte(`error importstr "secret.txt"`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.GetStatus() != decodecheckpb.Status(DecodeFailed+1) {
+		t.Errorf("status = %v, want decode failed", result.GetStatus())
+	}
+	if msg := result.GetError(); strings.Contains(msg, "secret-content") || !strings.Contains(msg, "imports are not allowed") {
+		t.Errorf("error = %q, want the import refused", msg)
+	}
+}
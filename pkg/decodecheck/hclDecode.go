@@ -0,0 +1,143 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// pathLabelDecoders are the decoders that take the file path only to name the
+// file in their messages, so their results can still be cached, under the path.
+var pathLabelDecoders = []string{"hcl", "tfvars", "tfvars-json"}
+
+// HCLDecodeFunc parses HCL native syntax (terragrunt.hcl, *.tf) the same way
+// terraform and terragrunt do via hclparse.   HCL bodies reference functions,
+// variables and other files so they can't be turned into a value without a full
+// evaluation context, which means this decoder only checks syntax and returns null.
+// The optional path names the file in messages, as terraform names it.
+var HCLDecodeFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "src",
+			Type: cty.String,
+		},
+	},
+	VarParam: &function.Parameter{
+		Name: "path",
+		Type: cty.String,
+	},
+	Type: function.StaticReturnType(cty.DynamicPseudoType),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		parser := hclparse.NewParser()
+		_, diags := parser.ParseHCL([]byte(args[0].AsString()), sourceName(args))
+		if diags.HasErrors() {
+			return cty.NilVal, diagsError(diags)
+		}
+		return cty.NullVal(cty.DynamicPseudoType), nil
+	},
+})
+
+// TFVarsDecodeFunc decodes terraform variable definition files (*.tfvars) with the
+// same semantics terraform applies: only attributes are allowed, so blocks and
+// duplicate assignments are errors, and values are evaluated without any variables
+// or functions in scope.   The optional path names the file in messages.
+var TFVarsDecodeFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "src",
+			Type: cty.String,
+		},
+	},
+	VarParam: &function.Parameter{
+		Name: "path",
+		Type: cty.String,
+	},
+	Type: function.StaticReturnType(cty.DynamicPseudoType),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		parser := hclparse.NewParser()
+		file, diags := parser.ParseHCL([]byte(args[0].AsString()), sourceName(args))
+		if diags.HasErrors() {
+			return cty.NilVal, diagsError(diags)
+		}
+		return tfvarsValue(file)
+	},
+})
+
+// TFVarsJSONDecodeFunc is the *.tfvars.json equivalent of TFVarsDecodeFunc, using
+// the HCL JSON syntax parser as terraform does for those files.
+var TFVarsJSONDecodeFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "src",
+			Type: cty.String,
+		},
+	},
+	VarParam: &function.Parameter{
+		Name: "path",
+		Type: cty.String,
+	},
+	Type: function.StaticReturnType(cty.DynamicPseudoType),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		parser := hclparse.NewParser()
+		file, diags := parser.ParseJSON([]byte(args[0].AsString()), sourceName(args))
+		if diags.HasErrors() {
+			return cty.NilVal, diagsError(diags)
+		}
+		return tfvarsValue(file)
+	},
+})
+
+// sourceName returns the path passed after src to a decoder, or "" when
+// there is none.
+func sourceName(args []cty.Value) string {
+	if len(args) > 1 {
+		return args[1].AsString()
+	}
+	return ""
+}
+
+// tfvarsValue evaluates the attributes of a parsed tfvars file into an object value.
+func tfvarsValue(file *hcl.File) (cty.Value, error) {
+	attrs, diags := file.Body.JustAttributes()
+	if diags.HasErrors() {
+		return cty.NilVal, diagsError(diags)
+	}
+
+	vals := make(map[string]cty.Value, len(attrs))
+	for name, attr := range attrs {
+		val, valDiags := attr.Expr.Value(nil)
+		diags = append(diags, valDiags...)
+		vals[name] = val
+	}
+	if diags.HasErrors() {
+		return cty.NilVal, diagsError(diags)
+	}
+	return cty.ObjectVal(vals), nil
+}
+
+// diagsError flattens HCL error diagnostics into a single error using the same
+// "on line X, column Y" wording go-cty-yaml uses, so all decoders report alike.
+func diagsError(diags hcl.Diagnostics) error {
+	var msgs []string
+	for _, diag := range diags {
+		if diag.Severity != hcl.DiagError {
+			continue
+		}
+		msg := diag.Summary
+		if diag.Detail != "" {
+			msg = fmt.Sprintf("%s; %s", diag.Summary, diag.Detail)
+		}
+		if diag.Subject != nil {
+			msg = fmt.Sprintf("on line %d, column %d: %s", diag.Subject.Start.Line, diag.Subject.Start.Column, msg)
+		}
+		msgs = append(msgs, msg)
+	}
+	return fmt.Errorf("%s", strings.Join(msgs, "\n"))
+}
@@ -0,0 +1,60 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// IdenticalGroup is a set of files that decode to the same value, like an
+// environment's inputs copied to another unchanged.
+type IdenticalGroup struct {
+	// Hash is the ValueHash the files share.
+	Hash string `json:"hash"`
+	// Paths are the files, in order.
+	Paths []string `json:"paths"`
+}
+
+// ValueHash returns a hash of value normalized to JSON, with object keys in
+// order, so files that decode to the same value hash the same whatever their
+// formatting, key order, comments or syntax.   Null values, like those of
+// empty files, have no hash.
+func ValueHash(value cty.Value) string {
+	if value.IsNull() || !value.IsWhollyKnown() {
+		return ""
+	}
+	normalized, err := ctyjson.SimpleJSONValue{Value: value}.MarshalJSON()
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(normalized)
+	return hex.EncodeToString(sum[:])
+}
+
+// Identical returns the groups of files in the report that decoded to the same
+// value, with Options.ValueHashes, ordered by their first path.
+func (rep *Report) Identical() []IdenticalGroup {
+	paths := make(map[string][]string)
+	for _, result := range rep.Results {
+		if result.ValueHash != "" {
+			paths[result.ValueHash] = append(paths[result.ValueHash], result.Path)
+		}
+	}
+	var groups []IdenticalGroup
+	for hash, group := range paths {
+		if len(group) > 1 {
+			sort.Strings(group)
+			groups = append(groups, IdenticalGroup{Hash: hash, Paths: group})
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Paths[0] < groups[j].Paths[0]
+	})
+	return groups
+}
@@ -0,0 +1,280 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignorePattern is one compiled line of a gitignore style file.
+type ignorePattern struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreFile is the patterns of one ignore file.   Paths are matched relative to
+// the directory holding it: dir is that directory for files inside the walked
+// tree (slash separated, "" for the top), and lead is the path from it down to
+// the walked root for files in a parent directory.
+type ignoreFile struct {
+	dir      string
+	lead     string
+	patterns []ignorePattern
+}
+
+// ignoreStack is the ignore files that apply in a directory, innermost first.
+// Stacks are shared between the walkers of sibling directories, so they are
+// never modified, only pushed onto.
+type ignoreStack struct {
+	file   *ignoreFile
+	parent *ignoreStack
+}
+
+// push returns the stack with file on top, or the same stack if file is nil.
+func (s *ignoreStack) push(file *ignoreFile) *ignoreStack {
+	if file == nil {
+		return s
+	}
+	return &ignoreStack{file: file, parent: s}
+}
+
+// ignored reports whether the slash separated path name, relative to the walked
+// root, is ignored.   As with git the last matching pattern wins and patterns in
+// deeper files come later.
+func (s *ignoreStack) ignored(name string, isDir bool) bool {
+	var files []*ignoreFile
+	for ; s != nil; s = s.parent {
+		files = append(files, s.file)
+	}
+
+	ignored := false
+	for i := len(files) - 1; i >= 0; i-- {
+		file := files[i]
+		rel := name
+		switch {
+		case file.lead != "":
+			rel = file.lead + "/" + name
+		case file.dir != "":
+			if !strings.HasPrefix(name, file.dir+"/") {
+				continue
+			}
+			rel = strings.TrimPrefix(name, file.dir+"/")
+		}
+		for _, pattern := range file.patterns {
+			if pattern.dirOnly && !isDir {
+				continue
+			}
+			if pattern.re.MatchString(rel) {
+				ignored = !pattern.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// parseIgnore compiles the lines of a gitignore style file.   Blank lines and #
+// comments are skipped, ! negates, a trailing / matches directories only and a
+// / anywhere else anchors the pattern to the file's directory, otherwise it
+// matches a name at any depth.   *, ?, [...] and ** work as in git.
+func parseIgnore(data []byte) []ignorePattern {
+	var patterns []ignorePattern
+	for _, line := range strings.Split(string(bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))), "\n") {
+		if !strings.HasSuffix(line, `\ `) {
+			line = strings.TrimRight(line, " ")
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var pattern ignorePattern
+		if strings.HasPrefix(line, "!") {
+			pattern.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			pattern.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if strings.TrimPrefix(line, "/") == "" {
+			continue
+		}
+
+		compiled, err := compilePattern(line)
+		if err != nil {
+			continue // git skips patterns it cannot parse too
+		}
+		pattern.re = compiled.re
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
+
+// globRegexp translates a gitignore glob to a regular expression.
+func globRegexp(glob string) string {
+	var expr strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			expr.WriteString("(?:.*/)?")
+			i += 2
+		case glob[i:] == "/**":
+			expr.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case c == '*':
+			expr.WriteString("[^/]*")
+		case c == '?':
+			expr.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				expr.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			expr.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return expr.String()
+}
+
+// parseIgnore parses data as an ignore file, its patterns folding case when
+// Options.IgnorePathCase is set, as git does with core.ignoreCase.
+func (r *Runner) parseIgnore(data []byte) []ignorePattern {
+	patterns := parseIgnore(data)
+	if r.opts.IgnorePathCase {
+		for i := range patterns {
+			patterns[i].re = foldRegexp(patterns[i].re)
+		}
+	}
+	return patterns
+}
+
+// readIgnores returns the stack with the IgnoreFiles found in dir pushed on.
+func (r *Runner) readIgnores(root searchRoot, dir string, stack *ignoreStack) *ignoreStack {
+	base := dir
+	if base == "." {
+		base = ""
+	}
+	for _, name := range r.opts.IgnoreFiles {
+		data, err := fs.ReadFile(root.fsys, path.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		stack = stack.push(&ignoreFile{dir: base, patterns: r.parseIgnore(data)})
+	}
+	return stack
+}
+
+// parentIgnores returns the IgnoreFiles in the directories above the OS root
+// dir, up to the top of the git work tree holding it, outermost at the bottom
+// of the stack.   Outside a work tree there are none.
+func (r *Runner) parentIgnores(dir string) *ignoreStack {
+	if len(r.opts.IgnoreFiles) == 0 {
+		return nil
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil
+	}
+	top, ok := WorkTreeTop(abs)
+	if !ok || top == abs {
+		return nil
+	}
+
+	// Push From The Top Down, So Deeper Files Are Consulted Later
+	var parents []string
+	for current := filepath.Dir(abs); ; current = filepath.Dir(current) {
+		parents = append(parents, current)
+		if current == top {
+			break
+		}
+	}
+	var stack *ignoreStack
+	for i := len(parents) - 1; i >= 0; i-- {
+		lead, err := filepath.Rel(parents[i], abs)
+		if err != nil {
+			continue
+		}
+		for _, name := range r.opts.IgnoreFiles {
+			data, err := os.ReadFile(filepath.Join(parents[i], name))
+			if err != nil {
+				continue
+			}
+			stack = stack.push(&ignoreFile{lead: filepath.ToSlash(lead), patterns: r.parseIgnore(data)})
+		}
+	}
+	return stack
+}
+
+// pathIgnored reports whether a listed file, name in root, is ignored by the
+// IgnoreFiles in the directories leading to it.   OS files are checked from the
+// top of their git work tree (or their own directory outside one), so ignored
+// parent directories count too.
+func (r *Runner) pathIgnored(root searchRoot, name string) bool {
+	if len(r.opts.IgnoreFiles) == 0 {
+		return false
+	}
+	if root.prefix != "" {
+		abs, err := filepath.Abs(root.display(name))
+		if err != nil {
+			return false
+		}
+		top, ok := WorkTreeTop(filepath.Dir(abs))
+		if !ok {
+			top = filepath.Dir(abs)
+		}
+		rel, err := filepath.Rel(top, abs)
+		if err != nil {
+			return false
+		}
+		root, name = searchRoot{fsys: os.DirFS(top), prefix: top}, filepath.ToSlash(rel)
+	}
+
+	stack := r.readIgnores(root, ".", nil)
+	parts := strings.Split(name, "/")
+	for i := 1; i < len(parts); i++ {
+		dir := strings.Join(parts[:i], "/")
+		if stack.ignored(dir, true) {
+			return true
+		}
+		stack = r.readIgnores(root, dir, stack)
+	}
+	return stack.ignored(name, false)
+}
+
+// WorkTreeTop returns the nearest directory at or above the absolute path dir
+// that holds .git, or false if there is none.
+func WorkTreeTop(dir string) (string, bool) {
+	for current := dir; ; {
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			return current, true
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return "", false
+		}
+		current = parent
+	}
+}
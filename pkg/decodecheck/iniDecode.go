@@ -0,0 +1,194 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// INIDecodeFunc decodes an INI file into an object where keys before the first
+// [section] header are strings and each section is a map of strings.
+var INIDecodeFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "src",
+			Type: cty.String,
+		},
+	},
+	Type: function.StaticReturnType(cty.DynamicPseudoType),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		global := map[string]cty.Value{}
+		sections := map[string]map[string]cty.Value{}
+		current := global
+
+		for i, line := range strings.Split(args[0].AsString(), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || line[0] == ';' || line[0] == '#' {
+				continue
+			}
+
+			if line[0] == '[' {
+				if !strings.HasSuffix(line, "]") || len(line) < 3 {
+					return cty.NilVal, fmt.Errorf("on line %d: malformed section header %q", i+1, line)
+				}
+				name := strings.TrimSpace(line[1 : len(line)-1])
+				if _, ok := sections[name]; !ok {
+					sections[name] = map[string]cty.Value{}
+				}
+				current = sections[name]
+				continue
+			}
+
+			sep := strings.IndexAny(line, "=:")
+			if sep < 1 {
+				return cty.NilVal, fmt.Errorf("on line %d: expected key = value, found %q", i+1, line)
+			}
+			key := strings.TrimSpace(line[:sep])
+			current[key] = cty.StringVal(unquote(strings.TrimSpace(line[sep+1:])))
+		}
+
+		for name, keys := range sections {
+			if _, ok := global[name]; ok {
+				return cty.NilVal, fmt.Errorf("section [%s] has the same name as a top level key", name)
+			}
+			if len(keys) == 0 {
+				global[name] = cty.MapValEmpty(cty.String)
+			} else {
+				global[name] = cty.MapVal(keys)
+			}
+		}
+		return cty.ObjectVal(global), nil
+	},
+})
+
+// unquote strips one pair of matching surrounding quotes from an INI value.
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// PropertiesDecodeFunc decodes a Java .properties file into a map of strings,
+// following java.util.Properties: "=", ":" or whitespace separated keys, "#"
+// and "!" comments, backslash line continuations and escapes including \uXXXX.
+var PropertiesDecodeFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "src",
+			Type: cty.String,
+		},
+	},
+	Type: function.StaticReturnType(cty.DynamicPseudoType),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		props := map[string]cty.Value{}
+		lines := strings.Split(strings.ReplaceAll(args[0].AsString(), "\r\n", "\n"), "\n")
+
+		for i := 0; i < len(lines); i++ {
+			lineNum := i + 1
+			logical := strings.TrimLeft(lines[i], " \t\f")
+			if logical == "" || logical[0] == '#' || logical[0] == '!' {
+				continue
+			}
+
+			// Join Continuation Lines, Which End In An Odd Number Of Backslashes
+			for continues(logical) && i+1 < len(lines) {
+				i++
+				logical = logical[:len(logical)-1] + strings.TrimLeft(lines[i], " \t\f")
+			}
+			if continues(logical) {
+				logical = logical[:len(logical)-1]
+			}
+
+			key, value := splitProperty(logical)
+			unescapedKey, err := unescapeProperty(key)
+			if err != nil {
+				return cty.NilVal, fmt.Errorf("on line %d: %v", lineNum, err)
+			}
+			unescapedValue, err := unescapeProperty(value)
+			if err != nil {
+				return cty.NilVal, fmt.Errorf("on line %d: %v", lineNum, err)
+			}
+			props[unescapedKey] = cty.StringVal(unescapedValue)
+		}
+
+		if len(props) == 0 {
+			return cty.MapValEmpty(cty.String), nil
+		}
+		return cty.MapVal(props), nil
+	},
+})
+
+// continues reports whether a properties line ends in an unescaped backslash.
+func continues(line string) bool {
+	slashes := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		slashes++
+	}
+	return slashes%2 == 1
+}
+
+// splitProperty splits a logical properties line at the first unescaped
+// separator, returning the still escaped key and value.
+func splitProperty(line string) (string, string) {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '=', ':':
+			return line[:i], strings.TrimLeft(line[i+1:], " \t\f")
+		case ' ', '\t', '\f':
+			rest := strings.TrimLeft(line[i:], " \t\f")
+			if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+				rest = strings.TrimLeft(rest[1:], " \t\f")
+			}
+			return line[:i], rest
+		}
+	}
+	return line, ""
+}
+
+// unescapeProperty resolves the backslash escapes allowed in properties files.
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf("malformed \\uxxxx escape in %q", s)
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("malformed \\uxxxx escape in %q", s)
+			}
+			b.WriteRune(rune(r))
+			i += 4
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
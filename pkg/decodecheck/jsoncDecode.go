@@ -0,0 +1,41 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"github.com/tailscale/hujson"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// JSONCDecodeFunc accepts the relaxed "JSON with comments and commas" syntax users
+// tend to hand write.   Comments and trailing commas are stripped with hujson and
+// the standardized result goes through the normal jsondecode function, so values
+// come out exactly as terraform would see them once the file is cleaned up.
+// Nothing else from JSON5 is accepted, so unquoted keys, single quoted strings
+// and hex numbers still fail.
+var JSONCDecodeFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "src",
+			Type: cty.String,
+		},
+	},
+	Type: function.StaticReturnType(cty.DynamicPseudoType),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		standard, err := hujson.Standardize([]byte(args[0].AsString()))
+		if err != nil {
+			return cty.NilVal, err
+		}
+		return stdlib.JSONDecode(cty.StringVal(string(standard)))
+	},
+})
+
+// jsoncStandard reports whether src is standard JSON, without the comments and
+// trailing commas JSONCDecodeFunc accepts.
+func jsoncStandard(src []byte) bool {
+	value, err := hujson.Parse(src)
+	return err == nil && value.IsStandard()
+}
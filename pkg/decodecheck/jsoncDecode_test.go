@@ -0,0 +1,51 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRelaxedJSONStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		src     string
+		relaxed bool
+		want    Status
+	}{
+		{name: "jsonc standard", file: "a.jsonc", src: `{"a": 1}`, want: Passed},
+		{name: "jsonc comment", file: "a.jsonc", src: "{\"a\": 1 // one\n}", want: NonStrict},
+		{name: "jsonc trailing comma", file: "a.jsonc", src: `{"a": 1,}`, want: NonStrict},
+		{name: "json trailing comma", file: "a.json", src: `{"a": 1,}`, want: DecodeFailed},
+		{name: "json trailing comma relaxed", file: "a.json", src: `{"a": 1,}`, relaxed: true, want: NonStrict},
+		{name: "json standard relaxed", file: "a.json", src: `{"a": 1}`, relaxed: true, want: Passed},
+		{name: "unquoted key relaxed", file: "a.json", src: `{a: 1}`, relaxed: true, want: DecodeFailed},
+		{name: "single quotes relaxed", file: "a.json", src: `{"a": 'one'}`, relaxed: true, want: DecodeFailed},
+		{name: "hex number relaxed", file: "a.json", src: `{"a": 0x1F}`, relaxed: true, want: DecodeFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.FS = fstest.MapFS{tt.file: {Data: []byte(tt.src)}}
+			opts.RelaxedJSON = tt.relaxed
+			report := NewRunner(opts).Run(context.Background())
+			if len(report.Results) != 1 {
+				t.Fatalf("got %d results, want 1", len(report.Results))
+			}
+			if got := report.Results[0].Status; got != tt.want {
+				t.Errorf("status = %v (%v), want %v", got, report.Results[0].Err, tt.want)
+			}
+			nonStrict := 0
+			if tt.want == NonStrict {
+				nonStrict = 1
+			}
+			if got := report.Counts.NonStrictFiles("total"); got != nonStrict {
+				t.Errorf("non-strict count = %d, want %d", got, nonStrict)
+			}
+		})
+	}
+}
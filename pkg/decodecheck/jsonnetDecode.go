@@ -0,0 +1,116 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-jsonnet"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// importDirDecoders are the decoders that take the directory their imports must
+// stay within after the file path.
+var importDirDecoders = []string{"jsonnet"}
+
+// JsonnetDecodeFunc renders a Jsonnet document with go-jsonnet and decodes the
+// JSON it produces with jsondecode, the same pre-render step we run before
+// handing the output to terragrunt.   The optional path argument is the file the
+// source came from, relative imports resolving from its directory, and the
+// optional argument after it the directory imports must stay within, by
+// default the file's own.   An empty directory, or no path, refuses every
+// import.
+var JsonnetDecodeFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "src",
+			Type: cty.String,
+		},
+	},
+	VarParam: &function.Parameter{
+		Name: "path",
+		Type: cty.String,
+	},
+	Type: function.StaticReturnType(cty.DynamicPseudoType),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		filename := sourceName(args)
+		importer := &jsonnetImporter{libDir: filepath.Dir(filename), contents: map[string]jsonnet.Contents{}}
+		switch {
+		case len(args) > 2:
+			importer.dir = args[2].AsString()
+		case filename != "":
+			importer.dir = importer.libDir
+		}
+		if importer.dir != "" {
+			root, err := os.OpenRoot(importer.dir)
+			if err == nil {
+				defer root.Close()
+				importer.root = root
+			}
+		}
+
+		vm := jsonnet.MakeVM()
+		vm.Importer(importer)
+		out, err := vm.EvaluateAnonymousSnippet(filename, args[0].AsString())
+		if err != nil {
+			return cty.NilVal, fmt.Errorf("%s", strings.TrimSpace(err.Error()))
+		}
+		return stdlib.JSONDecode(cty.StringVal(out))
+	},
+})
+
+// jsonnetImporter resolves Jsonnet imports relative to the importing file, then
+// from libDir, the directory of the rendered file, reading them through root so that neither .. nor symlinks lead
+// outside dir.   Absolute imports are refused, as is every import without a
+// root.
+type jsonnetImporter struct {
+	dir      string
+	libDir   string
+	root     *os.Root
+	contents map[string]jsonnet.Contents
+}
+
+// Import implements jsonnet.Importer.
+func (imp *jsonnetImporter) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	if imp.root == nil {
+		return jsonnet.Contents{}, "", fmt.Errorf("cannot import %s: imports are not allowed here", importedPath)
+	}
+	if path.IsAbs(importedPath) || filepath.IsAbs(importedPath) || filepath.VolumeName(importedPath) != "" {
+		return jsonnet.Contents{}, "", fmt.Errorf("cannot import %s: absolute imports are not allowed", importedPath)
+	}
+
+	// The Rendered File Is Imported From As An Anonymous Snippet, With No Name
+	dirs := []string{imp.libDir}
+	if importedFrom != "" && filepath.Dir(importedFrom) != imp.libDir {
+		dirs = []string{filepath.Dir(importedFrom), imp.libDir}
+	}
+	for _, dir := range dirs {
+		foundAt := filepath.Join(dir, filepath.FromSlash(importedPath))
+		if contents, ok := imp.contents[foundAt]; ok {
+			return contents, foundAt, nil
+		}
+		rel, err := filepath.Rel(imp.dir, foundAt)
+		if err != nil || !filepath.IsLocal(rel) {
+			return jsonnet.Contents{}, "", fmt.Errorf("cannot import %s: it is outside %s", importedPath, imp.dir)
+		}
+		data, err := imp.root.ReadFile(rel)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return jsonnet.Contents{}, "", fmt.Errorf("cannot import %s: %v", importedPath, err)
+		}
+		imp.contents[foundAt] = jsonnet.MakeContentsRaw(data)
+		return imp.contents[foundAt], foundAt, nil
+	}
+	return jsonnet.Contents{}, "", fmt.Errorf("cannot import %s: file not found", importedPath)
+}
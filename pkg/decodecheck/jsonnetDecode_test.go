@@ -0,0 +1,107 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestJsonnetDecode(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "base.libsonnet"), []byte("{ region: 'us-east-1' }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "envs", "prod"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(secret, []byte("secret-content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "main.jsonnet")
+	nested := filepath.Join(dir, "envs", "prod", "main.jsonnet")
+
+	tests := []struct {
+		name    string
+		args    []cty.Value
+		want    cty.Value
+		wantErr string
+	}{
+		{
+			name: "snippet",
+			args: []cty.Value{cty.StringVal("{ a: 1 + 1 }")},
+			want: cty.ObjectVal(map[string]cty.Value{"a": cty.NumberIntVal(2)}),
+		},
+		{
+			name: "relative import",
+			args: []cty.Value{cty.StringVal("(import 'base.libsonnet') { env: 'prod' }"), cty.StringVal(path)},
+			want: cty.ObjectVal(map[string]cty.Value{"env": cty.StringVal("prod"), "region": cty.StringVal("us-east-1")}),
+		},
+		{
+			name: "import from below the root",
+			args: []cty.Value{cty.StringVal("(import '../../base.libsonnet').region"), cty.StringVal(nested), cty.StringVal(dir)},
+			want: cty.StringVal("us-east-1"),
+		},
+		{
+			name:    "import outside the file's directory",
+			args:    []cty.Value{cty.StringVal("import '../../base.libsonnet'"), cty.StringVal(nested)},
+			wantErr: "outside",
+		},
+		{
+			name:    "import outside the root",
+			args:    []cty.Value{cty.StringVal("importstr '../" + filepath.Base(filepath.Dir(secret)) + "/secret.txt'"), cty.StringVal(path), cty.StringVal(dir)},
+			wantErr: "outside",
+		},
+		{
+			name:    "absolute import",
+			args:    []cty.Value{cty.StringVal("importstr '" + filepath.ToSlash(secret) + "'"), cty.StringVal(path), cty.StringVal(dir)},
+			wantErr: "absolute imports are not allowed",
+		},
+		{
+			name:    "imports refused without a root",
+			args:    []cty.Value{cty.StringVal("import 'base.libsonnet'"), cty.StringVal(path), cty.StringVal("")},
+			wantErr: "imports are not allowed",
+		},
+		{
+			name:    "imports refused without a path",
+			args:    []cty.Value{cty.StringVal("importstr '" + filepath.ToSlash(secret) + "'")},
+			wantErr: "imports are not allowed",
+		},
+		{
+			name:    "syntax error",
+			args:    []cty.Value{cty.StringVal("{ a: 1 +, }"), cty.StringVal(path)},
+			wantErr: "main.jsonnet:1:9",
+		},
+		{
+			name:    "runtime error",
+			args:    []cty.Value{cty.StringVal("{ a: error 'boom' }"), cty.StringVal(path)},
+			wantErr: "boom",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JsonnetDecodeFunc.Call(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if strings.Contains(err.Error(), "secret-content") {
+					t.Fatalf("err = %v, which holds the contents of a file outside the root", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.RawEquals(tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// NestingRule returns a CheckRule failing the values of files matching pattern
+// that nest objects and lists more than maxDepth levels deep, where a flat
+// object or list is one level.
+func NestingRule(pattern string, maxDepth int) CheckRule {
+	return CheckRule{
+		Pattern:  pattern,
+		Category: "max-nesting",
+		Name:     "max-nesting=" + strconv.Itoa(maxDepth),
+		Check: func(path string, value cty.Value) error {
+			if at, ok := deeperThan(value, maxDepth, nil); ok {
+				return fmt.Errorf("nesting deeper than %d levels at %s", maxDepth, formatSteps(at))
+			}
+			return nil
+		},
+	}
+}
+
+// deeperThan reports whether value nests more than levels deep, with the path
+// to the first value past the limit.   It never descends more than levels + 1.
+func deeperThan(value cty.Value, levels int, at []string) ([]string, bool) {
+	ty := value.Type()
+	if value.IsNull() || !value.IsKnown() || !(ty.IsObjectType() || ty.IsMapType() || ty.IsListType() || ty.IsTupleType() || ty.IsSetType()) {
+		return nil, false
+	}
+	if levels == 0 {
+		return at, true
+	}
+	for it := value.ElementIterator(); it.Next(); {
+		key, element := it.Element()
+		var step string
+		switch {
+		case ty.IsSetType():
+			step = "[*]"
+		case ty.IsObjectType() || ty.IsMapType():
+			step = "." + key.AsString()
+		default:
+			index, _ := key.AsBigFloat().Int64()
+			step = "[" + strconv.FormatInt(index, 10) + "]"
+		}
+		if deeper, ok := deeperThan(element, levels-1, append(at, step)); ok {
+			return deeper, true
+		}
+	}
+	return nil, false
+}
+
+// formatSteps joins the steps of a path into dotted form, like a.b[0].c.
+func formatSteps(steps []string) string {
+	if len(steps) == 0 {
+		return "the top level"
+	}
+	return strings.TrimPrefix(strings.Join(steps, ""), ".")
+}
+
+// BudgetRule returns a CheckRule failing the values of files matching pattern
+// that hold more than maxElements values in all, counting every element of
+// every object and list, or more than maxStringBytes bytes across all their
+// strings.   Zero or less disables either limit.
+func BudgetRule(pattern string, maxElements int, maxStringBytes int64) CheckRule {
+	return CheckRule{
+		Pattern:  pattern,
+		Category: "budget",
+		Name:     fmt.Sprintf("budget=%d,%d", maxElements, maxStringBytes),
+		Check: func(path string, value cty.Value) error {
+			budget := valueBudget{maxElements: maxElements, maxStringBytes: maxStringBytes}
+			return budget.spend(value)
+		},
+	}
+}
+
+// valueBudget counts the values and string bytes seen so far against limits.
+type valueBudget struct {
+	maxElements    int
+	maxStringBytes int64
+	elements       int
+	stringBytes    int64
+}
+
+// spend adds value and everything in it to the budget, returning an error as
+// soon as a limit is passed.
+func (b *valueBudget) spend(value cty.Value) error {
+	if value.IsNull() || !value.IsKnown() {
+		return nil
+	}
+	ty := value.Type()
+	if ty == cty.String {
+		b.stringBytes += int64(len(value.AsString()))
+		if b.maxStringBytes > 0 && b.stringBytes > b.maxStringBytes {
+			return fmt.Errorf("decoded strings total more than %d bytes", b.maxStringBytes)
+		}
+		return nil
+	}
+	if !(ty.IsObjectType() || ty.IsMapType() || ty.IsListType() || ty.IsTupleType() || ty.IsSetType()) {
+		return nil
+	}
+	for it := value.ElementIterator(); it.Next(); {
+		_, element := it.Element()
+		b.elements++
+		if b.maxElements > 0 && b.elements > b.maxElements {
+			return fmt.Errorf("decoded value holds more than %d elements", b.maxElements)
+		}
+		if err := b.spend(element); err != nil {
+			return err
+		}
+	}
+	return nil
+}
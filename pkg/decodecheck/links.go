@@ -0,0 +1,69 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"io/fs"
+	"os"
+	"sort"
+)
+
+// fileKey identifies a file on the OS filesystem, however many hard links or
+// symlinks lead to it.
+type fileKey struct {
+	dev, ino uint64
+}
+
+// firstLink records the file name in root, described by info, by its device
+// and inode with Options.DedupeLinks, returning the path it was first found at
+// and false if another hard link or symlink to it was found before.   Files
+// whose identity is unknown, like those in archives, an Options.FS or on
+// platforms without inodes, are always checked.
+func (r *Runner) firstLink(root searchRoot, name string, info fs.FileInfo) (string, bool) {
+	if !r.opts.DedupeLinks || root.prefix == "" || root.outer != nil {
+		return "", true
+	}
+	display := root.display(name)
+	if info.Mode()&fs.ModeSymlink != 0 {
+		target, err := os.Stat(display)
+		if err != nil {
+			return "", true
+		}
+		info = target
+	}
+	key, ok := fileKeyOf(info)
+	if !ok {
+		return "", true
+	}
+
+	r.linksMu.Lock()
+	defer r.linksMu.Unlock()
+	if first, seen := r.links[key]; seen {
+		r.aliases[first] = append(r.aliases[first], display)
+		return first, false
+	}
+	r.links[key] = display
+	return "", true
+}
+
+// applyAliases sets the Aliases of each result in results to the other paths
+// the same file was found at this run.   Whichever link the walk reached first
+// was checked, so each result is moved to the first of its paths in order,
+// keeping reports the same from run to run.
+func (r *Runner) applyAliases(results []Result) {
+	r.linksMu.Lock()
+	defer r.linksMu.Unlock()
+	if len(r.aliases) == 0 {
+		return
+	}
+	for i := range results {
+		aliases := r.aliases[results[i].Path]
+		if len(aliases) == 0 {
+			continue
+		}
+		paths := append([]string{results[i].Path}, aliases...)
+		sort.Strings(paths)
+		results[i].Path, results[i].Aliases = paths[0], paths[1:]
+	}
+}
@@ -0,0 +1,13 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+//go:build !unix
+
+package decodecheck
+
+import "io/fs"
+
+// fileKeyOf reports false, files have no inodes to tell links apart by here.
+func fileKeyOf(info fs.FileInfo) (fileKey, bool) {
+	return fileKey{}, false
+}
@@ -0,0 +1,20 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+//go:build unix
+
+package decodecheck
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileKeyOf returns the device and inode of the file info describes.
+func fileKeyOf(info fs.FileInfo) (fileKey, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileKey{}, false
+	}
+	return fileKey{dev: uint64(stat.Dev), ino: uint64(stat.Ino)}, true
+}
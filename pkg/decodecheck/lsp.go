@@ -0,0 +1,249 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// lspMessage is a JSON-RPC 2.0 request, notification or response.
+type lspMessage struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  interface{}      `json:"result,omitempty"`
+	Error   *lspError        `json:"error,omitempty"`
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspDiagnostic struct {
+	Range struct {
+		Start lspPosition `json:"start"`
+		End   lspPosition `json:"end"`
+	} `json:"range"`
+	Severity int    `json:"severity"`
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+}
+
+// textDocumentParams covers the didOpen, didChange, didSave and didClose
+// notifications, which all carry the document URI and some carry its text.
+type textDocumentParams struct {
+	TextDocument struct {
+		URI  string  `json:"uri"`
+		Text *string `json:"text"`
+	} `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+	Text *string `json:"text"`
+}
+
+// LSP diagnostic severities.
+const (
+	lspSeverityError   = 1
+	lspSeverityWarning = 2
+)
+
+// ServeLSP runs a minimal Language Server Protocol server over in and out (stdin
+// and stdout for editors), publishing a diagnostic for each open document that
+// fails to decode using the same decoders and rules as a scan.   Documents sync
+// in full, the decoder is picked from the document's file name.   It returns nil
+// after the client sends shutdown then exit, or when in reaches EOF.
+func ServeLSP(ctx context.Context, in io.Reader, out io.Writer, opts Options) error {
+	runner := NewRunner(opts)
+	reader := bufio.NewReader(in)
+	writer := bufio.NewWriter(out)
+	shutdown := false
+
+	for {
+		msg, err := readLSPMessage(reader)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch msg.Method {
+		case "initialize":
+			err = writeLSPMessage(writer, lspMessage{ID: msg.ID, Result: map[string]interface{}{
+				"capabilities": map[string]interface{}{
+					"textDocumentSync": map[string]interface{}{"openClose": true, "change": 1, "save": map[string]bool{"includeText": true}},
+				},
+				"serverInfo": map[string]string{"name": "decodeTest"},
+			}})
+		case "shutdown":
+			shutdown = true
+			err = writeLSPMessage(writer, lspMessage{ID: msg.ID, Result: json.RawMessage("null")})
+		case "exit":
+			if !shutdown {
+				return errors.New("lsp: exit before shutdown")
+			}
+			return nil
+		case "textDocument/didOpen", "textDocument/didChange", "textDocument/didSave", "textDocument/didClose":
+			var params textDocumentParams
+			if err := json.Unmarshal(msg.Params, &params); err != nil {
+				continue
+			}
+			if msg.Method == "textDocument/didSave" && params.Text == nil {
+				continue // nothing new to check
+			}
+
+			// Closed Documents Get Their Diagnostics Cleared
+			diagnostics := []lspDiagnostic{}
+			if text, ok := params.text(); ok && msg.Method != "textDocument/didClose" {
+				diagnostics = runner.lspDiagnostics(ctx, params.TextDocument.URI, text)
+			}
+			err = writeLSPMessage(writer, lspMessage{Method: "textDocument/publishDiagnostics", Params: mustMarshal(map[string]interface{}{
+				"uri":         params.TextDocument.URI,
+				"diagnostics": diagnostics,
+			})})
+		default:
+			// Answer Unknown Requests, Ignore Unknown Notifications
+			if msg.ID != nil {
+				err = writeLSPMessage(writer, lspMessage{ID: msg.ID, Error: &lspError{Code: -32601, Message: "method not found: " + msg.Method}})
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// text returns the full document text carried by the notification, if any.
+func (p textDocumentParams) text() (string, bool) {
+	switch {
+	case len(p.ContentChanges) > 0:
+		return p.ContentChanges[len(p.ContentChanges)-1].Text, true
+	case p.TextDocument.Text != nil:
+		return *p.TextDocument.Text, true
+	case p.Text != nil:
+		return *p.Text, true
+	}
+	return "", false
+}
+
+// lspDiagnostics decodes text as the document at uri and returns its problems.
+func (r *Runner) lspDiagnostics(ctx context.Context, uri, text string) []lspDiagnostic {
+	name := uri
+	if u, err := url.Parse(uri); err == nil && u.Scheme == "file" {
+		name = u.Path
+	}
+
+	src := []byte(text)
+	result, ok := r.Validate(ctx, name, "", src)
+	diagnostics := []lspDiagnostic{}
+	if !ok || result.Status == NoDecoder {
+		return diagnostics
+	}
+
+	for _, warning := range result.Warnings {
+		diagnostic := lspDiagnostic{Severity: lspSeverityWarning, Source: "decodeTest", Message: warning}
+		if line, column, ok := errorPosition(errors.New(warning), src); ok {
+			diagnostic.Range.Start = lspPositionOf(src, line, column)
+			diagnostic.Range.End = diagnostic.Range.Start
+		}
+		diagnostics = append(diagnostics, diagnostic)
+	}
+	if result.Status == Passed {
+		return diagnostics
+	}
+
+	diagnostic := lspDiagnostic{Severity: lspSeverityError, Source: "decodeTest"}
+	if result.Status == NonStrict {
+		diagnostic.Severity = lspSeverityWarning
+		diagnostic.Message = "non-strict JSON (comments or trailing commas)"
+	} else {
+		diagnostic.Message = result.Err.Error()
+		if result.Line > 0 {
+			diagnostic.Range.Start = lspPositionOf(src, result.Line, result.Column)
+			diagnostic.Range.End = diagnostic.Range.Start
+		}
+	}
+	return append(diagnostics, diagnostic)
+}
+
+// lspPositionOf converts a 1 based line and byte column in src to the 0 based
+// line and UTF-16 character offset LSP uses.
+func lspPositionOf(src []byte, line, column int) lspPosition {
+	lines := bytes.Split(src, []byte("\n"))
+	if line < 1 || line > len(lines) {
+		return lspPosition{Line: max(line-1, 0)}
+	}
+	text := lines[line-1]
+	if column-1 < len(text) {
+		text = text[:max(column-1, 0)]
+	}
+	character := 0
+	for len(text) > 0 {
+		r, size := utf8.DecodeRune(text)
+		character += len(utf16.Encode([]rune{r}))
+		text = text[size:]
+	}
+	return lspPosition{Line: line - 1, Character: character}
+}
+
+// readLSPMessage reads one Content-Length framed JSON-RPC message.
+func readLSPMessage(reader *bufio.Reader) (lspMessage, error) {
+	var msg lspMessage
+	header, err := textproto.NewReader(reader).ReadMIMEHeader()
+	if err != nil {
+		return msg, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return msg, fmt.Errorf("lsp: bad Content-Length %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return msg, err
+	}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return msg, fmt.Errorf("lsp: %v", err)
+	}
+	return msg, nil
+}
+
+// writeLSPMessage writes msg with its Content-Length header and flushes it.
+func writeLSPMessage(writer *bufio.Writer, msg lspMessage) error {
+	msg.JSONRPC = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(writer, "Content-Length: %d\r\n\r\n", len(body))
+	writer.Write(body)
+	return writer.Flush()
+}
+
+// mustMarshal encodes v, which must be JSON encodable.
+func mustMarshal(v interface{}) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
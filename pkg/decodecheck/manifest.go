@@ -0,0 +1,201 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Manifest is the contract for the input files a team promises to provide,
+// read by LoadManifest from YAML or JSON, like:
+//
+//	files:
+//	  - path: envs/prod/network.yaml
+//	    schema: schemas/network.json
+//	    require: [vpc, subnets]
+//	  - group: envs/*/terragrunt.hcl
+//	    format: hcl
+//
+// Paths, groups and schemas are relative to the directory holding the
+// manifest.
+type Manifest struct {
+	Files []ManifestEntry `yaml:"files"`
+
+	dir string
+}
+
+// ManifestEntry is one file of a Manifest, or a group of files, and what each
+// of them must be.
+type ManifestEntry struct {
+	// Path is a file that must exist and Group a glob, as matched by
+	// path/filepath.Match in each directory, that must match at least one.
+	// An entry has one or the other.
+	Path  string `yaml:"path"`
+	Group string `yaml:"group"`
+	// Format is the decoder the files are checked with, whatever their
+	// extension.
+	Format string `yaml:"format"`
+	// Schema, Type and Require are checked as by SchemaRule, TypeRule and
+	// RequireRule.
+	Schema  string   `yaml:"schema"`
+	Type    string   `yaml:"type"`
+	Require []string `yaml:"require"`
+}
+
+// LoadManifest reads the YAML or JSON manifest at name.   Unknown keys are
+// errors, so a misspelled expectation is not silently dropped.
+func LoadManifest(name string) (*Manifest, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&m); err != nil {
+		return nil, err
+	}
+	if len(m.Files) == 0 {
+		return nil, errors.New("manifest lists no files")
+	}
+	for i, entry := range m.Files {
+		if (entry.Path == "") == (entry.Group == "") {
+			return nil, fmt.Errorf("entry %d: expected one of path or group", i+1)
+		}
+		if entry.Group != "" {
+			if _, err := filepath.Match(entry.Group, ""); err != nil {
+				return nil, fmt.Errorf("group %s: %v", entry.Group, err)
+			}
+		}
+	}
+	m.dir = filepath.Dir(name)
+	return &m, nil
+}
+
+// Apply sets opts to check exactly the files of m.   Files lists them, each
+// matched by a pattern of its own with the decoder and checks of its entry,
+// and the excludes and ignore files that would leave some out are cleared.
+// Listed files that are missing fail, see RequireFiles, and so does a group
+// matching no files, reported under its glob.
+func (m *Manifest) Apply(opts *Options) error {
+	files := []string{}
+	seen := make(map[string]bool)
+	var rules []DecoderRule
+	var checks []CheckRule
+	for _, entry := range m.Files {
+		name := entry.Path
+		if name == "" {
+			name = entry.Group
+		}
+		if entry.Format != "" {
+			if _, ok := opts.Decoders[entry.Format]; !ok {
+				return fmt.Errorf("%s: unknown format %q, must be one of %s", name, entry.Format, strings.Join(DecoderNames(opts.Decoders), ", "))
+			}
+		}
+		schema := entry.Schema
+		if schema != "" && !filepath.IsAbs(schema) {
+			schema = filepath.Join(m.dir, schema)
+		}
+
+		// Expand Groups Sorted, Keeping The Glob When Nothing Matches So It Fails As Missing
+		matched := []string{m.path(name)}
+		if entry.Group != "" {
+			globbed, _ := filepath.Glob(matched[0])
+			if len(globbed) > 0 {
+				sort.Strings(globbed)
+				matched = globbed
+			}
+		}
+		for _, file := range matched {
+			file = relativePath(file)
+			pattern := literalPattern(file)
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+			if entry.Format != "" {
+				rules = append(rules, DecoderRule{Pattern: pattern, Decoder: entry.Format})
+			}
+			if schema != "" {
+				rule, err := SchemaRule(pattern, schema)
+				if err != nil {
+					return fmt.Errorf("%s: %v", name, err)
+				}
+				checks = append(checks, rule)
+			}
+			if entry.Type != "" {
+				rule, err := TypeRule(pattern, entry.Type)
+				if err != nil {
+					return fmt.Errorf("%s: %v", name, err)
+				}
+				checks = append(checks, rule)
+			}
+			if len(entry.Require) > 0 {
+				checks = append(checks, RequireRule(pattern, entry.Require))
+			}
+		}
+	}
+
+	opts.Files = files
+	opts.MatchPatterns = make([]string, len(files))
+	for i, file := range files {
+		opts.MatchPatterns[i] = literalPattern(file)
+	}
+	opts.MatchRegex = nil
+	opts.ExcludePatterns, opts.ExcludeRegex, opts.ExcludeDirs, opts.IgnoreFiles = nil, nil, nil, nil
+	opts.SkipHidden = false
+	opts.Rules = append(rules, opts.Rules...)
+	opts.Checks = append(opts.Checks, checks...)
+	opts.RequireFiles = true
+	return nil
+}
+
+// path returns name, from an entry of m, as an OS path.
+func (m *Manifest) path(name string) string {
+	name = filepath.FromSlash(name)
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(m.dir, name)
+}
+
+// relativePath returns name relative to the working directory when it can be,
+// the form listed files are matched against patterns in.
+func relativePath(name string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return name
+	}
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return name
+	}
+	if rel, err := filepath.Rel(wd, abs); err == nil {
+		return rel
+	}
+	return name
+}
+
+// literalPattern returns a pattern matching the OS path name and nothing
+// else, as listed files are matched: with its glob characters escaped and
+// anchored to the start of the path.
+func literalPattern(name string) string {
+	var pattern strings.Builder
+	pattern.WriteString("/")
+	for _, c := range filepath.ToSlash(name) {
+		if strings.ContainsRune(`*?[\`, c) {
+			pattern.WriteRune('\\')
+		}
+		pattern.WriteRune(c)
+	}
+	return pattern.String()
+}
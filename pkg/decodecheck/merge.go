@@ -0,0 +1,219 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// MergeConflict is a key whose value one layer of a merge replaced with a value
+// of another kind, like a map with a string, which is usually a mistake.
+type MergeConflict struct {
+	// Path is the dotted path to the key, like vpc.cidr.
+	Path string `json:"path"`
+	// File is the layer that replaced the value, and Kind its kind: object,
+	// list, string, number or bool.
+	File string `json:"file"`
+	Kind string `json:"kind"`
+	// PreviousFile is the layer the replaced value came from, and PreviousKind
+	// its kind.
+	PreviousFile string `json:"previousFile"`
+	PreviousKind string `json:"previousKind"`
+}
+
+func (c MergeConflict) String() string {
+	return fmt.Sprintf("%s: %s from %s replaced with %s from %s", c.Path, c.PreviousKind, c.PreviousFile, c.Kind, c.File)
+}
+
+// MergeReport is the outcome of Merge, the merged value and the conflicts met
+// on the way.
+type MergeReport struct {
+	Files     []string
+	Value     cty.Value
+	Conflicts []MergeConflict
+}
+
+// MarshalJSON encodes the merged value as plain JSON along with the files and
+// conflicts.
+func (rep *MergeReport) MarshalJSON() ([]byte, error) {
+	value, err := ctyjson.SimpleJSONValue{Value: rep.Value}.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	conflicts := rep.Conflicts
+	if conflicts == nil {
+		conflicts = []MergeConflict{}
+	}
+	return json.Marshal(struct {
+		Files     []string        `json:"files"`
+		Value     json.RawMessage `json:"value"`
+		Conflicts []MergeConflict `json:"conflicts"`
+	}{rep.Files, value, conflicts})
+}
+
+// Merge decodes files and deep merges their values in order, the way
+// terragrunt's deep merge strategy merges included configs: objects are merged
+// key by key, lists are concatenated and anything else is replaced by the later
+// file.   Replacing a value with one of another kind is reported as a conflict.
+// Files are decoded by the decoder rules for their path, and checks are not run.
+func (r *Runner) Merge(files []string) (*MergeReport, error) {
+	rep := &MergeReport{Files: files}
+	var merged *mergeNode
+	for _, file := range files {
+		value, err := r.decodeValue(file)
+		if err != nil {
+			return nil, err
+		}
+		merged = mergeValue(merged, value, file, "", &rep.Conflicts)
+	}
+	rep.Value = merged.value()
+	return rep, nil
+}
+
+// MergeDirectories deep merges the files of report that passed and were
+// decoded as one of types, as Merge does, per directory depth levels below the
+// root they were found under, like envs/prod for envs/prod/network/vpc.yaml at
+// depth 2.   Within a directory files nearer to it are merged first and deeper
+// ones over them, each level in path order, and files above depth are left
+// out.   The reports are keyed by the slash separated path of the directory
+// from its root, directories of the same name under several roots merging
+// together.
+func (r *Runner) MergeDirectories(report *Report, depth int, types []string) (map[string]*MergeReport, error) {
+	groups := make(map[string][]string)
+	for _, result := range report.Results {
+		if (result.Status != Passed && result.Status != NonStrict) || !slices.Contains(types, result.Type) {
+			continue
+		}
+		for _, root := range r.opts.Roots {
+			rel, err := filepath.Rel(root, result.Path)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				continue
+			}
+			if parts := strings.Split(filepath.ToSlash(rel), "/"); len(parts) > depth {
+				dir := strings.Join(parts[:depth], "/")
+				groups[dir] = append(groups[dir], result.Path)
+			}
+			break
+		}
+	}
+
+	merged := make(map[string]*MergeReport, len(groups))
+	for dir, files := range groups {
+		sort.SliceStable(files, func(i, j int) bool {
+			di, dj := strings.Count(filepath.ToSlash(files[i]), "/"), strings.Count(filepath.ToSlash(files[j]), "/")
+			if di != dj {
+				return di < dj
+			}
+			return files[i] < files[j]
+		})
+		rep, err := r.Merge(files)
+		if err != nil {
+			return nil, err
+		}
+		merged[dir] = rep
+	}
+	return merged, nil
+}
+
+// decodeValue reads and decodes file with the decoder its rules pick.
+func (r *Runner) decodeValue(file string) (cty.Value, error) {
+	name := r.opts.canonicalName(filepath.ToSlash(file))
+	_, decodeFunction, ok := decoderFor(r.opts.Decoders, r.rules, name)
+	if !ok {
+		return cty.NilVal, fmt.Errorf("no decoder for file %s", file)
+	}
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	args := []cty.Value{cty.StringVal(string(src))}
+	if decodeFunction.VarParam() != nil {
+		args = append(args, cty.StringVal(file))
+	}
+	value, err := decodeFunction.Call(args)
+	if err != nil {
+		return cty.NilVal, fmt.Errorf("error decoding file %s: %v", file, err)
+	}
+	return value, nil
+}
+
+// mergeNode is a merged value with the file it came from, objects kept as
+// their attributes so later layers can merge into them.
+type mergeNode struct {
+	file  string
+	leaf  cty.Value
+	attrs map[string]*mergeNode // set for objects
+}
+
+// mergeValue merges value from file over node, the value at the dotted path
+// at, returning the merged node.
+func mergeValue(node *mergeNode, value cty.Value, file, at string, conflicts *[]MergeConflict) *mergeNode {
+	kind := valueKind(value)
+	if node != nil && kind != "" && node.kind() != "" && kind != node.kind() {
+		*conflicts = append(*conflicts, MergeConflict{Path: formatSteps([]string{at}), File: file, Kind: kind, PreviousFile: node.file, PreviousKind: node.kind()})
+	}
+
+	switch {
+	case kind == "object":
+		if node == nil || node.attrs == nil {
+			node = &mergeNode{attrs: make(map[string]*mergeNode)}
+		}
+		node.file = file
+		for it := value.ElementIterator(); it.Next(); {
+			key, element := it.Element()
+			node.attrs[key.AsString()] = mergeValue(node.attrs[key.AsString()], element, file, at+"."+key.AsString(), conflicts)
+		}
+		return node
+	case kind == "list" && node != nil && node.kind() == "list":
+		return &mergeNode{file: file, leaf: cty.TupleVal(append(node.leaf.AsValueSlice(), value.AsValueSlice()...))}
+	}
+	return &mergeNode{file: file, leaf: value}
+}
+
+// kind returns the kind of the node's value, see valueKind.
+func (n *mergeNode) kind() string {
+	if n.attrs != nil {
+		return "object"
+	}
+	return valueKind(n.leaf)
+}
+
+// value returns the merged value.
+func (n *mergeNode) value() cty.Value {
+	if n == nil {
+		return cty.NullVal(cty.DynamicPseudoType)
+	}
+	if n.attrs == nil {
+		return n.leaf
+	}
+	attrs := make(map[string]cty.Value, len(n.attrs))
+	for key, attr := range n.attrs {
+		attrs[key] = attr.value()
+	}
+	return cty.ObjectVal(attrs)
+}
+
+// valueKind returns object, list or the primitive type name of value, or ""
+// for nulls, which merge with anything.
+func valueKind(value cty.Value) string {
+	ty := value.Type()
+	switch {
+	case value.IsNull() || !value.IsKnown():
+		return ""
+	case ty.IsObjectType() || ty.IsMapType():
+		return "object"
+	case ty.IsListType() || ty.IsTupleType() || ty.IsSetType():
+		return "list"
+	}
+	return ty.FriendlyName()
+}
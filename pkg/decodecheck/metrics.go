@@ -0,0 +1,108 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"path/filepath"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// Metrics are Prometheus metrics of the files checked, for Options.Metrics:
+//
+//	decodetest_files_total{type,status}         files checked
+//	decodetest_decode_errors_total{extension}   files that failed
+//	decodetest_decode_duration_seconds{type}    time taken to decode files
+//	decodetest_bytes_total                      bytes checked
+//	decodetest_runs_total                       scans of the roots completed
+//	decodetest_last_run_timestamp_seconds       when the last scan completed
+//
+// Cached results count as checked, but not towards the decode duration.
+type Metrics struct {
+	registry  *prometheus.Registry
+	files     *prometheus.CounterVec
+	errors    *prometheus.CounterVec
+	durations *prometheus.HistogramVec
+	bytes     prometheus.Counter
+	runs      prometheus.Counter
+	lastRun   prometheus.Gauge
+}
+
+// NewMetrics returns Metrics registered in a registry of their own.
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		files: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "decodetest_files_total",
+			Help: "Files checked, by decoder and status.",
+		}, []string{"type", "status"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "decodetest_decode_errors_total",
+			Help: "Files that failed, by file extension.",
+		}, []string{"extension"}),
+		durations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "decodetest_decode_duration_seconds",
+			Help:    "Time taken to decode and check files, by decoder.",
+			Buckets: prometheus.ExponentialBuckets(0.0001, 4, 10),
+		}, []string{"type"}),
+		bytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "decodetest_bytes_total",
+			Help: "Bytes of files checked.",
+		}),
+		runs: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "decodetest_runs_total",
+			Help: "Scans of the roots completed.",
+		}),
+		lastRun: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "decodetest_last_run_timestamp_seconds",
+			Help: "When the last scan of the roots completed, in seconds since the epoch.",
+		}),
+	}
+	m.registry.MustRegister(m.files, m.errors, m.durations, m.bytes, m.runs, m.lastRun)
+	return m
+}
+
+// Handler serves the metrics, with the Go runtime and process metrics, in the
+// Prometheus exposition format for a /metrics endpoint.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(prometheus.Gatherers{m.registry, prometheus.DefaultGatherer}, promhttp.HandlerOpts{})
+}
+
+// Push replaces the metrics grouped under job in the Pushgateway at url with
+// these, for one-shot runs that are not around to be scraped.
+func (m *Metrics) Push(ctx context.Context, url, job string) error {
+	return push.New(url, job).Gatherer(m.registry).PushContext(ctx)
+}
+
+// observe records result, a file with the extension ext.
+func (m *Metrics) observe(result Result, ext string) {
+	m.files.WithLabelValues(result.Type, result.Status.String()).Inc()
+	if result.Failed() {
+		m.errors.WithLabelValues(ext).Inc()
+	}
+	if result.Duration > 0 {
+		m.durations.WithLabelValues(result.Type).Observe(result.Duration.Seconds())
+	}
+	m.bytes.Add(float64(result.Size))
+}
+
+// observe records result in Options.Metrics, if set.
+func (r *Runner) observe(result Result) {
+	if r.opts.Metrics != nil {
+		r.opts.Metrics.observe(result, path.Ext(r.opts.canonicalName(filepath.ToSlash(result.Path))))
+	}
+}
+
+// ranScan records a completed scan of the roots in Options.Metrics, if set.
+func (r *Runner) ranScan() {
+	if r.opts.Metrics != nil {
+		r.opts.Metrics.runs.Inc()
+		r.opts.Metrics.lastRun.SetToCurrentTime()
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// KeyConventions are the key naming conventions KeyNamingRule knows by name.
+var KeyConventions = map[string]*regexp.Regexp{
+	"snake_case":           regexp.MustCompile(`^[a-z][a-z0-9]*(?:_[a-z0-9]+)*$`),
+	"camelCase":            regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`),
+	"PascalCase":           regexp.MustCompile(`^[A-Z][a-zA-Z0-9]*$`),
+	"kebab-case":           regexp.MustCompile(`^[a-z][a-z0-9]*(?:-[a-z0-9]+)*$`),
+	"SCREAMING_SNAKE_CASE": regexp.MustCompile(`^[A-Z][A-Z0-9]*(?:_[A-Z0-9]+)*$`),
+}
+
+// KeyConventionNames returns the names of KeyConventions, sorted.
+func KeyConventionNames() []string {
+	names := make([]string, 0, len(KeyConventions))
+	for name := range KeyConventions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// KeyNamingRule returns a CheckRule failing the values of files matching
+// pattern with object keys, at any depth, that do not follow convention, one
+// of KeyConventions or regex: and a regular expression each key must match in
+// full, naming the path to each.   Mixing conventions, like subnetIds next to
+// subnet_ids, makes lookups in Terraform fail on keys that look right.
+func KeyNamingRule(pattern, convention string) (CheckRule, error) {
+	re, ok := KeyConventions[convention]
+	if expr, isRegexp := strings.CutPrefix(convention, "regex:"); isRegexp {
+		var err error
+		if re, err = regexp.Compile("^(?:" + expr + ")$"); err != nil {
+			return CheckRule{}, err
+		}
+	} else if !ok {
+		return CheckRule{}, fmt.Errorf("unknown key convention %q, must be one of %s or regex:expr", convention, strings.Join(KeyConventionNames(), ", "))
+	}
+	return CheckRule{
+		Pattern:  pattern,
+		Category: "key-naming",
+		Name:     "key-naming=" + convention,
+		Check: func(path string, value cty.Value) error {
+			var misnamed []string
+			findMisnamedKeys(value, re, nil, &misnamed)
+			if len(misnamed) == 0 {
+				return nil
+			}
+			return fmt.Errorf("keys not in %s: %s", strings.TrimPrefix(convention, "regex:"), strings.Join(misnamed, ", "))
+		},
+	}, nil
+}
+
+// findMisnamedKeys appends the path to each object key in value that re does
+// not match to misnamed.
+func findMisnamedKeys(value cty.Value, re *regexp.Regexp, at []string, misnamed *[]string) {
+	if value.IsNull() || !value.IsKnown() {
+		return
+	}
+	ty := value.Type()
+	if !(ty.IsObjectType() || ty.IsMapType() || ty.IsListType() || ty.IsTupleType() || ty.IsSetType()) {
+		return
+	}
+	for it := value.ElementIterator(); it.Next(); {
+		key, element := it.Element()
+		var step string
+		switch {
+		case ty.IsSetType():
+			step = "[*]"
+		case ty.IsObjectType() || ty.IsMapType():
+			step = "." + key.AsString()
+			if !re.MatchString(key.AsString()) {
+				*misnamed = append(*misnamed, formatSteps(append(at, step)))
+			}
+		default:
+			index, _ := key.AsBigFloat().Int64()
+			step = "[" + strconv.FormatInt(index, 10) + "]"
+		}
+		findMisnamedKeys(element, re, append(at, step), misnamed)
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// NDJSONDecodeFunc decodes newline delimited JSON (JSON Lines), where every
+// non-blank line is an independent JSON document.   The result is a tuple of the
+// decoded lines, and the error names the first line that fails to decode.
+var NDJSONDecodeFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "src",
+			Type: cty.String,
+		},
+	},
+	Type: function.StaticReturnType(cty.DynamicPseudoType),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		var docs []cty.Value
+		for i, line := range strings.Split(args[0].AsString(), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			doc, err := stdlib.JSONDecode(cty.StringVal(line))
+			if err != nil {
+				return cty.NilVal, fmt.Errorf("on line %d: %v", i+1, err)
+			}
+			docs = append(docs, doc)
+		}
+		return cty.TupleVal(docs), nil
+	},
+})
@@ -0,0 +1,11 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+//go:build !windows
+
+package decodecheck
+
+// osPath returns name unchanged, only Windows names need rewriting.
+func osPath(name string) string {
+	return name
+}
@@ -0,0 +1,16 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+//go:build !windows
+
+package decodecheck
+
+import "testing"
+
+func TestOSPath(t *testing.T) {
+	for _, name := range []string{"", ".", "envs/prod", "/srv/envs/", `\\?\C:\envs`, "C:envs"} {
+		if got := osPath(name); got != name {
+			t.Errorf("osPath(%q) = %q, want it unchanged", name, got)
+		}
+	}
+}
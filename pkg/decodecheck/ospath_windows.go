@@ -0,0 +1,34 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// osPath returns the OS root or file name in the form it is opened and shown
+// in.   Long path prefixes are removed, \\?\C:\x becoming C:\x and
+// \\?\UNC\server\share becoming \\server\share, as they stop / being read as a
+// separator and the os package adds them back to any path too long without.
+// Drive relative names like C: or C:x are made absolute, as a DirFS joins them
+// to its files as C:\name, the root of the drive rather than its working
+// directory.
+func osPath(name string) string {
+	if name == "" {
+		return name
+	}
+	switch {
+	case len(name) > 8 && strings.EqualFold(name[:8], `\\?\UNC\`):
+		name = `\\` + name[8:]
+	case len(name) > 5 && name[:4] == `\\?\` && name[5] == ':':
+		name = name[4:]
+	}
+	if volume := filepath.VolumeName(name); len(volume) == 2 && !filepath.IsAbs(name) {
+		if abs, err := filepath.Abs(name); err == nil {
+			name = abs
+		}
+	}
+	return filepath.Clean(name)
+}
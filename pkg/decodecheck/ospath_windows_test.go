@@ -0,0 +1,53 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOSPath(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "", want: ""},
+		{name: `C:\envs\prod`, want: `C:\envs\prod`},
+		{name: `C:/envs/prod/`, want: `C:\envs\prod`},
+		{name: `\\?\C:\envs\prod`, want: `C:\envs\prod`},
+		{name: `\\?\c:\envs\..\prod`, want: `c:\prod`},
+		{name: `\\?\UNC\server\share`, want: `\\server\share`},
+		{name: `\\?\unc\server\share\envs`, want: `\\server\share\envs`},
+		{name: `\\server\share\envs`, want: `\\server\share\envs`},
+		{name: `envs\prod`, want: `envs\prod`},
+		{name: `.`, want: `.`},
+	}
+	for _, tt := range tests {
+		if got := osPath(tt.name); got != tt.want {
+			t.Errorf("osPath(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOSPathDriveRelative(t *testing.T) {
+	tests := []struct {
+		name   string
+		suffix string
+	}{
+		{name: `C:`},
+		{name: `C:envs`, suffix: `\envs`},
+		{name: `C:envs\prod`, suffix: `\envs\prod`},
+	}
+	for _, tt := range tests {
+		got := osPath(tt.name)
+		if !filepath.IsAbs(got) || filepath.VolumeName(got) != "C:" {
+			t.Errorf("osPath(%q) = %q, want an absolute path on C:", tt.name, got)
+		}
+		if !strings.HasSuffix(got, tt.suffix) {
+			t.Errorf("osPath(%q) = %q, want it to end in %q", tt.name, got, tt.suffix)
+		}
+	}
+}
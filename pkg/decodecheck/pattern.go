@@ -0,0 +1,161 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// pathPattern is a compiled glob or regular expression matched against slash
+// separated paths.   A glob without a / matches the base name at any depth, one
+// with a / matches the whole path from the root, and ** matches any number of
+// directories.   A regular expression matches the path from the root anywhere
+// it is not anchored.   source is the pattern as given, with regex: in front
+// for regular expressions.
+type pathPattern struct {
+	source string
+	re     *regexp.Regexp
+}
+
+// compilePattern compiles glob as a pathPattern.
+func compilePattern(glob string) (pathPattern, error) {
+	expr := globRegexp(strings.TrimPrefix(glob, "/"))
+	if !strings.Contains(glob, "/") {
+		expr = "(?:.*/)?" + expr
+	}
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return pathPattern{}, err
+	}
+	return pathPattern{source: glob, re: re}, nil
+}
+
+// compileRegexp compiles expr as a regular expression pathPattern.
+func compileRegexp(expr string) (pathPattern, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return pathPattern{}, err
+	}
+	return pathPattern{source: "regex:" + expr, re: re}, nil
+}
+
+// match reports whether the slash separated path name matches.
+func (p pathPattern) match(name string) bool {
+	return p.re.MatchString(path.Clean(name))
+}
+
+// folded returns p matching without regard to case, for
+// Options.IgnorePathCase.
+func (p pathPattern) folded() pathPattern {
+	p.re = foldRegexp(p.re)
+	return p
+}
+
+// foldRegexp returns re matching without regard to case.   re already
+// compiled, so it compiles again with the flag set.
+func foldRegexp(re *regexp.Regexp) *regexp.Regexp {
+	return regexp.MustCompile("(?i)" + re.String())
+}
+
+// foldPatterns makes every path pattern of r match without regard to case,
+// for Options.IgnorePathCase.
+func (r *Runner) foldPatterns() {
+	for i := range r.includes {
+		r.includes[i] = r.includes[i].folded()
+	}
+	for i := range r.excludes {
+		r.excludes[i] = r.excludes[i].folded()
+	}
+	foldRules(r.rules)
+	for i := range r.checks {
+		r.checks[i].pattern = r.checks[i].pattern.folded()
+	}
+	for i := range r.ignoreErrors {
+		r.ignoreErrors[i].pattern = r.ignoreErrors[i].pattern.folded()
+	}
+	for i := range r.filenameRules {
+		r.filenameRules[i].dir = r.filenameRules[i].dir.folded()
+		r.filenameRules[i].name = foldRegexp(r.filenameRules[i].name)
+	}
+}
+
+// excludedDir reports whether a directory called name is one of the
+// ExcludeDirs, whatever its case with Options.IgnorePathCase.
+func (r *Runner) excludedDir(name string) bool {
+	if !r.opts.IgnorePathCase {
+		return contains(r.opts.ExcludeDirs, name)
+	}
+	for _, dir := range r.opts.ExcludeDirs {
+		if strings.EqualFold(dir, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidatePatterns returns an error naming the first of globs that cannot be
+// compiled as a pattern for Options.MatchPatterns, ExcludePatterns or Rules.
+func ValidatePatterns(globs []string) error {
+	for _, glob := range globs {
+		if _, err := compilePattern(glob); err != nil {
+			return fmt.Errorf("invalid pattern %q: %v", glob, err)
+		}
+	}
+	return nil
+}
+
+// ValidateRegexps returns an error naming the first of exprs that cannot be
+// compiled for Options.MatchRegex or ExcludeRegex.
+func ValidateRegexps(exprs []string) error {
+	for _, expr := range exprs {
+		if _, err := compileRegexp(expr); err != nil {
+			return fmt.Errorf("invalid regex %q: %v", expr, err)
+		}
+	}
+	return nil
+}
+
+// compilePatterns compiles globs and then regular expressions exprs, skipping
+// any that fail (see ValidatePatterns and ValidateRegexps).
+func compilePatterns(globs, exprs []string) []pathPattern {
+	patterns := make([]pathPattern, 0, len(globs)+len(exprs))
+	for _, glob := range globs {
+		if pattern, err := compilePattern(glob); err == nil {
+			patterns = append(patterns, pattern)
+		}
+	}
+	for _, expr := range exprs {
+		if pattern, err := compileRegexp(expr); err == nil {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// matching returns the first of the MatchPatterns or MatchRegex matching the
+// slash separated path rel, relative to the root, as recorded in Result.Match.
+func (r *Runner) matching(rel string) (string, bool) {
+	rel = r.opts.canonicalName(rel)
+	for _, pattern := range r.includes {
+		if pattern.match(rel) {
+			return pattern.source, true
+		}
+	}
+	return "", false
+}
+
+// excluded reports whether the slash separated path rel, relative to the root,
+// matches any of the ExcludePatterns or ExcludeRegex.
+func (r *Runner) excluded(rel string) bool {
+	rel = r.opts.canonicalName(rel)
+	for _, pattern := range r.excludes {
+		if pattern.match(rel) {
+			return true
+		}
+	}
+	return false
+}
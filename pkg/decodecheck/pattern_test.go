@@ -0,0 +1,73 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestIgnorePathCase(t *testing.T) {
+	fsys := fstest.MapFS{
+		"Envs/Prod/Main.JSON":  {Data: []byte(`{"a": 1}`)},
+		"Envs/Dev/values.Yaml": {Data: []byte("a: 1\n")},
+		"Scripts/run.json":     {Data: []byte(`{"run": true}`)},
+	}
+	tests := []struct {
+		name           string
+		ignorePathCase bool
+		matchPatterns  []string
+		excludeDirs    []string
+		excludePattern []string
+		want           []string
+	}{
+		{
+			name:          "default matches directory case",
+			matchPatterns: []string{"envs/**/*.json", "*.yaml"},
+			want:          fromSlash("Envs/Dev/values.Yaml"),
+		},
+		{
+			name:           "folded match patterns",
+			ignorePathCase: true,
+			matchPatterns:  []string{"envs/**/*.json", "*.yaml"},
+			want:           fromSlash("Envs/Dev/values.Yaml", "Envs/Prod/Main.JSON"),
+		},
+		{
+			name:           "folded exclude dirs",
+			ignorePathCase: true,
+			matchPatterns:  []string{"*.json"},
+			excludeDirs:    []string{"scripts", "prod"},
+			want:           nil,
+		},
+		{
+			name:          "exclude dirs match case",
+			matchPatterns: []string{"*.json", "*.JSON"},
+			excludeDirs:   []string{"scripts", "prod"},
+			want:          fromSlash("Envs/Prod/Main.JSON", "Scripts/run.json"),
+		},
+		{
+			name:           "folded exclude patterns",
+			ignorePathCase: true,
+			matchPatterns:  []string{"*.json", "*.yaml"},
+			excludePattern: []string{"ENVS/DEV/**"},
+			want:           fromSlash("Envs/Prod/Main.JSON", "Scripts/run.json"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.FS = fsys
+			opts.IgnorePathCase = tt.ignorePathCase
+			opts.MatchPatterns = tt.matchPatterns
+			opts.ExcludeDirs = tt.excludeDirs
+			opts.ExcludePatterns = tt.excludePattern
+			report := NewRunner(opts).Run(context.Background())
+			if got := resultPaths(report); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("paths = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
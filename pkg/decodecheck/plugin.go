@@ -0,0 +1,123 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// Plugin is an external decoder, a program that checks formats decodeTest
+// does not know.   It is run once per file with the file's content on stdin,
+// and writes a PluginOutput as JSON to stdout.
+type Plugin struct {
+	// Name is the decoder name, used in decoder rules and summary counts.
+	Name string
+	// Command is the program and its arguments.
+	Command []string
+}
+
+// PluginOutput is what a Plugin writes to stdout.   The file passes when
+// Errors is empty, and Value, if given, is what it decoded to, for the checks
+// run on decoded values.   A plugin exiting non-zero without writing any
+// output fails the file with its stderr.
+type PluginOutput struct {
+	Errors []PluginError   `json:"errors"`
+	Value  json.RawMessage `json:"value,omitempty"`
+}
+
+// PluginError is one problem a Plugin found, with the 1 based position it is
+// at when it has one.
+type PluginError struct {
+	Message string `json:"message"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+}
+
+// RegisterPlugins adds a decoder running each of plugins to decoders, which
+// may not already have one of their names.
+func RegisterPlugins(decoders map[string]function.Function, plugins []Plugin) error {
+	for _, plugin := range plugins {
+		if _, ok := decoders[plugin.Name]; ok {
+			return fmt.Errorf("plugin %s has the name of a decoder already registered", plugin.Name)
+		}
+		if len(plugin.Command) == 0 {
+			return fmt.Errorf("plugin %s has no command", plugin.Name)
+		}
+		decoders[plugin.Name] = PluginDecodeFunc(plugin.Command)
+	}
+	return nil
+}
+
+// PluginDecodeFunc returns a decoder running command as a Plugin.   Errors
+// with a position are reported in the "on line X, column Y" form the built in
+// decoders use, so they are located the same way.
+func PluginDecodeFunc(command []string) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "src",
+				Type: cty.String,
+			},
+		},
+		Type: function.StaticReturnType(cty.DynamicPseudoType),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			var stdout, stderr bytes.Buffer
+			cmd := exec.Command(command[0], command[1:]...)
+			cmd.Stdin = strings.NewReader(args[0].AsString())
+			cmd.Stdout, cmd.Stderr = &stdout, &stderr
+			runErr := cmd.Run()
+
+			var exitErr *exec.ExitError
+			if runErr != nil && !errors.As(runErr, &exitErr) {
+				return cty.NilVal, fmt.Errorf("cannot run plugin %s: %w", command[0], runErr)
+			}
+			if runErr != nil && len(bytes.TrimSpace(stdout.Bytes())) == 0 {
+				message := strings.TrimSpace(stderr.String())
+				if message == "" {
+					message = runErr.Error()
+				}
+				return cty.NilVal, fmt.Errorf("plugin %s failed: %s", command[0], message)
+			}
+
+			var output PluginOutput
+			if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+				return cty.NilVal, fmt.Errorf("plugin %s wrote invalid output: %w", command[0], err)
+			}
+			if len(output.Errors) > 0 {
+				messages := make([]string, len(output.Errors))
+				for i, pluginErr := range output.Errors {
+					messages[i] = pluginErr.String()
+				}
+				return cty.NilVal, errors.New(strings.Join(messages, "; "))
+			}
+			if len(output.Value) == 0 {
+				return cty.EmptyObjectVal, nil
+			}
+			valueType, err := ctyjson.ImpliedType(output.Value)
+			if err != nil {
+				return cty.NilVal, fmt.Errorf("plugin %s wrote an invalid value: %w", command[0], err)
+			}
+			return ctyjson.Unmarshal(output.Value, valueType)
+		},
+	})
+}
+
+func (e PluginError) String() string {
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		return fmt.Sprintf("on line %d, column %d: %s", e.Line, e.Column, e.Message)
+	case e.Line > 0:
+		return fmt.Sprintf("on line %d: %s", e.Line, e.Message)
+	}
+	return e.Message
+}
@@ -0,0 +1,208 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/rego"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// policyRules are the rules PolicyRule evaluates in each package, with the
+// severity their findings have unless Options.Severities says otherwise.
+var policyRules = []struct {
+	name     string
+	severity Severity
+}{
+	{"deny", SeverityError},
+	{"violation", SeverityError},
+	{"warn", SeverityWarning},
+}
+
+// policyQuery is a prepared query for one rule of one package.
+type policyQuery struct {
+	rule     string
+	severity Severity
+	query    rego.PreparedEvalQuery
+}
+
+// PolicyRule returns a CheckRule evaluating the Rego policies in the .rego
+// files below policyDir against the values of files matching pattern, with
+// each value as input.   As with conftest, the deny and violation rules of
+// every package are errors, in the policy/deny category, and its warn rules
+// are warnings, in policy/warn.   Rules give messages as strings or objects
+// with a msg.   Policies may be written in Rego v1 or the older v0 syntax.
+func PolicyRule(pattern, policyDir string) (CheckRule, error) {
+	modules := make(map[string]*ast.Module)
+	hash := sha256.New()
+	err := filepath.WalkDir(policyDir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(name) != ".rego" {
+			return err
+		}
+		src, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		module, err := ast.ParseModuleWithOpts(name, string(src), ast.ParserOptions{RegoVersion: ast.RegoV1})
+		if err != nil {
+			var v0Err error
+			if module, v0Err = ast.ParseModuleWithOpts(name, string(src), ast.ParserOptions{RegoVersion: ast.RegoV0}); v0Err != nil {
+				return err
+			}
+		}
+		modules[name] = module
+		fmt.Fprintf(hash, "%s\x00%d\x00", name, len(src))
+		hash.Write(src)
+		return nil
+	})
+	if err != nil {
+		return CheckRule{}, err
+	}
+	if len(modules) == 0 {
+		return CheckRule{}, fmt.Errorf("no .rego files in %s", policyDir)
+	}
+
+	compiler := ast.NewCompiler()
+	if compiler.Compile(modules); compiler.Failed() {
+		return CheckRule{}, compiler.Errors
+	}
+
+	// Prepare A Query For Each Rule Named Like A Finding In Each Package
+	var queries []policyQuery
+	seen := make(map[string]bool)
+	for _, module := range modules {
+		for _, rule := range policyRules {
+			ref := module.Package.Path.String() + "." + rule.name
+			if seen[ref] || !definesRule(module, rule.name) {
+				continue
+			}
+			seen[ref] = true
+			query, err := rego.New(rego.Compiler(compiler), rego.Query(ref)).PrepareForEval(context.Background())
+			if err != nil {
+				return CheckRule{}, err
+			}
+			queries = append(queries, policyQuery{rule: ref, severity: rule.severity, query: query})
+		}
+	}
+	if len(queries) == 0 {
+		return CheckRule{}, fmt.Errorf("no deny, violation or warn rules in %s", policyDir)
+	}
+	sort.Slice(queries, func(i, j int) bool {
+		return queries[i].rule < queries[j].rule
+	})
+
+	return CheckRule{
+		Pattern:  pattern,
+		Category: "policy",
+		Name:     "policy=" + policyDir + "@" + hex.EncodeToString(hash.Sum(nil)[:8]),
+		Check: func(path string, value cty.Value) error {
+			buf, err := ctyjson.Marshal(value, value.Type())
+			if err != nil {
+				return err
+			}
+			input, err := ast.ValueFromReader(bytes.NewReader(buf))
+			if err != nil {
+				return err
+			}
+
+			var findings policyFindings
+			for _, query := range queries {
+				results, err := query.query.Eval(context.Background(), rego.EvalParsedInput(input))
+				if err != nil {
+					return fmt.Errorf("policy %s: %v", query.rule, err)
+				}
+				for _, result := range results {
+					for _, expression := range result.Expressions {
+						messages, _ := expression.Value.([]any)
+						for _, message := range messages {
+							findings = append(findings, policyFinding{rule: query.rule, severity: query.severity, message: policyMessage(message)})
+						}
+					}
+				}
+			}
+			if len(findings) == 0 {
+				return nil
+			}
+			return findings
+		},
+	}, nil
+}
+
+// definesRule reports whether module has a rule called name.
+func definesRule(module *ast.Module, name string) bool {
+	for _, rule := range module.Rules {
+		if rule.Head.Ref().String() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// policyMessage is the text of a message from a policy rule, the msg of an
+// object or else the value itself.
+func policyMessage(message any) string {
+	if object, ok := message.(map[string]any); ok {
+		if msg, ok := object["msg"].(string); ok {
+			return msg
+		}
+	}
+	if text, ok := message.(string); ok {
+		return text
+	}
+	return fmt.Sprint(message)
+}
+
+// policyFinding is one message from a deny, violation or warn rule.
+type policyFinding struct {
+	rule     string
+	severity Severity
+	message  string
+}
+
+// policyFindings is the error listing every message of the policies' rules on
+// its own line.   Denials are categorized as policy/deny and warnings as
+// policy/warn, so either can be given the other's severity.
+type policyFindings []policyFinding
+
+func (f policyFindings) Error() string {
+	lines := make([]string, len(f))
+	for i, finding := range f {
+		lines[i] = "  " + finding.rule + ": " + finding.message
+	}
+	return "policy findings:\n" + strings.Join(lines, "\n")
+}
+
+func (f policyFindings) split(severity func(category string, fallback Severity) Severity) (errs, warnings error) {
+	var errFindings, warnFindings policyFindings
+	for _, finding := range f {
+		category := "policy/deny"
+		if finding.severity == SeverityWarning {
+			category = "policy/warn"
+		}
+		if severity(category, finding.severity) == SeverityWarning {
+			warnFindings = append(warnFindings, finding)
+		} else {
+			errFindings = append(errFindings, finding)
+		}
+	}
+	if len(errFindings) > 0 {
+		errs = errFindings
+	}
+	if len(warnFindings) > 0 {
+		warnings = warnFindings
+	}
+	return errs, warnings
+}
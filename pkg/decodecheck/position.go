@@ -0,0 +1,137 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+
+	"github.com/BurntSushi/toml"
+	"github.com/tailscale/hujson"
+)
+
+// linePattern finds the "line X, column Y" wording the yaml, hcl, xml, ini and
+// csv decoders use, with the column optional for line based formats.
+var linePattern = regexp.MustCompile(`line (\d+)(?:, column (\d+))?`)
+
+// cuePositionPattern finds the indented file:line:column lines CUE errors end
+// with.
+var cuePositionPattern = regexp.MustCompile(`(?m)^\s+\S+:(\d+):(\d+)$`)
+
+// leadingPositionPattern finds the "on line X, column Y: " a decoder starts
+// its message with, after any prefix naming the check, like "yaml strict: ".
+var leadingPositionPattern = regexp.MustCompile(`^([^\n]*?: )??(?:on )?line (\d+)(?:, column (\d+))?: `)
+
+// positionedError is a decode error with the position at the start of its
+// message removed, shown with the path instead once the Result holds it.
+type positionedError struct {
+	msg string
+	err error
+}
+
+func (e *positionedError) Error() string { return e.msg }
+
+func (e *positionedError) Unwrap() error { return e.err }
+
+// withoutPosition returns err without the position at the start of its
+// message when that is line and column, so it is not shown twice, as in
+// a/bad.yaml:1:1: on line 1, column 1: did not find expected ','.
+func withoutPosition(err error, line, column int) error {
+	if err == nil || line == 0 {
+		return err
+	}
+	msg := err.Error()
+	match := leadingPositionPattern.FindStringSubmatchIndex(msg)
+	if match == nil {
+		return err
+	}
+	matchLine, _ := strconv.Atoi(msg[match[4]:match[5]])
+	matchColumn := 1
+	if match[6] >= 0 {
+		matchColumn, _ = strconv.Atoi(msg[match[6]:match[7]])
+	}
+	if matchLine != line || matchColumn != column {
+		return err
+	}
+	prefix := ""
+	if match[2] >= 0 {
+		prefix = msg[match[2]:match[3]]
+	}
+	return &positionedError{msg: prefix + msg[match[1]:], err: err}
+}
+
+// errorPosition returns the 1 based line and column a decode error of src
+// points at, or false if it has none.   Column is 1 when only the line is known.
+func errorPosition(err error, src []byte) (line, column int, ok bool) {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return offsetPosition(src, syntaxErr.Offset-1)
+	}
+	var tomlErr toml.ParseError
+	if errors.As(err, &tomlErr) {
+		return tomlErr.Position.Line, tomlErr.Position.Col, true
+	}
+
+	match := linePattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		match = cuePositionPattern.FindStringSubmatch(err.Error())
+	}
+	if match == nil {
+		return 0, 0, false
+	}
+	line, _ = strconv.Atoi(match[1])
+	column = 1
+	if match[2] != "" {
+		column, _ = strconv.Atoi(match[2])
+	}
+	return line, column, true
+}
+
+// failurePosition returns the position of err, from decoding src with
+// decoderName, like errorPosition.   JSON decoders report syntax errors without
+// one, so for those src is parsed again with encoding/json to find it.
+func failurePosition(decoderName string, err error, src []byte) (line, column int, ok bool) {
+	if line, column, ok := errorPosition(err, src); ok {
+		return line, column, true
+	}
+	if duplicateKeyDecoders[decoderName] != "json" {
+		return 0, 0, false
+	}
+	if standard, err := hujson.Standardize(bytes.Clone(src)); err == nil && decoderName != "json" {
+		src = standard
+	}
+	dec := json.NewDecoder(bytes.NewReader(src))
+	for {
+		var value any
+		err := dec.Decode(&value)
+		if err == io.EOF {
+			return 0, 0, false
+		}
+		if err != nil {
+			var syntaxErr *json.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				return offsetPosition(src, syntaxErr.Offset-1)
+			}
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				return offsetPosition(src, int64(len(src)))
+			}
+			return 0, 0, false
+		}
+	}
+}
+
+// offsetPosition converts a byte offset in src to a 1 based line and column.
+func offsetPosition(src []byte, offset int64) (line, column int, ok bool) {
+	if offset < 0 || offset > int64(len(src)) {
+		return 0, 0, false
+	}
+	before := src[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	column = len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column, true
+}
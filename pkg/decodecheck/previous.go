@@ -0,0 +1,103 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+)
+
+// PreviousReport is the failures of a JSON report from an earlier run, by
+// file path, for telling which of the current failures are new.
+type PreviousReport struct {
+	failures map[string]string // path to error
+}
+
+// previousResult is the part of a result in a JSON report a PreviousReport
+// reads.
+type previousResult struct {
+	Path      string `json:"path"`
+	Status    Status `json:"status"`
+	Error     string `json:"error"`
+	Severity  string `json:"severity"`
+	Baselined bool   `json:"baselined"`
+}
+
+// LoadPreviousReport reads the JSON report at path, as printed by -output json.
+func LoadPreviousReport(path string) (*PreviousReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file map[string]json.RawMessage
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if _, ok := file["results"]; !ok {
+		return nil, errors.New("not a JSON report, it has no results")
+	}
+	var results []previousResult
+	if err := json.Unmarshal(file["results"], &results); err != nil {
+		return nil, err
+	}
+	previous := &PreviousReport{failures: make(map[string]string)}
+	for _, result := range results {
+		failed := Result{Status: result.Status, Baselined: result.Baselined}
+		if result.Severity == SeverityWarning.String() {
+			failed.Severity = SeverityWarning
+		}
+		if failed.Failed() {
+			previous.failures[result.Path] = result.Error
+		}
+	}
+	return previous, nil
+}
+
+// ReportComparison is how the results of a run differ from a PreviousReport,
+// file by file.   A file failing in both runs is StillFailing even when its
+// error changed.
+type ReportComparison struct {
+	// NewFailures failed in this run and not in the previous one, including
+	// files it did not check.
+	NewFailures []Result `json:"newFailures"`
+	// Fixed failed in the previous run and pass in this one.
+	Fixed []string `json:"fixed"`
+	// StillFailing failed in both runs.
+	StillFailing []Result `json:"stillFailing"`
+	// Unchecked failed in the previous run and were not checked in this one,
+	// like files since deleted.
+	Unchecked []string `json:"unchecked"`
+	// Passing passed in both runs, or are new and pass.
+	Passing int `json:"passing"`
+}
+
+// Compare classifies results against the previous report.
+func (p *PreviousReport) Compare(results []Result) ReportComparison {
+	comparison := ReportComparison{NewFailures: []Result{}, Fixed: []string{}, StillFailing: []Result{}, Unchecked: []string{}}
+	seen := make(map[string]bool, len(results))
+	for _, result := range results {
+		seen[result.Path] = true
+		_, failedBefore := p.failures[result.Path]
+		switch {
+		case result.Failed() && failedBefore:
+			comparison.StillFailing = append(comparison.StillFailing, result)
+		case result.Failed():
+			comparison.NewFailures = append(comparison.NewFailures, result)
+		case failedBefore:
+			comparison.Fixed = append(comparison.Fixed, result.Path)
+		default:
+			comparison.Passing++
+		}
+	}
+	for path := range p.failures {
+		if !seen[path] {
+			comparison.Unchecked = append(comparison.Unchecked, path)
+		}
+	}
+	sort.Strings(comparison.Fixed)
+	sort.Strings(comparison.Unchecked)
+	return comparison
+}
@@ -0,0 +1,110 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// gitHosts are the hosts whose https://host/owner/repo URLs are repositories
+// to clone rather than pages to download.
+var gitHosts = []string{"github.com", "gitlab.com", "bitbucket.org"}
+
+// IsRemote reports whether root is a URL to fetch with FetchRemote rather than a
+// local path: an http:// or https:// link to a file, or a git repository, like
+// git@github.com:org/repo.git, ssh://host/repo, git://host/repo,
+// https://host/repo.git or https://github.com/org/repo.
+func IsRemote(root string) bool {
+	return isGitURL(root) || strings.HasPrefix(root, "https://") || strings.HasPrefix(root, "http://")
+}
+
+// isGitURL reports whether root names a git repository to clone.   A #ref on
+// the end is the branch or tag to check out.
+func isGitURL(root string) bool {
+	root, _, _ = strings.Cut(root, "#")
+	if strings.HasPrefix(root, "git://") || strings.HasPrefix(root, "ssh://") || strings.HasPrefix(root, "git@") {
+		return true
+	}
+	u, err := url.Parse(root)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return false
+	}
+	if strings.HasSuffix(u.Path, ".git") {
+		return true
+	}
+	return contains(gitHosts, u.Host) && strings.Count(strings.Trim(u.Path, "/"), "/") == 1
+}
+
+// FetchRemote fetches the remote root into dir, cloning a git repository at
+// its latest commit or downloading a file under its own name, so its decoder
+// rule still matches, and returns the local path to search in its place.
+func FetchRemote(ctx context.Context, root, dir string) (string, error) {
+	if isGitURL(root) {
+		return cloneRepo(ctx, root, dir)
+	}
+	return download(ctx, root, dir)
+}
+
+// cloneRepo makes a shallow clone of the repository at repo, at the branch or
+// tag after a #, in a directory named for it under dir.
+func cloneRepo(ctx context.Context, repo, dir string) (string, error) {
+	repo, ref, _ := strings.Cut(repo, "#")
+	name := strings.TrimSuffix(path.Base(strings.TrimRight(strings.ReplaceAll(repo, ":", "/"), "/")), ".git")
+	if name == "" || name == "." || name == "/" {
+		name = "repo"
+	}
+	target := filepath.Join(dir, name)
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	if _, err := git(ctx, dir, nil, append(args, "--", repo, target)...); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// download saves the file at link under dir, with the last element of its path
+// as its name.
+func download(ctx context.Context, link, dir string) (string, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", err
+	}
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		return "", fmt.Errorf("%s does not name a file", link)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: %s", link, resp.Status)
+	}
+
+	target := filepath.Join(dir, name)
+	file, err := os.Create(target)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		file.Close()
+		return "", fmt.Errorf("%s: %v", link, err)
+	}
+	return target, file.Close()
+}
@@ -0,0 +1,56 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+// Reporter builds a Report from what a run finds, the one place its counts
+// are kept: the SafeCounter totals, bytes included, and per directory counts
+// are derived from the Results handed to Add, so they always agree with
+// Report.Results, even for a run cancelled part way.   A Reporter is not safe
+// for concurrent use, Run feeds it from one goroutine.
+type Reporter struct {
+	report      *Report
+	directories directoryCounter
+}
+
+// NewReporter returns a Reporter with an empty Report.
+func NewReporter() *Reporter {
+	return &Reporter{
+		report:      &Report{Counts: NewSafeCounter()},
+		directories: directoryCounter{},
+	}
+}
+
+// Add adds result to the report and counts it and its bytes under its type,
+// and under its directory with Options.SummaryDepth.
+func (rp *Reporter) Add(result Result) {
+	counts := rp.report.Counts
+	counts.AddBytes(result.Size)
+	counts.AddFile(result.Type)
+	if result.Failed() {
+		counts.AddError(result.Type)
+	} else if result.Status == NonStrict {
+		counts.AddNonStrict(result.Type)
+	}
+	if len(result.Warnings) > 0 {
+		counts.AddWarning(result.Type)
+	}
+	if result.summaryDir != "" {
+		rp.directories.add(result)
+	}
+	rp.report.Results = append(rp.report.Results, result)
+}
+
+// AddWalkError adds err, met walking rather than checking a file, to the
+// report's WalkErrors.
+func (rp *Reporter) AddWalkError(err error) {
+	rp.report.WalkErrors = append(rp.report.WalkErrors, err)
+}
+
+// Report returns the report so far, its Directories as of the last Add.
+func (rp *Reporter) Report() *Report {
+	if len(rp.directories) > 0 {
+		rp.report.Directories = rp.directories.sorted()
+	}
+	return rp.report
+}
@@ -0,0 +1,196 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Status is the outcome of checking a single file.
+type Status int
+
+const (
+	// Passed files decoded with strict syntax.
+	Passed Status = iota
+	// NonStrict files only decoded with relaxed syntax (see Options.RelaxedJSON).
+	NonStrict
+	// NoDecoder files matched a match pattern but no decoder rule.
+	NoDecoder
+	// ReadFailed files could not be read.
+	ReadFailed
+	// DecodeFailed files were read but failed to decode.
+	DecodeFailed
+	// TooLarge files were over Options.MaxSize and reported without being read.
+	TooLarge
+	// Invalid files decoded but their value failed one of Options.Checks.
+	Invalid
+	// Listed files were found with Options.ListOnly and neither read nor
+	// decoded.
+	Listed
+)
+
+func (s Status) String() string {
+	switch s {
+	case Passed:
+		return "passed"
+	case NonStrict:
+		return "non-strict"
+	case NoDecoder:
+		return "no-decoder"
+	case ReadFailed:
+		return "read-failed"
+	case DecodeFailed:
+		return "decode-failed"
+	case TooLarge:
+		return "too-large"
+	case Invalid:
+		return "invalid"
+	case Listed:
+		return "listed"
+	}
+	return "unknown"
+}
+
+// MarshalText encodes s as its String form, so JSON reports are readable.
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes the String form of a Status.
+func (s *Status) UnmarshalText(text []byte) error {
+	for status := Passed; status <= Listed; status++ {
+		if status.String() == string(text) {
+			*s = status
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown status %q", text)
+}
+
+// Result is the outcome of checking one file.
+type Result struct {
+	// Path is the file path, joined onto the root it was found under.
+	Path string
+	// Size is the file size in bytes.
+	Size int64
+	// Type is the name of the decoder used, or the file extension when no
+	// decoder rule matched.   Summary counts are grouped by it.
+	Type string
+	// Status is the outcome of the check.
+	Status Status
+	// Err holds the read or decode error for failed files.
+	Err error
+	// Line and Column are the 1 based position in the file a decode error
+	// points at, or 0 when it has none.
+	Line, Column int
+	// TypeSignature is the type of the value the file decoded to, like
+	// object({regions=tuple([string]),tags=object({env=string})}), with
+	// Options.TypeSignatures.
+	TypeSignature string
+	// Shape is the size of the value the file decoded to, with Options.Shapes.
+	Shape *Shape
+	// Terraform summarizes terraform state and plan files, decoded by the
+	// tfstate and tfplan-json decoders.
+	Terraform *TerraformSummary
+	// ValueHash is the ValueHash of the value the file decoded to, with
+	// Options.ValueHashes.
+	ValueHash string
+	// UniqueValues holds the value at each of Options.Unique the file has,
+	// normalized to JSON, by path.
+	UniqueValues map[string]string
+	// QueryResult is the value at Options.Query in the value the file decoded
+	// to, normalized to JSON, or empty when it has nothing there.
+	QueryResult string
+	// Converted is the value the file decoded to in the format of
+	// Options.Convert, when it decoded without errors.
+	Converted []byte
+	// SyntaxOnly is set when the file was over Options.SyntaxOnlyAbove, so only
+	// its syntax was checked.
+	SyntaxOnly bool
+	// FormatDiff is a unified diff from the file to its canonical format, with
+	// Options.CheckFormat, or empty when it is already canonical.
+	FormatDiff string
+	// Warnings holds findings that do not fail the file, like those of
+	// Options.LintYAML or the checks Options.Severities makes warnings.
+	Warnings []string
+	// Severity is SeverityWarning when Options.Severities made the failure in
+	// Status a warning, like no-decoder, so the file does not count as failed.
+	Severity Severity
+	// Baselined is set when the failure is accepted by Options.Baseline, so
+	// the file does not count as failed.
+	Baselined bool
+	// Match is the match pattern that selected the file, regular expressions
+	// have regex: in front.   It is empty for files not found by a walk, like
+	// validated payloads.
+	Match string
+	// Rule is the pattern of the decoder rule that picked Type, with
+	// Options.ListOnly.
+	Rule string
+	// Aliases are the other paths the file was found at through hard links or
+	// symlinks, with Options.DedupeLinks, which were not checked again.
+	Aliases []string
+	// Cached is set when the result was reused from Options.Cache rather than
+	// decoded this run.
+	Cached bool
+	// Duration is how long the file took to decode and check, 0 when it was
+	// not decoded this run.
+	Duration time.Duration
+
+	summaryDir string // directory counted under, with Options.SummaryDepth
+}
+
+// Failed reports whether the file counts as a decode error.
+func (r Result) Failed() bool {
+	return r.Status != Passed && r.Status != NonStrict && r.Status != Listed && r.Severity != SeverityWarning && !r.Baselined
+}
+
+// Location is Path with the position of the error, like envs/a.yaml:3:7, or
+// just Path when the error has no position.
+func (r Result) Location() string {
+	if r.Line == 0 {
+		return r.Path
+	}
+	return fmt.Sprintf("%s:%d:%d", r.Path, r.Line, r.Column)
+}
+
+// MarshalJSON encodes the result with lower case keys and Err as its message.
+func (r Result) MarshalJSON() ([]byte, error) {
+	out := struct {
+		Path      string            `json:"path"`
+		Size      int64             `json:"size"`
+		Type      string            `json:"type"`
+		Status    Status            `json:"status"`
+		Error     string            `json:"error,omitempty"`
+		Line      int               `json:"line,omitempty"`
+		Column    int               `json:"column,omitempty"`
+		CtyType   string            `json:"ctyType,omitempty"`
+		Shape     *Shape            `json:"shape,omitempty"`
+		Terraform *TerraformSummary `json:"terraform,omitempty"`
+		ValueHash string            `json:"valueHash,omitempty"`
+		Unique    map[string]string `json:"uniqueValues,omitempty"`
+		Query     json.RawMessage   `json:"query,omitempty"`
+		Syntax    bool              `json:"syntaxOnly,omitempty"`
+		Diff      string            `json:"formatDiff,omitempty"`
+		Warnings  []string          `json:"warnings,omitempty"`
+		Severity  string            `json:"severity,omitempty"`
+		Baselined bool              `json:"baselined,omitempty"`
+		Match     string            `json:"match,omitempty"`
+		Rule      string            `json:"rule,omitempty"`
+		Aliases   []string          `json:"aliases,omitempty"`
+		Cached    bool              `json:"cached,omitempty"`
+		Duration  float64           `json:"durationMs,omitempty"`
+	}{Path: r.Path, Size: r.Size, Type: r.Type, Status: r.Status, Line: r.Line, Column: r.Column, CtyType: r.TypeSignature, Shape: r.Shape, Terraform: r.Terraform, ValueHash: r.ValueHash, Unique: r.UniqueValues, Syntax: r.SyntaxOnly, Diff: r.FormatDiff, Warnings: r.Warnings, Baselined: r.Baselined, Match: r.Match, Rule: r.Rule, Aliases: r.Aliases, Cached: r.Cached, Duration: milliseconds(r.Duration)}
+	if r.Severity == SeverityWarning {
+		out.Severity = r.Severity.String()
+	}
+	if r.Err != nil {
+		out.Error = r.Err.Error()
+	}
+	if r.QueryResult != "" {
+		out.Query = json.RawMessage(r.QueryResult)
+	}
+	return json.Marshal(out)
+}
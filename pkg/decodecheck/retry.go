@@ -0,0 +1,91 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"syscall"
+	"time"
+)
+
+// transientErrors are the errors network filesystems like NFS and EFS return
+// sporadically, which usually succeed when tried again.
+var transientErrors = []error{syscall.EIO, syscall.ESTALE, syscall.EAGAIN, syscall.EINTR, syscall.ETIMEDOUT}
+
+// isTransient reports whether err is worth retrying.
+func isTransient(err error) bool {
+	for _, transient := range transientErrors {
+		if errors.Is(err, transient) {
+			return true
+		}
+	}
+	return false
+}
+
+// withRetries runs op on name in root, running it again while it fails with a
+// transient error, up to Options.Retries times, waiting Options.RetryBackoff
+// and twice as long before each retry after that.   It returns the last error,
+// noting the retries made when there were any, or ctx.Err() when cancelled
+// while waiting.
+func (r *Runner) withRetries(ctx context.Context, root searchRoot, name string, op func() error) error {
+	err := op()
+	backoff := r.opts.RetryBackoff
+	retries := 0
+	for err != nil && isTransient(err) && retries < r.opts.Retries {
+		retries++
+		if r.opts.OnRetry != nil {
+			r.opts.OnRetry(root.display(name), retries, displayError(root, err))
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+		err = op()
+	}
+
+	// Note The Retries Inside Path Errors, So displayError Keeps Them
+	if err != nil && retries > 0 {
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			return &fs.PathError{Op: pathErr.Op, Path: pathErr.Path, Err: fmt.Errorf("%w (after %d retries)", pathErr.Err, retries)}
+		}
+		return fmt.Errorf("%w (after %d retries)", err, retries)
+	}
+	return err
+}
+
+// readDir is fs.ReadDir with Options.Retries.
+func (r *Runner) readDir(ctx context.Context, root searchRoot, dir string) ([]fs.DirEntry, error) {
+	var entries []fs.DirEntry
+	err := r.withRetries(ctx, root, dir, func() (err error) {
+		entries, err = fs.ReadDir(root.fsys, dir)
+		return err
+	})
+	return entries, err
+}
+
+// readFile is fs.ReadFile with Options.Retries.
+func (r *Runner) readFile(ctx context.Context, root searchRoot, name string) ([]byte, error) {
+	var content []byte
+	err := r.withRetries(ctx, root, name, func() (err error) {
+		content, err = fs.ReadFile(root.fsys, name)
+		return err
+	})
+	return content, err
+}
+
+// stat is fs.Stat with Options.Retries.
+func (r *Runner) stat(ctx context.Context, root searchRoot, name string) (fs.FileInfo, error) {
+	var info fs.FileInfo
+	err := r.withRetries(ctx, root, name, func() (err error) {
+		info, err = fs.Stat(root.fsys, name)
+		return err
+	})
+	return info, err
+}
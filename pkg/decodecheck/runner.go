@@ -0,0 +1,1694 @@
+// Copyright © 2016 Alan A. A. Donovan & Brian W. Kernighan.
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+// Package decodecheck walks directory trees looking for configuration files and
+// attempts to decode them with the go-cty library, the same library and functions
+// terraform itself uses for the jsondecode and yamldecode functions.
+//
+// The walker is based on the du4 program on page 251 of the Go Programming
+// Language book, and still counts the files and disk usage as it goes.
+//
+// The decodeTest command is a thin CLI over this package, it can also be embedded
+// in other tools (like a terragrunt wrapper) by building Options and calling
+// Runner.Run.
+package decodecheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Options configures a Runner.
+type Options struct {
+	// Roots are the directories to search recursively.   A root that is a file
+	// is checked on its own, whatever the patterns and filters below, with
+	// its decoder picked by the Rules.   On Windows long path (\\?\) prefixes
+	// are removed and drive relative roots like C: made absolute.
+	Roots []string
+	// FS, if set, is searched instead of the OS filesystem and Roots are
+	// slash separated paths within it ("." for the whole FS).   This allows
+	// checking embedded or in-memory filesystems such as fstest.MapFS.
+	FS fs.FS
+	// Files, if not nil, are checked instead of walking Roots (so an empty
+	// list checks nothing).   They are slash
+	// separated paths in FS, or OS paths when FS is nil, and are filtered by
+	// MatchPatterns and ExcludeDirs the same as walked files.
+	Files []string
+	// RequireFiles fails Files that do not exist with ReadFailed, rather than
+	// reporting them with the other paths the walk could not read, for runs
+	// checking a Manifest.
+	RequireFiles bool
+	// MatchPatterns are the glob patterns of files to decode.   A pattern
+	// without a / matches the file name at any depth, one with a / matches the
+	// path from the root, and ** matches any number of directories.
+	// Directories are still walked when no pattern could match below them.
+	MatchPatterns []string
+	// ExtensionAliases map file extensions to the extension they are matched
+	// as, like ".yml" to ".yaml", so patterns and rules only name one of them.
+	// Extensions are lower cased before the lookup.
+	ExtensionAliases map[string]string
+	// CaseSensitiveExtensions turns off lower casing file extensions before
+	// matching, so *.json no longer matches A.JSON.
+	CaseSensitiveExtensions bool
+	// IgnorePathCase matches MatchPatterns, ExcludePatterns, the regular
+	// expressions, Rules, Checks, ExcludeDirs, ignore files and the other path
+	// patterns without regard to case, as Windows and macOS filesystems do.
+	IgnorePathCase bool
+	// MatchRegex are regular expressions matched against the slash separated
+	// path from the root, files matching one are decoded as well.
+	MatchRegex []string
+	// ExcludePatterns skip files that match MatchPatterns.   A pattern without
+	// a / matches the file name at any depth, one with a / matches the path
+	// from the root, and ** matches any number of directories.
+	ExcludePatterns []string
+	// ExcludeRegex are regular expressions like MatchRegex, files matching one
+	// are skipped like ExcludePatterns.
+	ExcludeRegex []string
+	// ExcludeDirs are directory names that are never walked into.
+	ExcludeDirs []string
+	// FollowSymlinks walks into symlinked directories of OS roots.   Each
+	// directory is walked once by its resolved path, so links back up the
+	// tree or to a directory already searched are skipped.
+	FollowSymlinks bool
+	// DedupeLinks checks each file on the OS filesystem once, however many hard
+	// links or followed symlinks lead to it, listing the other paths in the
+	// Aliases of its result instead of counting them again.
+	DedupeLinks bool
+	// MaxDepth, if positive, limits how deep the walk goes: 1 only searches
+	// the files directly in each root, 2 their subdirectories too, and so on.
+	MaxDepth int
+	// MinSize skips files smaller than this many bytes.   Empty files are
+	// always skipped, unless CheckEmpty is set.
+	MinSize int64
+	// MaxSize, if positive, skips files larger than this many bytes so they
+	// are never read into memory, or reports them as TooLarge when
+	// ReportOversize is set.
+	MaxSize        int64
+	ReportOversize bool
+	// SyntaxOnlyAbove, if positive, checks json and yaml files larger than
+	// this many bytes by parsing them as they are read, a token or a document
+	// at a time, rather than reading them into memory and decoding them, so
+	// huge files fit on small machines.   Only their syntax is checked, the
+	// Checks and everything else done with decoded values are skipped, and
+	// they are marked Result.SyntaxOnly.
+	SyntaxOnlyAbove int64
+	// NewerThan, if not zero, skips files last modified at or before it.
+	// Files without a modification time, like staged files, are kept.
+	NewerThan time.Time
+	// Retries, if positive, retries listing directories and reading files
+	// that fail with the transient errors network filesystems like NFS and EFS
+	// return sporadically, EIO, ESTALE, EAGAIN, EINTR and ETIMEDOUT, this many
+	// times, waiting RetryBackoff before the first retry and twice as long
+	// before each one after it.   Errors left after the retries are reported as
+	// usual, noting the retries made.
+	Retries      int
+	RetryBackoff time.Duration
+	// SkipHidden skips files and directories whose names start with a dot,
+	// beyond those named in ExcludeDirs.
+	SkipHidden bool
+	// IgnoreFiles are names of gitignore style files (like ".gitignore") read
+	// in every directory walked, and in the directories above each root up to
+	// the top of its git work tree.   Files and directories they match are
+	// skipped.
+	IgnoreFiles []string
+	// Archives opens zip, tar and tar.gz files found by the walk, or given as
+	// Roots or Files, and checks the files in them matching the patterns and
+	// filters above as if the archive were a directory, with paths like
+	// bundle.zip!envs/prod.yaml.   Files in archives over MaxSize are never
+	// decompressed, and archives expanding to more than MaxTotalBytes, or 1 GiB
+	// when it is unset, fail.
+	Archives bool
+	// Decoders are the decoders available to Rules, by name.
+	Decoders map[string]function.Function
+	// Rules pick the decoder for each matched file, first match wins.
+	Rules []DecoderRule
+	// Checks run on the values files decode to, every rule whose pattern
+	// matches a file is run.   Files failing one are reported as Invalid.
+	Checks []CheckRule
+	// StreamDecoders name the decoders whose values are a tuple of separate
+	// documents, like ndjson, which Checks are run on one by one.
+	StreamDecoders []string
+	// Concurrency is the default for IOConcurrency and DecodeConcurrency when
+	// they are unset.
+	Concurrency int
+	// IOConcurrency limits how many directory listings and file reads run at
+	// once.   Zero or less uses Concurrency, then DefaultConcurrency.
+	IOConcurrency int
+	// DecodeConcurrency limits how many decoders run at once.   Zero or less
+	// uses Concurrency, then runtime.NumCPU since decoding is CPU bound.
+	DecodeConcurrency int
+	// Budget, if set, is shared with other Runners so their runs together stay
+	// within its limits, in place of the limits above.
+	Budget *Budget
+	// DecodeTimeout, if positive, fails files that take longer than this to
+	// decode with a timed out error, so one pathological file cannot hang the
+	// run.   Decoders cannot be interrupted, so one that times out keeps running
+	// in the background and its result is dropped.
+	DecodeTimeout time.Duration
+	// MaxErrors, if positive, stops the run once that many files have failed,
+	// cancelling the walk so huge broken trees do not run to completion.
+	MaxErrors int
+	// MaxFiles and MaxTotalBytes, if positive, abort the run once the walk has
+	// found more matching files or more bytes in them than this, so a run
+	// pointed at / or a tree of data files gives up instead of exhausting the
+	// machine.
+	MaxFiles      int
+	MaxTotalBytes int64
+	// ListOnly walks and filters as usual but neither reads nor decodes the
+	// files found, each Result having the Listed status, the decoder that
+	// would check it as its Type and the Rule that picked it.   Files without
+	// a decoder are still reported with NoDecoder.   Archives are opened to
+	// list the files in them.
+	ListOnly bool
+	// Severities overrides whether findings fail their file or are only
+	// reported as warnings, by category: no-decoder for files no decoder rule
+	// matches, yaml-lint for LintYAML findings and the Category of each check
+	// rule, like schema, whose violations are also categorized by keyword, like
+	// schema/additionalProperties.   Findings are errors by default, except
+	// yaml-lint.
+	Severities map[string]Severity
+	// TypeSignatures records the cty type of the value each file decodes to in
+	// Result.TypeSignature.
+	TypeSignatures bool
+	// ValueHashes records a hash of the value each file decodes to in
+	// Result.ValueHash, for Report.Identical.
+	ValueHashes bool
+	// Unique fails files with the same value at one of these paths as another
+	// file, like two environments with the same account_id, naming the file
+	// checked first.   Files without the path are not compared.
+	Unique []ValuePath
+	// CheckFormat fails json and yaml files that differ from their value
+	// converted back to their format by Convert, recording the difference in
+	// Result.FormatDiff, like terraform fmt -check.
+	CheckFormat bool
+	// Snapshots, if set, compares the value each file decodes to with its
+	// snapshot, failing files whose values changed since.   Results are not
+	// cached while comparing snapshots.
+	Snapshots *Snapshots
+	// SOPSDecrypt decrypts json, yaml and ini files encrypted by sops with the
+	// keys sops finds in the environment, checking their values rather than
+	// only reporting them.   Decrypted files are never converted, compared with
+	// snapshots or cached, so their secrets are not written anywhere.
+	SOPSDecrypt bool
+	// Convert, if set to one of ConvertFormats, records each file that decodes
+	// without errors converted to that format in Result.Converted.   Results are
+	// not cached while converting.
+	Convert string
+	// Query, if set, records the value at this path in the value each file
+	// decodes to in Result.QueryResult.
+	Query *ValuePath
+	// Shapes records the key count, leaf count and nesting depth of the value
+	// each file decodes to in Result.Shape.
+	Shapes bool
+	// ShapeLimits reports files whose values are over them, as warnings unless
+	// Severities makes the shape category an error.
+	ShapeLimits ShapeLimits
+	// SummaryDepth, if positive, breaks the counts down by directory in
+	// Report.Directories, each file counted under the directory this many
+	// levels below its root, so 1 gives a line per top level directory.
+	SummaryDepth int
+	// Baseline, if set, accepts the failures it lists, reporting them
+	// Baselined so only new failures fail the run.
+	Baseline *Baseline
+	// IgnoreErrors downgrades failures whose errors they match to warnings,
+	// for the files their patterns match.
+	IgnoreErrors []ErrorFilter
+	// FilenameRules fail files in the directories they match whose names do
+	// not match them.
+	FilenameRules []FilenameRule
+	// RelaxedJSON retries json files that fail strict decoding with
+	// JSONCDecodeFunc, reporting them NonStrict if that succeeds.   jsonc files
+	// are NonStrict when they use comments or trailing commas either way.
+	RelaxedJSON bool
+	// StrictKeys fails json and yaml files that repeat a key in the same object
+	// or mapping, which the decoders otherwise resolve by keeping the last value.
+	StrictKeys bool
+	// LintYAML warns about plain YAML scalars read as a different type than
+	// they most likely mean, like no as false or 1.10 as 1.1, in Result.Warnings.
+	LintYAML bool
+	// StrictYAML fails yaml files that the YAML 1.2 core schema would not read
+	// the way the YAML decoder does: tags outside the core schema, << merge
+	// keys, and plain values read with YAML 1.1 rules, like yes as true, or
+	// that YAML 1.1 parsers read as base 60 numbers, like 1:30.
+	StrictYAML bool
+	// LintText fails files with invalid UTF-8 or NUL bytes, and LintCRLF and
+	// LintFinalNewline warn about files with CRLF line endings or without a
+	// newline at the end, in the text category, like text/crlf.
+	LintText         bool
+	LintCRLF         bool
+	LintFinalNewline bool
+	// CheckEmpty fails files that are empty or decode to null or an empty
+	// object or list, which usually means a truncated upload, in the empty
+	// category, like empty/null.   Empty files are checked rather than skipped.
+	CheckEmpty bool
+	// TerragruntRefs checks that the files each terragrunt.hcl loads with
+	// yamldecode(file(...)), jsondecode(file(...)) and read_terragrunt_config
+	// exist and decode, in the terragrunt category.   Terragrunt configs are not
+	// cached while checking them, since the files they load may change.
+	TerragruntRefs bool
+	// MaxYAMLAliases and MaxYAMLNodes fail YAML files with more aliases, or
+	// that expand to more nodes once aliases are expanded, before decoding them,
+	// so billion laughs style documents cannot exhaust memory.   Zero or less
+	// disables a limit.
+	MaxYAMLAliases int
+	MaxYAMLNodes   int
+	// Cache, if set, is consulted before decoding each file and updated with
+	// the result.   Decoders that take the file path to read other files
+	// (jsonnet, cue) are never cached.
+	Cache *Cache
+	// CacheTag describes any decoder settings that change results without
+	// changing the decoder name (like a custom CSV delimiter), so results are
+	// only reused under the same settings.
+	CacheTag string
+	// Metrics, if set, records every file checked, and each scan of the
+	// Roots completed, for Prometheus.
+	Metrics *Metrics
+	// OnResult, if set, is called with each Result as it completes.   Calls are
+	// made from a single goroutine.
+	OnResult func(Result)
+	// OnProgress, if set, is called with how far the run has got each time a
+	// file is found or checked.   Calls are made from a single goroutine.
+	OnProgress func(Progress)
+	// OnSkip, if set, is called with the path of each file or directory left
+	// out of the run and the reason why, for verbose logging.   Files that no
+	// match pattern matches are not reported.   Calls may be concurrent.
+	OnSkip func(path, reason string)
+	// OnDir, if set, is called with the path of each directory walked and the
+	// number of entries in it, for progress logging.   Calls may be concurrent.
+	OnDir func(path string, entries int)
+	// OnDecoder, if set, is called with the path of each file checked, the
+	// decoder picked for it and the pattern of the decoder rule that picked it,
+	// for verbose logging.   Calls may be concurrent.
+	OnDecoder func(path, decoder, pattern string)
+	// OnRetry, if set, is called with the path of each file or directory that
+	// failed with a transient error, the number of the retry about to be made
+	// and the error, for logging.   Calls may be concurrent.
+	OnRetry func(path string, retry int, err error)
+	// OnWalkError, if set, is called with each error listing a directory or
+	// statting a file as Run meets it, from the goroutine calling Run, and
+	// with each error watching for changes, which are otherwise printed to
+	// stderr.   Walk errors are in Report.WalkErrors either way.
+	OnWalkError func(err error)
+}
+
+// DefaultOptions returns the options decodeTest uses when no flags are given.
+func DefaultOptions() Options {
+	return Options{
+		Roots:         []string{"."},
+		MatchPatterns: []string{"*.json", "*.yaml", "*.hcl", "*.tf", "*.tfvars", "*.toml", "*.jsonc", "*.ndjson", "*.jsonl", "*.cue", "*.xml", "*.ini", "*.properties", "*.csv"},
+		ExcludeDirs:   []string{".git", ".terragrunt-cache", "scripts"},
+		IgnoreFiles:   []string{".decodeignore"},
+		ExtensionAliases: map[string]string{
+			".yml": ".yaml",
+		},
+		Decoders: DefaultDecoders(),
+		Rules:    DefaultRules(),
+		StreamDecoders: []string{
+			"ndjson", "yaml-stream",
+		},
+		MaxYAMLAliases: 10000,
+		MaxYAMLNodes:   10000000,
+		Retries:        2,
+		RetryBackoff:   200 * time.Millisecond,
+	}
+}
+
+// DefaultConcurrency is the I/O concurrency used when Options.IOConcurrency and
+// Options.Concurrency are unset, scaled from GOMAXPROCS since reads mostly wait.
+func DefaultConcurrency() int {
+	return 4 * runtime.GOMAXPROCS(0)
+}
+
+// Progress is how far a Run has got.
+type Progress struct {
+	// Found is the number of files found to check so far, and Bytes their
+	// total size.   Both grow until the walk finishes.
+	Found int
+	Bytes int64
+	// Checked is the number of those files checked so far.
+	Checked int
+}
+
+// Report is everything a Run found.
+type Report struct {
+	// Results holds one Result per matched file, in completion order until
+	// Sort is called.
+	Results []Result
+	// Counts holds the byte count and per type file and error counts.
+	Counts *SafeCounter
+	// Interrupted is set when the run's context was cancelled before the walk
+	// finished, in which case Results and Counts only cover part of the tree.
+	Interrupted bool
+	// TimedOut is set along with Interrupted when it was the context's
+	// deadline that passed.
+	TimedOut bool
+	// Unscanned holds the paths of the files and directories left unchecked
+	// when the run was interrupted or stopped, directories standing for
+	// everything below them.
+	Unscanned []string
+	// Stopped is set when the run stopped early after Options.MaxErrors files
+	// failed, in which case Results and Counts only cover part of the tree.
+	Stopped bool
+	// Pipeline holds how files moved between the walkers, the decodes and Run.
+	Pipeline PipelineStats
+	// OverBudget is set when the run was aborted after the walk found more
+	// files than Options.MaxFiles or more bytes than Options.MaxTotalBytes, in
+	// which case Results and Counts only cover part of the tree.
+	OverBudget bool
+	// WalkErrors holds the errors listing directories and statting files met
+	// during the walk, the parts of the tree that could not be checked.
+	WalkErrors []error
+	// Directories holds the counts for each directory Options.SummaryDepth
+	// levels below the roots, ordered by path, when it is set.
+	Directories []DirectoryCounts
+}
+
+// Sort orders Results by path, and WalkErrors by message, so reports of the
+// same tree are the same from run to run however the files were scheduled.
+func (rep *Report) Sort() {
+	sort.SliceStable(rep.Results, func(i, j int) bool {
+		return rep.Results[i].Path < rep.Results[j].Path
+	})
+	sort.Strings(rep.Unscanned)
+	sort.SliceStable(rep.WalkErrors, func(i, j int) bool {
+		return rep.WalkErrors[i].Error() < rep.WalkErrors[j].Error()
+	})
+}
+
+// Failed reports whether any file failed to decode.
+func (rep *Report) Failed() bool {
+	return rep.Counts.Errors("total") > 0
+}
+
+// typeCountsJSON is the per type counts in a JSON report.
+type typeCountsJSON struct {
+	Files     int `json:"files"`
+	Errors    int `json:"errors"`
+	NonStrict int `json:"nonStrict,omitempty"`
+	Warnings  int `json:"warnings,omitempty"`
+}
+
+// MarshalJSON encodes the report as its totals, per type counts and results.
+func (rep *Report) MarshalJSON() ([]byte, error) {
+	out := struct {
+		Files       int                       `json:"files"`
+		Bytes       int64                     `json:"bytes"`
+		Errors      int                       `json:"errors"`
+		Failed      bool                      `json:"failed"`
+		Interrupted bool                      `json:"interrupted,omitempty"`
+		TimedOut    bool                      `json:"timedOut,omitempty"`
+		Stopped     bool                      `json:"stopped,omitempty"`
+		OverBudget  bool                      `json:"overBudget,omitempty"`
+		Unscanned   []string                  `json:"unscanned,omitempty"`
+		WalkErrors  []string                  `json:"walkErrors,omitempty"`
+		Types       map[string]typeCountsJSON `json:"types"`
+		Directories []DirectoryCounts         `json:"directories,omitempty"`
+		Timing      *timingJSON               `json:"timing,omitempty"`
+		Identical   []IdenticalGroup          `json:"identical,omitempty"`
+		Results     []Result                  `json:"results"`
+	}{
+		Files:       rep.Counts.Files("total"),
+		Bytes:       rep.Counts.Bytes(),
+		Errors:      rep.Counts.Errors("total"),
+		Failed:      rep.Failed(),
+		Interrupted: rep.Interrupted,
+		TimedOut:    rep.TimedOut,
+		Stopped:     rep.Stopped,
+		OverBudget:  rep.OverBudget,
+		Unscanned:   rep.Unscanned,
+		Types:       make(map[string]typeCountsJSON),
+		Directories: rep.Directories,
+		Identical:   rep.Identical(),
+		Results:     rep.Results,
+	}
+	for _, err := range rep.WalkErrors {
+		out.WalkErrors = append(out.WalkErrors, err.Error())
+	}
+	if timing := rep.Timing(); timing.Files > 0 {
+		out.Timing = &timingJSON{Files: timing.Files, TotalMs: milliseconds(timing.Total), P50Ms: milliseconds(timing.P50), P95Ms: milliseconds(timing.P95), MaxMs: milliseconds(timing.Max)}
+	}
+	for _, fileType := range rep.Counts.Types() {
+		out.Types[fileType] = typeCountsJSON{
+			Files:     rep.Counts.Files(fileType),
+			Errors:    rep.Counts.Errors(fileType),
+			NonStrict: rep.Counts.NonStrictFiles(fileType),
+			Warnings:  rep.Counts.WarningFiles(fileType),
+		}
+	}
+	if out.Results == nil {
+		out.Results = []Result{}
+	}
+	return json.Marshal(out)
+}
+
+// pipelineBuffer is how many found files, results and walk errors can wait
+// for Run at once, so walkers and decodes run ahead of it instead of in step
+// with it.
+const pipelineBuffer = 2048
+
+// eventKind is what an event hands Run.
+type eventKind int
+
+const (
+	fileFound   eventKind = iota // a file of size bytes was found to check
+	fileChecked                  // result is the outcome of checking a file
+	walkFailed                   // err was met walking, see Report.WalkErrors
+)
+
+// event is one thing walkers and decodes hand Run, all on the one channel so
+// Run reads them in the order they were sent: a file is always found before
+// its result arrives, and nothing is left unread when the channel closes.
+type event struct {
+	kind   eventKind
+	size   int64
+	result Result
+	err    error
+}
+
+// PipelineStats are how files moved through a Run: how long it took to find
+// them and check them, and how long walkers and decodes spent waiting on Run
+// with the buffers between them full, a sign Run is the bottleneck.
+type PipelineStats struct {
+	// Found is the number of files found to check, the last after Walked.
+	Found  int
+	Walked time.Duration
+	// Elapsed is how long the whole run took.
+	Elapsed time.Duration
+	// SizeWait is the total time walkers waited to hand Run a file they found,
+	// and ResultWait the total time decodes waited to hand it their result.
+	SizeWait   time.Duration
+	ResultWait time.Duration
+	// MaxQueued is the most found files, results and walk errors that were
+	// waiting for Run at once, out of Buffer.
+	MaxQueued int
+	Buffer    int
+}
+
+// Runner walks the configured roots and decodes the matching files.
+type Runner struct {
+	opts          Options
+	includes      []pathPattern
+	excludes      []pathPattern
+	rules         []compiledRule
+	checks        []compiledCheck
+	ignoreErrors  []compiledFilter
+	filenameRules []compiledFilenameRule
+	ioSema        chan struct{} // counting semaphore limiting dirents and file reads
+	decodeSema    chan struct{} // counting semaphore limiting decoder calls
+
+	visitedMu sync.Mutex
+	visited   map[string]bool // resolved paths of directories walked, with FollowSymlinks
+
+	unscannedMu sync.Mutex
+	unscanned   []string // paths left unchecked when the run was cancelled
+
+	linksMu sync.Mutex
+	links   map[fileKey]string  // path each file was first found at, with DedupeLinks
+	aliases map[string][]string // other paths each of those files was found at
+
+	decodes chan decodeJob // files queued for the decode workers this run
+
+	sizeWait   atomic.Int64 // nanoseconds walkers waited on a full events this run
+	resultWait atomic.Int64 // nanoseconds decodes waited on a full events this run
+}
+
+// NewRunner returns a Runner for opts.
+func NewRunner(opts Options) *Runner {
+	budget := opts.Budget
+	if budget == nil {
+		budget = NewBudget(opts)
+	}
+	if opts.FS == nil {
+		opts.Roots = osPaths(opts.Roots)
+		opts.Files = osPaths(opts.Files)
+	}
+	r := &Runner{
+		opts:          opts,
+		includes:      compilePatterns(opts.MatchPatterns, opts.MatchRegex),
+		excludes:      compilePatterns(opts.ExcludePatterns, opts.ExcludeRegex),
+		rules:         compileRules(opts.Rules),
+		checks:        compileChecks(opts.Checks),
+		ignoreErrors:  compileFilters(opts.IgnoreErrors),
+		filenameRules: compileFilenameRules(opts.FilenameRules),
+		ioSema:        budget.io,
+		decodeSema:    budget.decode,
+	}
+	if opts.IgnorePathCase {
+		r.foldPatterns()
+	}
+	return r
+}
+
+// osPaths returns names with osPath applied to each, nil staying nil.
+func osPaths(names []string) []string {
+	if names == nil {
+		return nil
+	}
+	paths := make([]string, len(names))
+	for i, name := range names {
+		paths[i] = osPath(name)
+	}
+	return paths
+}
+
+// Budget is a pool of I/O and decode tokens, which Runners sharing it through
+// Options.Budget take from, as a daemon running several scans at once does.
+type Budget struct {
+	io, decode chan struct{}
+}
+
+// NewBudget returns a Budget with the I/O and decode limits of opts.
+func NewBudget(opts Options) *Budget {
+	return &Budget{
+		io:     make(chan struct{}, firstPositive(opts.IOConcurrency, opts.Concurrency, DefaultConcurrency())),
+		decode: make(chan struct{}, firstPositive(opts.DecodeConcurrency, opts.Concurrency, runtime.NumCPU())),
+	}
+}
+
+// firstPositive returns the first of limits above zero, or 1 if there are none.
+func firstPositive(limits ...int) int {
+	for _, limit := range limits {
+		if limit > 0 {
+			return limit
+		}
+	}
+	return 1
+}
+
+// acquire takes a token from sema, returning false instead if ctx is cancelled
+// first.   Tokens are released with release.
+func acquire(ctx context.Context, sema chan struct{}) bool {
+	if ctx.Err() != nil {
+		return false // select picks at random when a token is free too
+	}
+	select {
+	case sema <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// release returns a token taken by acquire.
+func release(sema chan struct{}) {
+	<-sema
+}
+
+// Run walks every root, decodes each matching file and returns the report.
+// Cancelling parent stops the walk and starts no more decodes, files already
+// being decoded finish and are reported, and the report of what was checked so
+// far is returned marked Interrupted, listing what was not in Unscanned.
+// Reaching Options.MaxErrors stops the walk the same way, marking the report
+// Stopped, and passing Options.MaxFiles or Options.MaxTotalBytes marks it
+// OverBudget.
+func (r *Runner) Run(parent context.Context) *Report {
+	reporter := NewReporter()
+	report := reporter.Report()
+	ctx, span := tracer.Start(parent, "scan", trace.WithAttributes(attribute.StringSlice("decodetest.roots", r.opts.Roots)))
+	defer span.End()
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	// Create Buffered Channels, A Fixed Pool Of Decode Workers And The Walkers' WaitGroup
+	started := time.Now()
+	events := make(chan event, pipelineBuffer)
+	r.decodes = make(chan decodeJob, pipelineBuffer)
+	var n, workers sync.WaitGroup
+	for i := 0; i < max(cap(r.ioSema), cap(r.decodeSema)); i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			r.decodeWorker(ctx, r.decodes, events)
+		}()
+	}
+	r.visited = make(map[string]bool)
+	r.unscanned = nil
+	r.links = make(map[fileKey]string)
+	r.aliases = make(map[string][]string)
+	r.sizeWait.Store(0)
+	r.resultWait.Store(0)
+	report.Pipeline.Buffer = pipelineBuffer
+	var progress Progress
+	uniqueSeen := make(map[string]string)
+
+	// Check Listed Files, Or Search Roots Recursively With Each OS Root In Its Own DirFS
+	for _, file := range r.opts.Files {
+		n.Add(1)
+		if r.opts.FS != nil {
+			go r.checkFile(ctx, searchRoot{fsys: r.opts.FS, listed: true}, path.Clean(filepath.ToSlash(file)), &n, events)
+		} else {
+			dir := filepath.Dir(file)
+			go r.checkFile(ctx, searchRoot{fsys: os.DirFS(dir), prefix: dir, listed: true}, filepath.Base(file), &n, events)
+		}
+	}
+	for _, root := range r.opts.Roots {
+		if r.opts.Files != nil {
+			break
+		}
+		n.Add(1)
+		go r.walkRoot(ctx, root, &n, events)
+	}
+	go func() {
+		n.Wait()
+		close(r.decodes)
+		workers.Wait()
+		close(events)
+	}()
+
+	for e := range events {
+		report.Pipeline.MaxQueued = max(report.Pipeline.MaxQueued, len(events))
+		switch e.kind {
+		case fileFound:
+			// Track Progress Unless Draining After Cancellation, Bytes Are Counted With Each Result
+			if ctx.Err() != nil {
+				continue
+			}
+			progress.Found++
+			progress.Bytes += e.size
+			report.Pipeline.Found, report.Pipeline.Walked = progress.Found, time.Since(started)
+			if r.opts.OnProgress != nil {
+				r.opts.OnProgress(progress)
+			}
+			if (r.opts.MaxFiles > 0 && progress.Found > r.opts.MaxFiles) || (r.opts.MaxTotalBytes > 0 && progress.Bytes > r.opts.MaxTotalBytes) {
+				report.OverBudget = true
+				stop()
+			}
+
+		case walkFailed:
+			reporter.AddWalkError(e.err)
+			if r.opts.OnWalkError != nil {
+				r.opts.OnWalkError(e.err)
+			}
+
+		case fileChecked:
+			result := e.result
+			r.applyUnique(uniqueSeen, &result)
+			r.applyBaseline(&result)
+			reporter.Add(result)
+			if result.Failed() && r.opts.MaxErrors > 0 && report.Counts.Errors("total") >= r.opts.MaxErrors {
+				report.Stopped = true
+				stop()
+			}
+			r.observe(result)
+			if r.opts.OnResult != nil {
+				r.opts.OnResult(result)
+			}
+			progress.Checked++
+			if r.opts.OnProgress != nil {
+				r.opts.OnProgress(progress)
+			}
+		}
+	}
+	report = reporter.Report()
+	report.Interrupted = parent.Err() != nil
+	report.TimedOut = errors.Is(parent.Err(), context.DeadlineExceeded)
+	r.applyAliases(report.Results)
+	report.Pipeline.Elapsed = time.Since(started)
+	report.Pipeline.SizeWait = time.Duration(r.sizeWait.Load())
+	report.Pipeline.ResultWait = time.Duration(r.resultWait.Load())
+	report.Unscanned = r.unscanned
+	span.SetAttributes(
+		attribute.Int("decodetest.files", report.Counts.Files("total")),
+		attribute.Int("decodetest.errors", report.Counts.Errors("total")),
+		attribute.Bool("decodetest.interrupted", report.Interrupted),
+	)
+	if !report.Interrupted {
+		r.ranScan()
+	}
+	return report
+}
+
+// walkRoot searches root, one of the Roots, with each OS root in its own DirFS.
+// A root that is a file rather than a directory is decoded without walking.
+func (r *Runner) walkRoot(ctx context.Context, root string, n *sync.WaitGroup, events chan<- event) {
+	var info fs.FileInfo
+	var err error
+	var file searchRoot
+	var name string
+	if r.opts.FS != nil {
+		name = path.Clean(filepath.ToSlash(root))
+		file = searchRoot{fsys: r.opts.FS, listed: true}
+		info, err = r.stat(ctx, file, name)
+	} else {
+		name = filepath.Base(root)
+		info, err = os.Stat(root)
+		file = searchRoot{fsys: os.DirFS(filepath.Dir(root)), prefix: filepath.Dir(root), listed: true}
+	}
+	if err != nil || info.IsDir() {
+		if r.opts.FS != nil {
+			r.walkDir(ctx, searchRoot{fsys: r.opts.FS, top: name}, name, nil, n, events)
+		} else {
+			r.walkDir(ctx, searchRoot{fsys: os.DirFS(root), prefix: root}, ".", r.parentIgnores(root), n, events)
+		}
+		return
+	}
+
+	defer n.Done()
+	if r.opts.Archives && isArchive(name) {
+		n.Add(1)
+		r.walkArchive(ctx, file, name, info.Size(), n, events)
+		return
+	}
+	if !r.sendFound(ctx, events, info.Size()) {
+		r.notScanned(file, name)
+		return
+	}
+	r.queueDecode(ctx, file, name, "", info.Size())
+}
+
+// searchRoot is a filesystem being searched, and the OS path prefix used to
+// display paths within it (empty when searching Options.FS).   top is the
+// directory in fsys the walk started from, when that is not its root, and
+// listed is set for the roots of Options.Files.   The files of an archive are
+// searched in a root of their own, with outer the root the archive was found
+// in and archive its name there.
+type searchRoot struct {
+	fsys    fs.FS
+	prefix  string
+	top     string
+	listed  bool
+	outer   *searchRoot
+	archive string
+}
+
+// rel returns name relative to the directory the walk started from, the path
+// patterns are matched against.   Listed files have no walk, so their patterns
+// match the path as listed.
+func (root searchRoot) rel(name string) string {
+	switch {
+	case root.listed:
+		return filepath.ToSlash(root.display(name))
+	case root.top == "" || root.top == ".":
+		return name
+	}
+	return strings.TrimPrefix(name, root.top+"/")
+}
+
+// display returns the path shown for name, a slash separated path in root.fsys.
+func (root searchRoot) display(name string) string {
+	if root.outer != nil {
+		return root.outer.display(root.archive) + "!" + strings.TrimPrefix(name, ".")
+	}
+	if root.prefix == "" {
+		return name
+	}
+	return filepath.Join(root.prefix, filepath.FromSlash(name))
+}
+
+// cacheKey returns the key cached results for name are stored under, the
+// absolute path for OS roots so runs from different directories share results.
+func (root searchRoot) cacheKey(name string) string {
+	if root.outer != nil {
+		return root.outer.cacheKey(root.archive) + "!" + name
+	}
+	if root.prefix == "" {
+		return name
+	}
+	if abs, err := filepath.Abs(root.display(name)); err == nil {
+		return abs
+	}
+	return root.display(name)
+}
+
+// walkDir recursively walks the file tree rooted at dir, sends the size of
+// each found file on events and queues it for the decode workers.
+// ignores are the ignore files that apply above dir.
+func (r *Runner) walkDir(ctx context.Context, root searchRoot, dir string, ignores *ignoreStack, n *sync.WaitGroup, events chan<- event) {
+	defer n.Done()
+	ctx, span := tracer.Start(ctx, "walk directory", trace.WithAttributes(attribute.String("decodetest.path", root.display(dir))))
+	defer span.End()
+
+	// Walk Each Directory Once When Symlinks Can Lead Back Into The Tree
+	if r.opts.FollowSymlinks && !r.firstVisit(root, dir) {
+		return
+	}
+
+	if len(r.opts.IgnoreFiles) > 0 {
+		if !acquire(ctx, r.ioSema) {
+			r.notScanned(root, dir)
+			return
+		}
+		ignores = r.readIgnores(root, dir, ignores)
+		release(r.ioSema)
+	}
+
+	entries := r.dirents(ctx, root, dir, events)
+	if entries == nil && ctx.Err() != nil {
+		r.notScanned(root, dir)
+		return
+	}
+	span.SetAttributes(attribute.Int("decodetest.entries", len(entries)))
+	if r.opts.OnDir != nil {
+		r.opts.OnDir(root.display(dir), len(entries))
+	}
+	for i, entry := range entries {
+		// Stop Walking Once Cancelled, Leaving The Rest Of The Directory Unscanned
+		if ctx.Err() != nil {
+			r.notScannedEntries(root, dir, entries[i:])
+			return
+		}
+
+		// Resolve Symlinks When Following Them, So Linked Directories Are Walked
+		isDir := entry.IsDir()
+		if r.opts.FollowSymlinks && entry.Type()&fs.ModeSymlink != 0 {
+			isDir = r.linksToDir(ctx, root, path.Join(dir, entry.Name()), events)
+		}
+
+		// Skip Anything The Ignore Files Match, And Hidden Entries If Asked To
+		if ignores != nil && ignores.ignored(path.Join(dir, entry.Name()), isDir) {
+			r.skipped(root, path.Join(dir, entry.Name()), "matched by an ignore file")
+			continue
+		}
+		if r.opts.SkipHidden && strings.HasPrefix(entry.Name(), ".") {
+			r.skipped(root, path.Join(dir, entry.Name()), "hidden")
+			continue
+		}
+
+		// If Entry Is Directory And Not In excludedDirs Recursively Walk It
+		if isDir && r.excludedDir(entry.Name()) {
+			r.skipped(root, path.Join(dir, entry.Name()), "excluded directory")
+		} else if isDir {
+			subdir := path.Join(dir, entry.Name())
+			if r.opts.MaxDepth > 0 && depth(root.rel(subdir)) >= r.opts.MaxDepth {
+				r.skipped(root, subdir, "below -max-depth")
+				continue
+			}
+			n.Add(1)
+			go r.walkDir(ctx, root, subdir, ignores, n, events)
+		} else {
+			// If Entry Is Not A Directory, Test For Pattern Match.   Exclude Files with Size 0
+			// Those don't need to be decoded, Or Matching An Exclude Pattern.
+			name := path.Join(dir, entry.Name())
+			rel := root.rel(name)
+			match, ok := r.matching(rel)
+			archive := r.opts.Archives && isArchive(entry.Name())
+			if ok || archive {
+				if r.excluded(rel) {
+					r.skipped(root, name, "matched an exclude pattern")
+					continue
+				}
+				var info fs.FileInfo
+				err := r.withRetries(ctx, root, name, func() (err error) {
+					info, err = entry.Info()
+					return err
+				})
+				if err == nil && r.opts.FollowSymlinks && info.Mode()&fs.ModeSymlink != 0 {
+					info, err = r.stat(ctx, root, name)
+				}
+
+				// Report Files That Cannot Be Statted, Unless They Were Removed Since The Listing
+				if err != nil {
+					if !errors.Is(err, fs.ErrNotExist) {
+						r.walkError(events, displayError(root, err))
+					}
+					continue
+				}
+				if reason := r.skipReason(info); reason != "" {
+					r.skipped(root, name, reason)
+					continue
+				}
+
+				if first, ok := r.firstLink(root, name, info); !ok {
+					r.skipped(root, name, "same file as "+first)
+					continue
+				}
+
+				// Walk Archives Like Directories, Their Files Are Counted As They Are Found
+				if archive {
+					n.Add(1)
+					go r.walkArchive(ctx, root, name, info.Size(), n, events)
+					continue
+				}
+				if !r.sendFound(ctx, events, info.Size()) {
+					r.notScannedEntries(root, dir, entries[i:])
+					return
+				}
+				r.queueDecode(ctx, root, name, match, info.Size())
+			}
+		}
+	}
+}
+
+// skipReason returns why the file described by info is left out of the run,
+// being empty without CheckEmpty, outside MinSize and MaxSize or not modified since NewerThan, or
+// "" if it is checked.   Oversized files are still checked when ReportOversize
+// is set, fileDecode reports them without reading them.
+func (r *Runner) skipReason(info fs.FileInfo) string {
+	size := info.Size()
+	switch {
+	case size == 0 && !r.opts.CheckEmpty:
+		return "empty"
+	case size < r.opts.MinSize:
+		return "smaller than -min-size"
+	case !r.opts.NewerThan.IsZero() && !info.ModTime().IsZero() && !info.ModTime().After(r.opts.NewerThan):
+		return "not modified since -newer-than"
+	case r.opts.MaxSize > 0 && size > r.opts.MaxSize && !r.opts.ReportOversize:
+		return "larger than -max-size"
+	}
+	return ""
+}
+
+// skipped passes name in root, and the reason it was skipped, to OnSkip.
+func (r *Runner) skipped(root searchRoot, name, reason string) {
+	if r.opts.OnSkip != nil {
+		r.opts.OnSkip(root.display(name), reason)
+	}
+}
+
+// depth returns the number of directories in the slash separated path rel, a
+// directory relative to the root.
+func depth(rel string) int {
+	if rel == "." || rel == "" {
+		return 0
+	}
+	return strings.Count(rel, "/") + 1
+}
+
+// linksToDir reports whether the symlink name in root resolves to a directory,
+// sending the error on events when it cannot be resolved.
+func (r *Runner) linksToDir(ctx context.Context, root searchRoot, name string, events chan<- event) bool {
+	if !acquire(ctx, r.ioSema) {
+		return false
+	}
+	defer release(r.ioSema)
+
+	info, err := r.stat(ctx, root, name)
+	if err != nil {
+		r.walkError(events, displayError(root, err))
+		return false
+	}
+	return info.IsDir()
+}
+
+// firstVisit records directory dir in root by its resolved OS path, reporting
+// false if it was already walked through another path.   Directories that
+// cannot be resolved, or are not on the OS filesystem, are always walked.
+func (r *Runner) firstVisit(root searchRoot, dir string) bool {
+	if root.prefix == "" {
+		return true
+	}
+	real, err := filepath.EvalSymlinks(root.display(dir))
+	if err != nil {
+		return true
+	}
+	if abs, err := filepath.Abs(real); err == nil {
+		real = abs
+	}
+
+	r.visitedMu.Lock()
+	defer r.visitedMu.Unlock()
+	if r.visited[real] {
+		return false
+	}
+	r.visited[real] = true
+	return true
+}
+
+// checkFile checks a single listed file, skipping it unless it matches the
+// MatchPatterns outside the ExcludeDirs and is not empty.
+func (r *Runner) checkFile(ctx context.Context, root searchRoot, name string, n *sync.WaitGroup, events chan<- event) {
+	defer n.Done()
+
+	rel := root.rel(name)
+	match, ok := r.matching(rel)
+	archive := r.opts.Archives && isArchive(name)
+	if !ok && !archive {
+		return
+	}
+	if r.excluded(rel) {
+		r.skipped(root, name, "matched an exclude pattern")
+		return
+	}
+	for _, component := range strings.Split(filepath.FromSlash(root.display(name)), string(filepath.Separator)) {
+		if r.opts.SkipHidden && strings.HasPrefix(component, ".") && component != "." && component != ".." {
+			r.skipped(root, name, "hidden")
+			return
+		}
+	}
+	for _, dir := range strings.Split(filepath.Dir(filepath.FromSlash(root.display(name))), string(filepath.Separator)) {
+		if r.excludedDir(dir) {
+			r.skipped(root, name, "in an excluded directory")
+			return
+		}
+	}
+	if r.pathIgnored(root, name) {
+		r.skipped(root, name, "matched by an ignore file")
+		return
+	}
+
+	if !acquire(ctx, r.ioSema) {
+		r.notScanned(root, name)
+		return
+	}
+	info, err := r.stat(ctx, root, name)
+	release(r.ioSema)
+	if err != nil && r.opts.RequireFiles && errors.Is(err, fs.ErrNotExist) {
+		// Fail Missing Files Under The Decoder They Would Have Been Checked With
+		result := Result{Path: root.display(name), Status: ReadFailed, Err: displayError(root, err), Match: match}
+		if result.Type, _, _ = decoderFor(r.opts.Decoders, r.rules, r.opts.canonicalName(rel)); result.Type == "" {
+			result.Type = path.Ext(r.opts.canonicalName(rel))
+		}
+		if !r.sendFound(ctx, events, 0) {
+			r.notScanned(root, name)
+			return
+		}
+		r.applyPathRules(rel, &result)
+		r.sendResult(events, result)
+		return
+	}
+	if err != nil {
+		r.walkError(events, displayError(root, err))
+		return
+	}
+	if info.IsDir() {
+		return
+	}
+	if reason := r.skipReason(info); reason != "" {
+		r.skipped(root, name, reason)
+		return
+	}
+	if first, ok := r.firstLink(root, name, info); !ok {
+		r.skipped(root, name, "same file as "+first)
+		return
+	}
+	if archive {
+		n.Add(1)
+		r.walkArchive(ctx, root, name, info.Size(), n, events)
+		return
+	}
+	if !r.sendFound(ctx, events, info.Size()) {
+		r.notScanned(root, name)
+		return
+	}
+
+	r.queueDecode(ctx, root, name, match, info.Size())
+}
+
+// decodeJob is a file found to check, queued for the decode workers of a Run.
+type decodeJob struct {
+	root        searchRoot
+	name, match string
+	size        int64
+}
+
+// queueDecode hands name in root, which was selected by the match pattern
+// match and is size bytes, to the decode workers, waiting while they are all
+// busy and the queue is full.   If ctx is cancelled first, it is recorded as
+// unscanned instead.
+func (r *Runner) queueDecode(ctx context.Context, root searchRoot, name, match string, size int64) {
+	select {
+	case r.decodes <- decodeJob{root: root, name: name, match: match, size: size}:
+	case <-ctx.Done():
+		r.notScanned(root, name)
+	}
+}
+
+// decodeWorker decodes the files queued on decodes until it is closed.
+func (r *Runner) decodeWorker(ctx context.Context, decodes <-chan decodeJob, events chan<- event) {
+	for job := range decodes {
+		r.decodeFile(ctx, job.root, job.name, job.match, job.size, events)
+	}
+}
+
+// decodeFile decodes name in root, which was selected by the match pattern
+// match and is size bytes, and sends its Result on events.   If ctx is
+// cancelled before decoding starts, it is recorded as unscanned instead.
+func (r *Runner) decodeFile(ctx context.Context, root searchRoot, name, match string, size int64, events chan<- event) {
+	ctx, span := tracer.Start(ctx, "check file", trace.WithAttributes(attribute.String("decodetest.path", root.display(name))))
+
+	result, ok := r.fileDecode(ctx, root, name)
+	if !ok {
+		r.notScanned(root, name)
+		span.End()
+		return
+	}
+	r.applyPathRules(root.rel(name), &result)
+	endFileSpan(span, result)
+	result.Match = match
+	if result.Size == 0 {
+		result.Size = size // files without a decoder are not read
+	}
+	if r.opts.SummaryDepth > 0 {
+		result.summaryDir = r.summaryDir(root, name)
+	}
+	r.sendResult(events, result)
+}
+
+// applyPathRules applies the Options that depend on where the file at rel
+// from its root is to its result, Options.FilenameRules and then
+// Options.IgnoreErrors, so misnamed files can be ignored too.   Cached results
+// are stored before them.
+func (r *Runner) applyPathRules(rel string, result *Result) {
+	r.applyFilenameRules(rel, result)
+	r.applyIgnoreErrors(rel, result)
+}
+
+// sendFound hands Run the size of a file found to check, adding any time
+// spent waiting for room in events to the run's SizeWait.   It returns false
+// instead if ctx is cancelled while waiting.
+func (r *Runner) sendFound(ctx context.Context, events chan<- event, size int64) bool {
+	if ctx.Err() != nil {
+		return false // there may still be room in events
+	}
+	found := event{kind: fileFound, size: size}
+	select {
+	case events <- found:
+		return true
+	default:
+	}
+	start := time.Now()
+	defer func() { r.sizeWait.Add(int64(time.Since(start))) }()
+	select {
+	case events <- found:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// sendResult hands Run result, adding any time spent waiting for room in
+// events to the run's ResultWait.   Run reads events until every walker and
+// decode is done, so this never blocks for good.
+func (r *Runner) sendResult(events chan<- event, result Result) {
+	checked := event{kind: fileChecked, result: result}
+	select {
+	case events <- checked:
+		return
+	default:
+	}
+	start := time.Now()
+	events <- checked
+	r.resultWait.Add(int64(time.Since(start)))
+}
+
+// notScanned records name in root as left unchecked by a cancelled run.
+func (r *Runner) notScanned(root searchRoot, name string) {
+	r.unscannedMu.Lock()
+	r.unscanned = append(r.unscanned, root.display(name))
+	r.unscannedMu.Unlock()
+}
+
+// notScannedEntries records the entries of dir in root that a cancelled run
+// left unchecked, the subdirectories and the files the patterns match.
+func (r *Runner) notScannedEntries(root searchRoot, dir string, entries []fs.DirEntry) {
+	for _, entry := range entries {
+		name := path.Join(dir, entry.Name())
+		if _, ok := r.matching(root.rel(name)); ok || entry.IsDir() || (r.opts.Archives && isArchive(name)) {
+			r.notScanned(root, name)
+		}
+	}
+}
+
+// dirents returns the entries of directory dir, or none once ctx is cancelled,
+// sending any error listing it on events.
+func (r *Runner) dirents(ctx context.Context, root searchRoot, dir string, events chan<- event) []fs.DirEntry {
+
+	if !acquire(ctx, r.ioSema) {
+		return nil
+	}
+	defer release(r.ioSema)
+
+	entries, err := r.readDir(ctx, root, dir)
+	if err != nil {
+		r.walkError(events, displayError(root, err))
+		// Don't return: ReadDir may return partial results.
+	}
+	return entries
+}
+
+// walkError hands Run err, met listing a directory or statting a file, for
+// Report.WalkErrors.
+func (r *Runner) walkError(events chan<- event, err error) {
+	events <- event{kind: walkFailed, err: err}
+}
+
+// reportError passes err to OnWalkError, or prints it on stderr when unset.
+func (r *Runner) reportError(err error) {
+	if r.opts.OnWalkError != nil {
+		r.opts.OnWalkError(err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "decodeTest: %v\n", err)
+}
+
+// displayError rewrites the path in filesystem errors to the displayed path, so
+// errors name the OS path instead of the path relative to the DirFS root.
+func displayError(root searchRoot, err error) error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return &fs.PathError{Op: pathErr.Op, Path: root.display(pathErr.Path), Err: pathErr.Err}
+	}
+	return err
+}
+
+// fileDecode decodes name in root with the decoder its decoder rule selects.
+// When RelaxedJSON is set, json files that fail strict decoding are retried with
+// JSONCDecodeFunc and reported as NonStrict if that succeeds.   Reading holds an
+// I/O token and decoding a decode token, the bool is false if ctx was cancelled
+// while waiting for either.
+func (r *Runner) fileDecode(ctx context.Context, root searchRoot, name string) (Result, bool) {
+
+	filename := root.display(name)
+	result := Result{Path: filename}
+
+	decoderName, decodeFunction, ok := decoderFor(r.opts.Decoders, r.rules, r.opts.canonicalName(root.rel(name)))
+	if !ok {
+		result.Type = path.Ext(r.opts.canonicalName(filepath.ToSlash(filename)))
+		result.Status = NoDecoder
+		result.Err = fmt.Errorf("no decoder for file type %s", result.Type)
+		if r.opts.severity(CategoryNoDecoder, SeverityError) == SeverityWarning {
+			result.Severity = SeverityWarning
+			result.Warnings = []string{result.Err.Error()}
+		}
+		return result, true
+	}
+	result.Type = decoderName
+	if r.opts.OnDecoder != nil {
+		rule, _ := ruleFor(r.rules, r.opts.canonicalName(root.rel(name)))
+		r.opts.OnDecoder(filename, decoderName, rule.pattern.source)
+	}
+	if r.opts.ListOnly {
+		rule, _ := ruleFor(r.rules, r.opts.canonicalName(root.rel(name)))
+		result.Status, result.Rule = Listed, rule.pattern.source
+		return result, true
+	}
+
+	if !acquire(ctx, r.ioSema) {
+		return Result{}, false
+	}
+	if r.opts.MaxSize > 0 || r.opts.SyntaxOnlyAbove > 0 {
+		if info, err := r.stat(ctx, root, name); err == nil {
+			switch check, ok := syntaxCheckers[decoderName]; {
+			case r.opts.MaxSize > 0 && info.Size() > r.opts.MaxSize:
+				release(r.ioSema)
+				result.Size = info.Size()
+				result.Status = TooLarge
+				result.Err = fmt.Errorf("file is %d bytes, over the maximum size of %d", info.Size(), r.opts.MaxSize)
+				return result, true
+			case ok && r.opts.SyntaxOnlyAbove > 0 && info.Size() > r.opts.SyntaxOnlyAbove:
+				release(r.ioSema)
+				result.Size = info.Size()
+				return r.syntaxOnlyDecode(ctx, root, name, result, check)
+			}
+		}
+	}
+	fileString, err := r.readFile(ctx, root, name)
+	release(r.ioSema)
+	if err != nil {
+		result.Status = ReadFailed
+		result.Err = displayError(root, err)
+		return result, true
+	}
+	return r.decodeSource(ctx, result, root.cacheKey(name), root.prefix, decodeFunction, r.checksFor(root.rel(name)), fileString)
+}
+
+// Validate decodes src as if it were the file name, without reading anything.
+// The decoder is picked by decoderName, or by the decoder rules for name when
+// decoderName is empty.   Results are never cached, the bool is false if ctx was
+// cancelled while waiting for a decode token.   src has no directory, so it
+// cannot import other files.
+func (r *Runner) Validate(ctx context.Context, name, decoderName string, src []byte) (Result, bool) {
+	result := Result{Path: name}
+
+	var decodeFunction function.Function
+	var ok bool
+	if decoderName == "" {
+		if decoderName, decodeFunction, ok = decoderFor(r.opts.Decoders, r.rules, r.opts.canonicalName(filepath.ToSlash(name))); !ok {
+			result.Type = path.Ext(r.opts.canonicalName(filepath.ToSlash(name)))
+			result.Status = NoDecoder
+			result.Err = fmt.Errorf("no decoder for file type %s", result.Type)
+			return result, true
+		}
+	} else if decodeFunction, ok = r.opts.Decoders[decoderName]; !ok {
+		result.Type = decoderName
+		result.Status = NoDecoder
+		result.Err = fmt.Errorf("unknown decoder %q", decoderName)
+		return result, true
+	}
+	result.Type = decoderName
+
+	result, ok = r.decodeSource(ctx, result, "", "", decodeFunction, r.checksFor(filepath.ToSlash(name)), src)
+	if ok {
+		r.observe(result)
+	}
+	return result, ok
+}
+
+// decodeSource decodes src with decodeFunction, filling in result whose Path and
+// Type are already set.   Results are looked up in and stored to the cache under
+// cacheKey, unless it is empty.   Decoders that import other files may only
+// import those under importDir, none when it is empty.   checks are run on the
+// decoded value.   Files
+// in UTF-16, UTF-32 or with a byte order mark are decoded as UTF-8, reporting
+// their encoding.
+func (r *Runner) decodeSource(ctx context.Context, result Result, cacheKey, importDir string, decodeFunction function.Function, checks []CheckRule, raw []byte) (Result, bool) {
+	result.Size = int64(len(raw))
+
+	// Empty Files Have Nothing To Decode, So They Are Only Reported
+	if len(raw) == 0 && r.opts.CheckEmpty {
+		result.Status = Passed
+		r.addFinding(&result, CategoryEmpty+"/file", SeverityError, "empty file")
+		return result, true
+	}
+
+	src, encoding, err := transcode(raw)
+	if err != nil {
+		result.Status = DecodeFailed
+		result.Err = fmt.Errorf("invalid %s: %w", encoding, err)
+		return result, true
+	}
+
+	ctyValues := []cty.Value{
+		cty.StringVal(string(src)),
+	}
+
+	// Converting And Comparing Snapshots Need The Decoded Value, So They Skip The Cache, And Decrypted Values Are Never Stored
+	cacheable := r.opts.Cache != nil && cacheKey != "" && r.opts.Convert == "" && r.opts.Snapshots == nil && !r.opts.SOPSDecrypt
+	if r.opts.TerragruntRefs && isTerragruntConfig(result.Path) {
+		cacheable = false
+	}
+
+	// Decoders That Resolve Relative Files Take The File Path As An Extra Argument, Those Only Naming It In Messages Are Cached Under It
+	var pathTag string
+	if decodeFunction.VarParam() != nil {
+		ctyValues = append(ctyValues, cty.StringVal(result.Path))
+		if contains(importDirDecoders, result.Type) {
+			ctyValues = append(ctyValues, cty.StringVal(importDir))
+		}
+		if contains(pathLabelDecoders, result.Type) {
+			pathTag = " path=" + result.Path
+		} else {
+			cacheable = false
+		}
+	}
+
+	// Reuse The Last Result If Neither The File Nor The Decoder Settings Changed
+	var hash string
+	if cacheable {
+		hash = contentHash(result.Type, r.cacheTag(result.Type)+checksTag(checks)+pathTag, raw)
+		if r.opts.Cache.lookup(cacheKey, hash, &result) {
+			result.Cached = true
+			return result, true
+		}
+	}
+
+	if !acquire(ctx, r.decodeSema) {
+		return Result{}, false
+	}
+	_, span := tracer.Start(ctx, "decode")
+	start := time.Now()
+	outcome, timedOut := r.decodeTimed(result.Type, result.Path, decodeFunction, checks, ctyValues)
+	result.Duration = time.Since(start)
+	result.Status, result.Warnings, result.Err, result.TypeSignature = outcome.status, outcome.warnings, outcome.err, outcome.typeSignature
+	result.Shape, result.ValueHash, result.UniqueValues = outcome.shape, outcome.valueHash, outcome.uniqueValues
+	result.Terraform = outcome.terraform
+	result.QueryResult, result.Converted = outcome.queryResult, outcome.converted
+	result.FormatDiff = outcome.formatDiff
+	span.End()
+	release(r.decodeSema)
+
+	// Point Decode Errors At The Line And Column They Were Found On
+	if result.Status == DecodeFailed && !timedOut {
+		result.Line, result.Column, _ = failurePosition(result.Type, result.Err, src)
+		result.Err = withoutPosition(result.Err, result.Line, result.Column)
+	}
+
+	// Report Files Not In Plain UTF-8 And Problems With Their Bytes, Whether They Decoded Or Not
+	if encoding != "" {
+		r.addFinding(&result, CategoryEncoding, SeverityWarning, "encoded as "+encoding+", checked as UTF-8")
+	}
+	for _, finding := range lintText(src, r.opts.LintText, r.opts.LintCRLF, r.opts.LintFinalNewline) {
+		r.addFinding(&result, finding.category, finding.severity, finding.message)
+	}
+
+	// Timeouts Depend On The Machine, So They Are Not Cached
+	if cacheable && !timedOut {
+		r.opts.Cache.store(cacheKey, hash, result)
+	}
+	return result, true
+}
+
+// addFinding adds finding in category to result as a warning or, when
+// Options.Severities or fallback make it an error, fails result with it,
+// adding it to the error of a result that already failed.
+func (r *Runner) addFinding(result *Result, category string, fallback Severity, finding string) {
+	switch {
+	case r.opts.severity(category, fallback) == SeverityWarning:
+		result.Warnings = append(result.Warnings, finding)
+	case result.Err == nil:
+		result.Status, result.Err = Invalid, errors.New(finding)
+	default:
+		result.Err = errors.Join(result.Err, errors.New(finding))
+	}
+}
+
+// decodeTimed calls decode, giving up once Options.DecodeTimeout has passed and
+// reporting whether it did.
+func (r *Runner) decodeTimed(decoderName, name string, decodeFunction function.Function, checks []CheckRule, ctyValues []cty.Value) (decoded, bool) {
+	if r.opts.DecodeTimeout <= 0 {
+		return r.decode(decoderName, name, decodeFunction, checks, ctyValues), false
+	}
+
+	done := make(chan decoded, 1)
+	go func() {
+		done <- r.decode(decoderName, name, decodeFunction, checks, ctyValues)
+	}()
+
+	timer := time.NewTimer(r.opts.DecodeTimeout)
+	defer timer.Stop()
+	select {
+	case d := <-done:
+		return d, false
+	case <-timer.C:
+		return decoded{status: DecodeFailed, err: fmt.Errorf("timed out after %v", r.opts.DecodeTimeout)}, true
+	}
+}
+
+// decoded is the outcome of decoding and checking one file.
+type decoded struct {
+	status        Status
+	warnings      []string
+	err           error
+	typeSignature string
+	shape         *Shape
+	terraform     *TerraformSummary
+	valueHash     string
+	uniqueValues  map[string]string
+	queryResult   string
+	converted     []byte
+	formatDiff    string
+}
+
+// decode checks YAML alias expansion against its limits, and YAML against the
+// core schema when StrictYAML is set, then calls
+// decodeFunction on ctyValues, falling back to JSONCDecodeFunc for json files
+// when RelaxedJSON is set.   Both those and jsonc files using comments or
+// trailing commas are NonStrict.   It then looks for duplicate keys when StrictKeys is
+// set and runs checks on the value decoded from the file name.   Warnings from
+// LintYAML are returned for files that decode.   Files encrypted by sops are
+// decrypted first when SOPSDecrypt is set, and otherwise only reported.
+func (r *Runner) decode(decoderName, name string, decodeFunction function.Function, checks []CheckRule, ctyValues []cty.Value) decoded {
+	status := Passed
+	src := []byte(ctyValues[0].AsString())
+	if contains(yamlLimitDecoders, decoderName) {
+		if err := checkYAMLExpansion(src, r.opts.MaxYAMLAliases, r.opts.MaxYAMLNodes); err != nil {
+			return decoded{status: DecodeFailed, err: err}
+		}
+	}
+	if r.opts.StrictYAML && contains(yamlStrictDecoders, decoderName) {
+		if err := strictYAML(src); err != nil {
+			return decoded{status: DecodeFailed, err: fmt.Errorf("yaml strict: %w", err)}
+		}
+	}
+	value, err := decodeFunction.Call(ctyValues)
+	if err != nil && r.opts.RelaxedJSON && decoderName == "json" {
+		if relaxed, relaxedErr := JSONCDecodeFunc.Call(ctyValues[:1]); relaxedErr == nil {
+			status, value, err = NonStrict, relaxed, nil
+		}
+	}
+	if err != nil {
+		return decoded{status: DecodeFailed, err: err}
+	}
+	if decoderName == "jsonc" && !jsoncStandard(src) {
+		status = NonStrict
+	}
+	encrypted := sopsEncrypted(decoderName, value)
+	if encrypted && !r.opts.SOPSDecrypt {
+		finding := "sops encrypted, values not checked without decrypting"
+		if r.opts.severity(CategorySOPS, SeverityWarning) == SeverityError {
+			return decoded{status: Invalid, err: errors.New(finding)}
+		}
+		return decoded{status: status, warnings: []string{finding}}
+	}
+	if encrypted {
+		if src, err = decryptSOPS(decoderName, src); err != nil {
+			return decoded{status: DecodeFailed, err: fmt.Errorf("sops: %w", err)}
+		}
+		if value, err = decodeFunction.Call(append([]cty.Value{cty.StringVal(string(src))}, ctyValues[1:]...)); err != nil {
+			return decoded{status: DecodeFailed, err: fmt.Errorf("sops decrypted: %w", err)}
+		}
+		// Secrets Are What Sops Encrypts, So They Are Not Findings Here
+		checks = slices.DeleteFunc(slices.Clone(checks), func(rule CheckRule) bool { return rule.Category == "secrets" })
+	}
+	if r.opts.StrictKeys {
+		if err := duplicateKeys(decoderName, src); err != nil {
+			return decoded{status: DecodeFailed, err: err}
+		}
+	}
+	out := decoded{status: status, uniqueValues: uniqueValues(r.opts.Unique, value), terraform: terraformSummary(decoderName, src)}
+	if r.opts.TypeSignatures {
+		out.typeSignature = TypeSignature(value)
+	}
+	if r.opts.ValueHashes {
+		out.valueHash = ValueHash(value)
+	}
+	if r.opts.Query != nil {
+		out.queryResult, _ = r.opts.Query.lookupJSON(value)
+	}
+	var errs []error
+	if r.opts.Shapes || r.opts.ShapeLimits.enabled() {
+		measured := ShapeOf(value)
+		if r.opts.Shapes {
+			out.shape = &measured
+		}
+		if findings := r.opts.ShapeLimits.check(measured); len(findings) > 0 && r.opts.severity(CategoryShape, SeverityWarning) == SeverityError {
+			errs = append(errs, fmt.Errorf("shape: %s", strings.Join(findings, ", ")))
+		} else {
+			out.warnings = append(out.warnings, findings...)
+		}
+	}
+	if r.opts.CheckFormat && !encrypted {
+		if out.formatDiff = canonicalDiff(decoderName, name, src, value); out.formatDiff != "" {
+			finding := "not in canonical format, keys in order with two space indents"
+			if r.opts.severity(CategoryFormat, SeverityError) == SeverityWarning {
+				out.warnings = append(out.warnings, finding)
+			} else {
+				errs = append(errs, errors.New(finding))
+			}
+		}
+	}
+	if r.opts.LintYAML && contains(yamlLintDecoders, decoderName) {
+		lint := lintYAML(src)
+		if len(lint) > 0 && r.opts.severity(CategoryYAMLLint, SeverityWarning) == SeverityError {
+			errs = append(errs, fmt.Errorf("yaml lint:\n  %s", strings.Join(lint, "\n  ")))
+		} else {
+			out.warnings = append(out.warnings, lint...)
+		}
+	}
+	var findings []finding
+	if r.opts.CheckEmpty {
+		findings = append(findings, r.emptyFindings(decoderName, value)...)
+	}
+	if r.opts.TerragruntRefs && decoderName == "hcl" && isTerragruntConfig(name) {
+		findings = append(findings, terragruntFindings(name, src)...)
+	}
+	for _, finding := range findings {
+		if r.opts.severity(finding.category, finding.severity) == SeverityWarning {
+			out.warnings = append(out.warnings, finding.message)
+		} else {
+			errs = append(errs, errors.New(finding.message))
+		}
+	}
+	checkWarnings, err := r.runChecks(checks, decoderName, name, value)
+	out.warnings = append(out.warnings, checkWarnings...)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if r.opts.Snapshots != nil && !encrypted {
+		changes, err := r.opts.Snapshots.compare(name, value)
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("snapshot: %v", err))
+		case len(changes) > 0 && r.opts.severity(CategorySnapshot, SeverityError) == SeverityWarning:
+			out.warnings = append(out.warnings, snapshotFinding(changes))
+		case len(changes) > 0:
+			errs = append(errs, errors.New(snapshotFinding(changes)))
+		}
+	}
+	if len(errs) > 0 {
+		out.status, out.err = Invalid, errors.Join(errs...)
+		return out
+	}
+	if r.opts.Convert != "" && encrypted {
+		out.warnings = append(out.warnings, "not converted, sops encrypted")
+	} else if r.opts.Convert != "" && !value.IsNull() {
+		if out.converted, err = Convert(value, r.opts.Convert); err != nil {
+			out.warnings = append(out.warnings, fmt.Sprintf("cannot convert to %s: %v", r.opts.Convert, err))
+		}
+	}
+	return out
+}
+
+// cacheTag is Options.CacheTag plus the runner settings that change results
+// for decoderName.
+func (r *Runner) cacheTag(decoderName string) string {
+	tag := r.opts.CacheTag
+	if r.opts.RelaxedJSON && decoderName == "json" {
+		tag += " relaxed-json"
+	}
+	if _, ok := duplicateKeyDecoders[decoderName]; ok && r.opts.StrictKeys {
+		tag += " strict-keys"
+	}
+	if r.opts.LintYAML && contains(yamlLintDecoders, decoderName) {
+		tag += " lint-yaml"
+	}
+	if r.opts.StrictYAML && contains(yamlStrictDecoders, decoderName) {
+		tag += " yaml-strict"
+	}
+	if contains(yamlLimitDecoders, decoderName) {
+		tag += fmt.Sprintf(" yaml-limits=%d,%d", r.opts.MaxYAMLAliases, r.opts.MaxYAMLNodes)
+	}
+	if r.opts.LintText || r.opts.LintCRLF || r.opts.LintFinalNewline {
+		tag += fmt.Sprintf(" lint-text=%t,%t,%t", r.opts.LintText, r.opts.LintCRLF, r.opts.LintFinalNewline)
+	}
+	if r.opts.CheckEmpty {
+		tag += " check-empty"
+	}
+	if r.opts.TypeSignatures {
+		tag += " type-signatures"
+	}
+	if r.opts.ValueHashes {
+		tag += " value-hashes"
+	}
+	if _, ok := formatDecoders[decoderName]; ok && r.opts.CheckFormat {
+		tag += " check-format"
+	}
+	tag += uniqueTag(r.opts.Unique)
+	if r.opts.Query != nil {
+		tag += " query=" + r.opts.Query.Path
+	}
+	if r.opts.Shapes || r.opts.ShapeLimits.enabled() {
+		tag += fmt.Sprintf(" shape-limits=%d,%d,%d", r.opts.ShapeLimits.Keys, r.opts.ShapeLimits.Leaves, r.opts.ShapeLimits.Depth)
+	}
+	tag += r.opts.severitiesTag()
+	return tag
+}
+
+func contains(slice []string, item string) bool {
+	set := make(map[string]struct{}, len(slice))
+	for _, s := range slice {
+		set[s] = struct{}{}
+	}
+
+	_, ok := set[item]
+	return ok
+}
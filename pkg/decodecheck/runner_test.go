@@ -0,0 +1,206 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+// testTree is the tree the runner tests walk.
+var testTree = fstest.MapFS{
+	"a.json":                     {Data: []byte(`{"a": 1}`)},
+	"b.yaml":                     {Data: []byte("b: 2\n")},
+	"notes.txt":                  {Data: []byte("not matched\n")},
+	"empty.json":                 {Data: []byte{}},
+	"envs/prod/main.tf":          {Data: []byte("variable \"region\" {}\n")},
+	"envs/prod/bad.json":         {Data: []byte("{\n  \"a\": ,\n}\n")},
+	"envs/dev/values.yaml":       {Data: []byte("region: us-east-1\n")},
+	"envs/dev/scripts/run.json":  {Data: []byte(`{"run": true}`)},
+	".git/config.json":           {Data: []byte(`{}`)},
+	"modules/vpc/variables.json": {Data: []byte(`{"cidr": "10.0.0.0/16"}`)},
+}
+
+// runTree runs the default options, changed by setup, on testTree and
+// returns the report along with the paths OnSkip was called with by reason.
+func runTree(t *testing.T, setup func(*Options)) (*Report, map[string][]string) {
+	t.Helper()
+	var mu sync.Mutex
+	skipped := map[string][]string{}
+	opts := DefaultOptions()
+	opts.FS = testTree
+	opts.OnSkip = func(path, reason string) {
+		mu.Lock()
+		skipped[reason] = append(skipped[reason], path)
+		mu.Unlock()
+	}
+	if setup != nil {
+		setup(&opts)
+	}
+	report := NewRunner(opts).Run(context.Background())
+	if len(report.WalkErrors) > 0 {
+		t.Fatalf("walk errors: %v", report.WalkErrors)
+	}
+	return report, skipped
+}
+
+// resultPaths returns the sorted paths of the results in report.
+func resultPaths(report *Report) []string {
+	var paths []string
+	for _, result := range report.Results {
+		paths = append(paths, result.Path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// fromSlash converts each of paths to the separators results are shown with.
+func fromSlash(paths ...string) []string {
+	for i := range paths {
+		paths[i] = filepath.FromSlash(paths[i])
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func TestRunPaths(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(*Options)
+		want  []string
+	}{
+		{
+			name: "defaults",
+			want: fromSlash("a.json", "b.yaml", "envs/dev/values.yaml", "envs/prod/bad.json", "envs/prod/main.tf", "modules/vpc/variables.json"),
+		},
+		{
+			name: "match patterns",
+			setup: func(opts *Options) {
+				opts.MatchPatterns = []string{"*.yaml"}
+			},
+			want: fromSlash("b.yaml", "envs/dev/values.yaml"),
+		},
+		{
+			name: "match patterns with directories",
+			setup: func(opts *Options) {
+				opts.MatchPatterns = []string{"envs/**/*.json"}
+			},
+			want: fromSlash("envs/prod/bad.json"),
+		},
+		{
+			name: "exclude dirs",
+			setup: func(opts *Options) {
+				opts.ExcludeDirs = []string{"prod", "modules"}
+			},
+			want: fromSlash("a.json", "b.yaml", "envs/dev/scripts/run.json", "envs/dev/values.yaml", ".git/config.json"),
+		},
+		{
+			name: "exclude patterns",
+			setup: func(opts *Options) {
+				opts.ExcludePatterns = []string{"envs/**"}
+			},
+			want: fromSlash("a.json", "b.yaml", "modules/vpc/variables.json"),
+		},
+		{
+			name: "root below the top",
+			setup: func(opts *Options) {
+				opts.Roots = []string{"envs/prod"}
+			},
+			want: fromSlash("envs/prod/bad.json", "envs/prod/main.tf"),
+		},
+		{
+			name: "several roots",
+			setup: func(opts *Options) {
+				opts.Roots = []string{"envs/dev", "modules"}
+				opts.ExcludeDirs = nil
+			},
+			want: fromSlash("envs/dev/scripts/run.json", "envs/dev/values.yaml", "modules/vpc/variables.json"),
+		},
+		{
+			name: "check empty",
+			setup: func(opts *Options) {
+				opts.MatchPatterns = []string{"*.json"}
+				opts.ExcludeDirs = []string{"envs", "modules", ".git"}
+				opts.CheckEmpty = true
+			},
+			want: fromSlash("a.json", "empty.json"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, _ := runTree(t, tt.setup)
+			if got := resultPaths(report); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("paths = %q, want %q", got, tt.want)
+			}
+			if got := report.Counts.Files("total"); got != len(tt.want) {
+				t.Errorf("file count = %d, want %d", got, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestRunSkipsEmptyFiles(t *testing.T) {
+	report, skipped := runTree(t, nil)
+	if got, want := skipped["empty"], fromSlash("empty.json"); !reflect.DeepEqual(got, want) {
+		t.Errorf("skipped as empty = %q, want %q", got, want)
+	}
+	for _, result := range report.Results {
+		if result.Path == "empty.json" {
+			t.Errorf("empty.json was checked with status %v", result.Status)
+		}
+	}
+}
+
+func TestRunStatuses(t *testing.T) {
+	report, _ := runTree(t, nil)
+	var bytes int64
+	for _, result := range report.Results {
+		bytes += result.Size
+		want := Passed
+		if result.Path == filepath.FromSlash("envs/prod/bad.json") {
+			want = DecodeFailed
+		}
+		if result.Status != want {
+			t.Errorf("%s: status = %v (%v), want %v", result.Path, result.Status, result.Err, want)
+		}
+	}
+	if got := report.Counts.Errors("total"); got != 1 {
+		t.Errorf("error count = %d, want 1", got)
+	}
+	if got := report.Counts.Bytes(); got != bytes {
+		t.Errorf("bytes = %d, want %d, the sum of the result sizes", got, bytes)
+	}
+}
+
+func TestRunDecodeFailure(t *testing.T) {
+	report, _ := runTree(t, func(opts *Options) {
+		opts.Roots = []string{"envs/prod"}
+		opts.MatchPatterns = []string{"*.json"}
+	})
+	if len(report.Results) != 1 {
+		t.Fatalf("got %d results, want 1", len(report.Results))
+	}
+	result := report.Results[0]
+	if result.Status != DecodeFailed || result.Err == nil {
+		t.Fatalf("status = %v, err = %v, want a decode failure", result.Status, result.Err)
+	}
+	if !result.Failed() {
+		t.Error("Failed() = false for a decode failure")
+	}
+	if result.Type != "json" {
+		t.Errorf("type = %q, want json", result.Type)
+	}
+	if result.Line != 2 || result.Column == 0 {
+		t.Errorf("position = %d:%d, want line 2 with a column", result.Line, result.Column)
+	}
+	if msg := result.Err.Error(); strings.Contains(msg, "line 2") {
+		t.Errorf("error %q repeats the position", msg)
+	}
+}
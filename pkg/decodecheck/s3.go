@@ -0,0 +1,235 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3Scheme starts the roots that name an S3 bucket and prefix, like
+// s3://configs/envs/prod.
+const S3Scheme = "s3://"
+
+// ParseS3URL splits an s3:// URL into its bucket and the slash separated
+// prefix within it, "." for the whole bucket.
+func ParseS3URL(url string) (bucket, prefix string, err error) {
+	rest, ok := strings.CutPrefix(url, S3Scheme)
+	if !ok {
+		return "", "", fmt.Errorf("%s is not an %s URL", url, S3Scheme)
+	}
+	bucket, prefix, _ = strings.Cut(rest, "/")
+	if bucket == "" {
+		return "", "", fmt.Errorf("%s has no bucket", url)
+	}
+	prefix = path.Clean("/" + prefix)[1:]
+	if prefix == "" {
+		prefix = "."
+	}
+	return bucket, prefix, nil
+}
+
+// s3FS is an S3 bucket as a read only filesystem, with keys as paths and the
+// prefixes between slashes as directories.
+type s3FS struct {
+	ctx    context.Context
+	client *s3.Client
+	bucket string
+}
+
+// NewS3FS returns the S3 bucket as a filesystem, for Options.FS, using the
+// credentials and settings the AWS CLI would.   Directories are listed a page
+// at a time and each file is read with its own request, so Options.IOConcurrency
+// bounds the requests in flight.   An S3 compatible store can be used by setting
+// AWS_ENDPOINT_URL_S3, which is addressed path style.
+func NewS3FS(ctx context.Context, bucket string) (fs.FS, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loading AWS config: %v", err)
+	}
+	custom := os.Getenv("AWS_ENDPOINT_URL_S3") != "" || os.Getenv("AWS_ENDPOINT_URL") != ""
+	options := func(o *s3.Options) {
+		o.UsePathStyle = custom
+		o.DisableLogOutputChecksumValidationSkipped = true
+		if o.Region == "" {
+			o.Region = "us-east-1"
+		}
+	}
+	client := s3.NewFromConfig(cfg, options)
+
+	// Find The Bucket's Own Region, So Buckets Outside The Configured Region Can Be Read
+	if !custom {
+		region, err := manager.GetBucketRegion(ctx, client, bucket)
+		if err != nil {
+			return nil, fmt.Errorf("bucket %s: %v", bucket, err)
+		}
+		client = s3.NewFromConfig(cfg, options, func(o *s3.Options) { o.Region = region })
+	}
+	return &s3FS{ctx: ctx, client: client, bucket: bucket}, nil
+}
+
+// key returns the object key of the path name.
+func (f *s3FS) key(name string) string {
+	if name == "." {
+		return ""
+	}
+	return name
+}
+
+func (f *s3FS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	info, err := f.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return &s3File{info: info}, nil
+	}
+	data, err := f.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return &s3File{info: info, Reader: bytes.NewReader(data)}, nil
+}
+
+func (f *s3FS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) || name == "." {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+	out, err := f.client.GetObject(f.ctx, &s3.GetObjectInput{Bucket: &f.bucket, Key: aws.String(name)})
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: s3Error(err)}
+	}
+	defer out.Body.Close()
+	return io.ReadAll(out.Body)
+}
+
+func (f *s3FS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return s3Info{name: ".", dir: true}, nil
+	}
+	head, err := f.client.HeadObject(f.ctx, &s3.HeadObjectInput{Bucket: &f.bucket, Key: aws.String(name)})
+	if err == nil {
+		return s3Info{name: path.Base(name), size: aws.ToInt64(head.ContentLength), modTime: aws.ToTime(head.LastModified)}, nil
+	}
+	if err = s3Error(err); !errors.Is(err, fs.ErrNotExist) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+
+	// Prefixes Are Only Directories While Some Key Is Under Them
+	list, err := f.client.ListObjectsV2(f.ctx, &s3.ListObjectsV2Input{Bucket: &f.bucket, Prefix: aws.String(name + "/"), MaxKeys: aws.Int32(1)})
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: s3Error(err)}
+	}
+	if len(list.Contents) == 0 {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return s3Info{name: path.Base(name), dir: true}, nil
+}
+
+func (f *s3FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	prefix := ""
+	if name != "." {
+		prefix = name + "/"
+	}
+	var entries []fs.DirEntry
+	pages := s3.NewListObjectsV2Paginator(f.client, &s3.ListObjectsV2Input{Bucket: &f.bucket, Prefix: &prefix, Delimiter: aws.String("/")})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(f.ctx)
+		if err != nil {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: s3Error(err)}
+		}
+		for _, common := range page.CommonPrefixes {
+			if dir := strings.TrimSuffix(strings.TrimPrefix(aws.ToString(common.Prefix), prefix), "/"); dir != "" {
+				entries = append(entries, fs.FileInfoToDirEntry(s3Info{name: dir, dir: true}))
+			}
+		}
+		for _, object := range page.Contents {
+			// Skip The Empty Objects Consoles Create To Stand For Folders
+			if base := strings.TrimPrefix(aws.ToString(object.Key), prefix); base != "" && !strings.HasSuffix(base, "/") {
+				entries = append(entries, fs.FileInfoToDirEntry(s3Info{name: base, size: aws.ToInt64(object.Size), modTime: aws.ToTime(object.LastModified)}))
+			}
+		}
+	}
+	if len(entries) == 0 && name != "." {
+		if _, err := f.Stat(name); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// s3Error returns fs.ErrNotExist for missing keys and buckets, and err
+// otherwise.
+func s3Error(err error) error {
+	var noKey *types.NoSuchKey
+	var notFound *types.NotFound
+	var noBucket *types.NoSuchBucket
+	if errors.As(err, &noKey) || errors.As(err, &notFound) || errors.As(err, &noBucket) {
+		return fs.ErrNotExist
+	}
+	return err
+}
+
+// s3Info describes an object, or a prefix when dir is set.
+type s3Info struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func (i s3Info) Name() string       { return i.name }
+func (i s3Info) Size() int64        { return i.size }
+func (i s3Info) ModTime() time.Time { return i.modTime }
+func (i s3Info) IsDir() bool        { return i.dir }
+func (i s3Info) Sys() any           { return nil }
+
+func (i s3Info) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+// s3File is an open object, read whole, or an open prefix.
+type s3File struct {
+	info fs.FileInfo
+	*bytes.Reader
+}
+
+func (f *s3File) Stat() (fs.FileInfo, error) { return f.info, nil }
+
+func (f *s3File) Read(b []byte) (int, error) {
+	if f.Reader == nil {
+		return 0, &fs.PathError{Op: "read", Path: f.info.Name(), Err: errors.New("is a directory")}
+	}
+	return f.Reader.Read(b)
+}
+
+func (f *s3File) Close() error { return nil }
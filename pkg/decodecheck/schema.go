@@ -0,0 +1,178 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	ctyyaml "github.com/zclconf/go-cty-yaml"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// schemaPrinter formats schema violation messages.
+var schemaPrinter = message.NewPrinter(language.English)
+
+// SchemaRule returns a CheckRule validating the values of files matching
+// pattern against the JSON Schema in the file schemaPath, written in JSON or
+// YAML.   Each violation is reported with the JSON pointer of the value at fault.
+func SchemaRule(pattern, schemaPath string) (CheckRule, error) {
+	src, err := os.ReadFile(schemaPath)
+	if err != nil {
+		return CheckRule{}, err
+	}
+	doc, err := schemaDocument(schemaPath, src)
+	if err != nil {
+		return CheckRule{}, fmt.Errorf("schema %s: %v", schemaPath, err)
+	}
+
+	abs, err := filepath.Abs(schemaPath)
+	if err != nil {
+		return CheckRule{}, err
+	}
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(abs, doc); err != nil {
+		return CheckRule{}, fmt.Errorf("schema %s: %v", schemaPath, err)
+	}
+	schema, err := compiler.Compile(abs)
+	if err != nil {
+		return CheckRule{}, fmt.Errorf("schema %s: %v", schemaPath, err)
+	}
+
+	sum := sha256.Sum256(src)
+	return CheckRule{
+		Pattern:  pattern,
+		Category: "schema",
+		Name:     "schema=" + schemaPath + "@" + hex.EncodeToString(sum[:8]),
+		Check: func(path string, value cty.Value) error {
+			return validateSchema(schema, value)
+		},
+	}, nil
+}
+
+// schemaDocument parses a JSON Schema, as YAML when its file name says so.
+func schemaDocument(schemaPath string, src []byte) (any, error) {
+	ext := strings.ToLower(filepath.Ext(schemaPath))
+	if ext != ".yaml" && ext != ".yml" {
+		return jsonschema.UnmarshalJSON(bytes.NewReader(src))
+	}
+	value, err := ctyyaml.YAMLDecodeFunc.Call([]cty.Value{cty.StringVal(string(src))})
+	if err != nil {
+		return nil, err
+	}
+	return ctyToJSONValue(value)
+}
+
+// validateSchema validates value against schema, returning each violation on
+// its own line as "pointer: message", sorted by pointer.
+func validateSchema(schema *jsonschema.Schema, value cty.Value) error {
+	instance, err := ctyToJSONValue(value)
+	if err != nil {
+		return err
+	}
+	err = schema.Validate(instance)
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+
+	var violations schemaViolations
+	collectViolations(validationErr, &violations)
+	sort.Slice(violations, func(i, j int) bool {
+		return violations[i].text < violations[j].text
+	})
+	return violations
+}
+
+// schemaViolation is one violation of a JSON Schema, formatted as
+// "pointer: message", with the keyword that failed.
+type schemaViolation struct {
+	keyword string
+	text    string
+}
+
+// schemaViolations is the error listing every violation on its own line.   Each
+// violation is categorized by keyword, so schema/additionalProperties findings
+// can be warnings while the rest stay errors.
+type schemaViolations []schemaViolation
+
+func (v schemaViolations) Error() string {
+	lines := make([]string, len(v))
+	for i, violation := range v {
+		lines[i] = "  " + violation.text
+	}
+	return "schema violations:\n" + strings.Join(lines, "\n")
+}
+
+func (v schemaViolations) split(severity func(category string, fallback Severity) Severity) (errs, warnings error) {
+	var errViolations, warnViolations schemaViolations
+	for _, violation := range v {
+		if severity("schema/"+violation.keyword, SeverityError) == SeverityWarning {
+			warnViolations = append(warnViolations, violation)
+		} else {
+			errViolations = append(errViolations, violation)
+		}
+	}
+	if len(errViolations) > 0 {
+		errs = errViolations
+	}
+	if len(warnViolations) > 0 {
+		warnings = warnViolations
+	}
+	return errs, warnings
+}
+
+// collectViolations appends the leaf errors below err, the ones naming what is
+// actually wrong rather than which subschema failed.
+func collectViolations(err *jsonschema.ValidationError, violations *schemaViolations) {
+	if len(err.Causes) == 0 {
+		if _, ok := err.ErrorKind.(*kind.Group); ok {
+			return
+		}
+		pointer := "/" + strings.Join(escapePointer(err.InstanceLocation), "/")
+		keyword := ""
+		if path := err.ErrorKind.KeywordPath(); len(path) > 0 {
+			keyword = path[len(path)-1]
+		}
+		*violations = append(*violations, schemaViolation{
+			keyword: keyword,
+			text:    fmt.Sprintf("%s: %s", pointer, err.ErrorKind.LocalizedString(schemaPrinter)),
+		})
+		return
+	}
+	for _, cause := range err.Causes {
+		collectViolations(cause, violations)
+	}
+}
+
+// escapePointer escapes JSON pointer tokens, ~ as ~0 and / as ~1.
+func escapePointer(tokens []string) []string {
+	escaped := make([]string, len(tokens))
+	for i, token := range tokens {
+		escaped[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+	}
+	return escaped
+}
+
+// ctyToJSONValue converts value to the generic Go value its JSON decodes to,
+// with numbers as json.Number, the form the schema validator expects.
+func ctyToJSONValue(value cty.Value) (any, error) {
+	buf, err := ctyjson.Marshal(value, value.Type())
+	if err != nil {
+		return nil, err
+	}
+	return jsonschema.UnmarshalJSON(bytes.NewReader(buf))
+}
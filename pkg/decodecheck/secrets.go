@@ -0,0 +1,156 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// secretPatterns are the kinds of secret recognized by their form, checked in
+// order, each named by its category below secrets.
+var secretPatterns = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{"private-key", regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)},
+	{"aws-access-key", regexp.MustCompile(`\b(AKIA|ASIA|ABIA|ACCA)[A-Z0-9]{16}\b`)},
+	{"github-token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"slack-token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+}
+
+// secretWord matches the runs of characters random tokens are made of, which
+// are taken to be secrets when they mix letters and digits with enough
+// entropy.
+var secretWord = regexp.MustCompile(`[A-Za-z0-9+/=_-]{24,}`)
+
+// minSecretEntropy is the Shannon entropy in bits per character above which a
+// secretWord is taken to be random, above English words and hex hashes but
+// below most base64 keys and tokens.
+const minSecretEntropy = 4.2
+
+// SecretsRule returns a CheckRule failing the values of files matching pattern
+// holding strings that look like secrets: private keys, AWS access keys,
+// GitHub and Slack tokens and other long high-entropy strings, naming the path
+// to each but never the secret.   Each kind is a category of its own below
+// secrets, like secrets/high-entropy.
+func SecretsRule(pattern string) CheckRule {
+	return CheckRule{
+		Pattern:  pattern,
+		Category: "secrets",
+		Name:     "secrets",
+		Check: func(path string, value cty.Value) error {
+			var findings secretFindings
+			findSecrets(value, nil, &findings)
+			if len(findings) == 0 {
+				return nil
+			}
+			return findings
+		},
+	}
+}
+
+// secretFinding is a string that looks like a secret of kind at path.
+type secretFinding struct {
+	kind string
+	path string
+}
+
+// secretFindings are the secrets in one value, an error split by kind.
+type secretFindings []secretFinding
+
+func (f secretFindings) Error() string {
+	found := make([]string, len(f))
+	for i, finding := range f {
+		found[i] = finding.kind + " at " + finding.path
+	}
+	return "possible secrets: " + strings.Join(found, ", ")
+}
+
+func (f secretFindings) split(severity func(category string, fallback Severity) Severity) (errs, warnings error) {
+	var errFindings, warnFindings secretFindings
+	for _, finding := range f {
+		if severity("secrets/"+finding.kind, SeverityError) == SeverityWarning {
+			warnFindings = append(warnFindings, finding)
+		} else {
+			errFindings = append(errFindings, finding)
+		}
+	}
+	if len(errFindings) > 0 {
+		errs = errFindings
+	}
+	if len(warnFindings) > 0 {
+		warnings = warnFindings
+	}
+	return errs, warnings
+}
+
+// findSecrets appends the strings in value that look like secrets to findings,
+// at most one for each path.
+func findSecrets(value cty.Value, at []string, findings *secretFindings) {
+	if value.IsNull() || !value.IsKnown() {
+		return
+	}
+	ty := value.Type()
+	if ty == cty.String {
+		if kind := secretKind(value.AsString()); kind != "" {
+			*findings = append(*findings, secretFinding{kind, formatSteps(at)})
+		}
+		return
+	}
+	if !(ty.IsObjectType() || ty.IsMapType() || ty.IsListType() || ty.IsTupleType() || ty.IsSetType()) {
+		return
+	}
+	for it := value.ElementIterator(); it.Next(); {
+		key, element := it.Element()
+		var step string
+		switch {
+		case ty.IsSetType():
+			step = "[*]"
+		case ty.IsObjectType() || ty.IsMapType():
+			step = "." + key.AsString()
+		default:
+			index, _ := key.AsBigFloat().Int64()
+			step = "[" + strconv.FormatInt(index, 10) + "]"
+		}
+		findSecrets(element, append(at, step), findings)
+	}
+}
+
+// secretKind returns the kind of secret s looks like, or "" if none.
+func secretKind(s string) string {
+	for _, secret := range secretPatterns {
+		if secret.pattern.MatchString(s) {
+			return secret.kind
+		}
+	}
+	for _, word := range secretWord.FindAllString(s, -1) {
+		if strings.ContainsAny(word, "0123456789") && strings.IndexFunc(word, isLetter) >= 0 && entropy(word) >= minSecretEntropy {
+			return "high-entropy"
+		}
+	}
+	return ""
+}
+
+func isLetter(r rune) bool {
+	return r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z'
+}
+
+// entropy returns the Shannon entropy of s in bits per character.
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	var bits float64
+	for _, n := range counts {
+		p := float64(n) / float64(len(s))
+		bits -= p * math.Log2(p)
+	}
+	return bits
+}
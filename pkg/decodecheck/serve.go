@@ -0,0 +1,147 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// maxValidateBytes caps the size of a /validate request body.
+const maxValidateBytes = 10 << 20
+
+// contentTypeDecoders maps request media types to decoder names for /validate.
+var contentTypeDecoders = map[string]string{
+	"application/json":     "json",
+	"text/json":            "json",
+	"application/yaml":     "yaml",
+	"application/x-yaml":   "yaml",
+	"text/yaml":            "yaml",
+	"text/x-yaml":          "yaml",
+	"application/toml":     "toml",
+	"application/xml":      "xml",
+	"text/xml":             "xml",
+	"text/csv":             "csv",
+	"application/x-ndjson": "ndjson",
+	"application/jsonl":    "ndjson",
+}
+
+// NewHandler returns an HTTP handler serving decode checks over opts:
+//
+//	POST /validate   decodes the request body and responds with its Result.
+//	                 The decoder comes from the decoder query parameter, the
+//	                 Content-Type, or the decoder rules for the name parameter.
+//	                 Sources sent here cannot import files, whatever their name.
+//	POST /scan       walks the path query parameter (relative to the first of
+//	                 opts.Roots, it may not escape it) and responds with the Report.
+//	GET /metrics     serves opts.Metrics for Prometheus, when it is set.
+//
+// Passed results respond 200, decode failures 422 and requests naming no usable
+// decoder 415.
+func NewHandler(opts Options) http.Handler {
+	runner := NewRunner(opts)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /validate", func(w http.ResponseWriter, req *http.Request) {
+		src, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxValidateBytes))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		name := req.URL.Query().Get("name")
+		decoderName := req.URL.Query().Get("decoder")
+		if decoderName == "" {
+			decoderName = contentTypeDecoder(req.Header.Get("Content-Type"))
+		}
+		if decoderName == "" && name == "" {
+			http.Error(w, "no decoder: set the decoder or name parameter, or a known Content-Type", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		result, ok := runner.Validate(req.Context(), name, decoderName, src)
+		if !ok {
+			return // client went away
+		}
+		status := http.StatusOK
+		switch result.Status {
+		case NoDecoder:
+			status = http.StatusUnsupportedMediaType
+		case DecodeFailed, TooLarge, Invalid:
+			status = http.StatusUnprocessableEntity
+		}
+		writeJSON(w, status, result)
+	})
+	mux.HandleFunc("POST /scan", func(w http.ResponseWriter, req *http.Request) {
+		scanOpts, err := scanOptions(opts, req.URL.Query().Get("path"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		report := NewRunner(scanOpts).Run(req.Context())
+		report.Sort()
+		writeJSON(w, http.StatusOK, report)
+	})
+	if opts.Metrics != nil {
+		mux.Handle("GET /metrics", opts.Metrics.Handler())
+	}
+	return mux
+}
+
+// scanOptions returns opts rooted at dir under the first of opts.Roots, for
+// servers scanning on request.   dir may not escape the root, empty is the root.
+func scanOptions(opts Options, dir string) (Options, error) {
+	if dir == "" {
+		dir = "."
+	}
+	if !filepath.IsLocal(dir) {
+		return opts, errors.New("path must be relative and stay within the served root")
+	}
+	root := "."
+	if len(opts.Roots) > 0 {
+		root = opts.Roots[0]
+	}
+
+	opts.OnResult = nil
+	opts.Roots = []string{filepath.Join(root, dir)}
+	if opts.FS != nil {
+		opts.Roots[0] = filepath.ToSlash(opts.Roots[0])
+	}
+	return opts, nil
+}
+
+// contentTypeDecoder returns the decoder name for a Content-Type header, or ""
+// if it is missing or unknown.   Structured syntax suffixes like +json count.
+func contentTypeDecoder(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	if decoderName, ok := contentTypeDecoders[mediaType]; ok {
+		return decoderName
+	}
+	switch {
+	case strings.HasSuffix(mediaType, "+json"):
+		return "json"
+	case strings.HasSuffix(mediaType, "+yaml"):
+		return "yaml"
+	case strings.HasSuffix(mediaType, "+xml"):
+		return "xml"
+	}
+	return ""
+}
+
+// writeJSON responds with v encoded as JSON.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
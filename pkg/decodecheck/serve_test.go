@@ -0,0 +1,65 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateRefusesJsonnetImports(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "secret.txt")
+	if err := os.WriteFile(secret, []byte("secret-content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultOptions()
+	opts.Rules = append(opts.Rules, DecoderRule{Pattern: "*.jsonnet", Decoder: "jsonnet"})
+	handler := NewHandler(opts)
+
+	tests := []struct {
+		name  string
+		query url.Values
+		body  string
+	}{
+		{
+			name:  "absolute importstr",
+			query: url.Values{"decoder": {"jsonnet"}},
+			body:  `error importstr "` + filepath.ToSlash(secret) + `"`,
+		},
+		{
+			name:  "importstr next to the name",
+			query: url.Values{"decoder": {"jsonnet"}, "name": {filepath.Join(dir, "main.jsonnet")}},
+			body:  `error importstr "secret.txt"`,
+		},
+		{
+			name:  "import picked by the name",
+			query: url.Values{"name": {filepath.Join(dir, "main.jsonnet")}},
+			body:  `{ secret: importstr "secret.txt" }`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/validate?"+tt.query.Encode(), strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusUnprocessableEntity {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+			}
+			body := rec.Body.String()
+			if strings.Contains(body, "secret-content") {
+				t.Fatalf("response holds the imported file: %s", body)
+			}
+			if !strings.Contains(body, "imports are not allowed") {
+				t.Errorf("response %s does not say the import was refused", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severity is how a finding counts, as an error failing its file or as a
+// warning that is reported without failing it.
+type Severity int
+
+const (
+	// SeverityError findings fail their file.
+	SeverityError Severity = iota
+	// SeverityWarning findings are reported in Result.Warnings only.
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// MarshalText encodes s as its String form.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes error or warning.
+func (s *Severity) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "error":
+		*s = SeverityError
+	case "warning", "warn":
+		*s = SeverityWarning
+	default:
+		return fmt.Errorf("unknown severity %q, must be error or warning", text)
+	}
+	return nil
+}
+
+// Finding Categories Outside Of Check Rules, Whose Categories Are Their Own
+const (
+	// CategoryNoDecoder is files a match pattern selected but no decoder rule
+	// matched, by default an error.
+	CategoryNoDecoder = "no-decoder"
+	// CategoryYAMLLint is the findings of Options.LintYAML, by default warnings.
+	CategoryYAMLLint = "yaml-lint"
+	// CategoryShape is the findings of Options.ShapeLimits, by default
+	// warnings.
+	CategoryShape = "shape"
+	// CategoryUnique is the findings of Options.Unique, by default errors.
+	CategoryUnique = "unique"
+	// CategoryFormat is the findings of Options.CheckFormat, by default errors.
+	CategoryFormat = "format"
+	// CategorySnapshot is the findings of Options.Snapshots, by default errors.
+	CategorySnapshot = "snapshot"
+	// CategorySOPS is sops encrypted files whose values were not checked
+	// because SOPSDecrypt is not set, by default warnings.
+	CategorySOPS = "sops"
+	// CategoryEncoding is files in UTF-16, UTF-32 or with a byte order mark,
+	// by default warnings.
+	CategoryEncoding = "encoding"
+	// CategoryEmpty is files found by Options.CheckEmpty, in empty/file,
+	// empty/null and empty/collection, by default errors.
+	CategoryEmpty = "empty"
+	// CategoryTerragrunt is the files terragrunt configs load found by
+	// Options.TerragruntRefs, in terragrunt/missing and terragrunt/invalid,
+	// errors by default, and terragrunt/unresolved, warnings by default.
+	CategoryTerragrunt = "terragrunt"
+	// CategoryFilename is the files named against Options.FilenameRules, by
+	// default errors.
+	CategoryFilename = "filename"
+)
+
+// finding is a problem reported in its category, an error or a warning by
+// default.
+type finding struct {
+	category string
+	severity Severity
+	message  string
+}
+
+// severity returns the severity set for category in Options.Severities, or for
+// the category it belongs to, schema for schema/additionalProperties, falling
+// back to fallback.
+func (o *Options) severity(category string, fallback Severity) Severity {
+	if severity, ok := o.Severities[category]; ok {
+		return severity
+	}
+	if parent, _, ok := strings.Cut(category, "/"); ok {
+		if severity, ok := o.Severities[parent]; ok {
+			return severity
+		}
+	}
+	return fallback
+}
+
+// severitiesTag describes Options.Severities for cache keys.
+func (o *Options) severitiesTag() string {
+	if len(o.Severities) == 0 {
+		return ""
+	}
+	categories := make([]string, 0, len(o.Severities))
+	for category, severity := range o.Severities {
+		categories = append(categories, category+"="+severity.String())
+	}
+	sort.Strings(categories)
+	return " severities=" + strings.Join(categories, ",")
+}
+
+// splitError is a check error made of findings in categories of their own,
+// like the violations of a JSON Schema by keyword, so each can take its own
+// severity.
+type splitError interface {
+	error
+	// split returns the error made of the findings that are errors and the
+	// one made of the warnings, either nil when it would be empty.
+	split(severity func(category string, fallback Severity) Severity) (errs, warnings error)
+}
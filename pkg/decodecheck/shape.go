@@ -0,0 +1,72 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"fmt"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Shape measures how big a decoded document has grown.
+type Shape struct {
+	// Keys is the number of top level keys, 0 when the document is not an
+	// object or map.
+	Keys int `json:"keys"`
+	// Leaves is the number of values that are not collections, nulls included.
+	Leaves int `json:"leaves"`
+	// Depth is how deeply collections are nested, 0 for a lone scalar and 1 for
+	// an object of scalars.
+	Depth int `json:"depth"`
+}
+
+// ShapeLimits are the most keys, leaves and nesting depth a document may have
+// before Options.ShapeLimits reports it.   Zero or less disables a limit.
+type ShapeLimits struct {
+	Keys, Leaves, Depth int
+}
+
+func (l ShapeLimits) enabled() bool {
+	return l.Keys > 0 || l.Leaves > 0 || l.Depth > 0
+}
+
+// check describes each limit shape is over.
+func (l ShapeLimits) check(shape Shape) []string {
+	var findings []string
+	if l.Keys > 0 && shape.Keys > l.Keys {
+		findings = append(findings, fmt.Sprintf("%d top level keys, more than %d", shape.Keys, l.Keys))
+	}
+	if l.Leaves > 0 && shape.Leaves > l.Leaves {
+		findings = append(findings, fmt.Sprintf("%d leaf values, more than %d", shape.Leaves, l.Leaves))
+	}
+	if l.Depth > 0 && shape.Depth > l.Depth {
+		findings = append(findings, fmt.Sprintf("nested %d deep, more than %d", shape.Depth, l.Depth))
+	}
+	return findings
+}
+
+// ShapeOf measures value.   The values of stream decoders, like ndjson, are a
+// tuple of documents, so they count one level deeper than each document.
+func ShapeOf(value cty.Value) Shape {
+	var shape Shape
+	if ty := value.Type(); (ty.IsObjectType() || ty.IsMapType()) && value.IsKnown() && !value.IsNull() {
+		shape.Keys = value.LengthInt()
+	}
+	shape.Leaves, shape.Depth = measure(value)
+	return shape
+}
+
+// measure returns the leaf count and nesting depth of value.
+func measure(value cty.Value) (leaves, depth int) {
+	if !value.IsKnown() || value.IsNull() || !value.CanIterateElements() {
+		return 1, 0
+	}
+	for it := value.ElementIterator(); it.Next(); {
+		_, element := it.Element()
+		elementLeaves, elementDepth := measure(element)
+		leaves += elementLeaves
+		depth = max(depth, elementDepth)
+	}
+	return leaves, depth + 1
+}
@@ -0,0 +1,31 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+// SizeBuckets are the upper bounds, in bytes, of the buckets SizeHistogram
+// counts files into.   Files larger than the last go in one more bucket.
+var SizeBuckets = []int64{1e3, 10e3, 100e3, 1e6, 10e6}
+
+// SizeHistogram counts the report's files of each type, and "total", by size,
+// with one count per bucket of SizeBuckets and a last for larger files.
+func (rep *Report) SizeHistogram() map[string][]int {
+	histogram := map[string][]int{"total": make([]int, len(SizeBuckets)+1)}
+	for _, result := range rep.Results {
+		counts, ok := histogram[result.Type]
+		if !ok {
+			counts = make([]int, len(SizeBuckets)+1)
+			histogram[result.Type] = counts
+		}
+		bucket := len(SizeBuckets)
+		for i, limit := range SizeBuckets {
+			if result.Size <= limit {
+				bucket = i
+				break
+			}
+		}
+		counts[bucket]++
+		histogram["total"][bucket]++
+	}
+	return histogram
+}
@@ -0,0 +1,113 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// maxSnapshotChanges is how many changed paths a snapshot finding lists before
+// summing up the rest.
+const maxSnapshotChanges = 10
+
+// Snapshots stores the value each file decodes to as canonical JSON in a
+// directory, so later runs can report the paths whose values changed even when
+// the files were only reformatted.
+type Snapshots struct {
+	dir      string
+	update   bool
+	recorded atomic.Int64
+	updated  atomic.Int64
+}
+
+// NewSnapshots returns the Snapshots kept in dir.   Files without a snapshot
+// are recorded, and with update set every snapshot that differs is rewritten
+// rather than reported.
+func NewSnapshots(dir string, update bool) *Snapshots {
+	return &Snapshots{dir: dir, update: update}
+}
+
+// Recorded returns how many snapshots were written for files that had none.
+func (s *Snapshots) Recorded() int {
+	return int(s.recorded.Load())
+}
+
+// Updated returns how many snapshots that differed were rewritten.
+func (s *Snapshots) Updated() int {
+	return int(s.updated.Load())
+}
+
+// path returns where the snapshot of the file name is kept, its path under
+// the snapshot directory with .json added and any .. made __ so it stays
+// inside.
+func (s *Snapshots) path(name string) string {
+	parts := strings.Split(strings.TrimLeft(filepath.ToSlash(filepath.Clean(name)), "/"), "/")
+	for i, part := range parts {
+		if part == ".." {
+			parts[i] = "__"
+		}
+		parts[i] = strings.ReplaceAll(parts[i], ":", "_")
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(strings.Join(parts, "/"))+".json")
+}
+
+// compare checks value, decoded from the file name, against its snapshot,
+// returning the paths that were added, removed or changed since.   Missing
+// snapshots are recorded, and differing ones rewritten when updating.
+func (s *Snapshots) compare(name string, value cty.Value) ([]ValueChange, error) {
+	current, err := Convert(value, "json")
+	if err != nil {
+		return nil, err
+	}
+	snapshotPath := s.path(name)
+	previous, err := os.ReadFile(snapshotPath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		s.recorded.Add(1)
+		return nil, s.write(snapshotPath, current)
+	case err != nil:
+		return nil, err
+	case bytes.Equal(previous, current):
+		return nil, nil
+	}
+
+	changes, err := diffJSON(previous, current)
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot %s: %v", snapshotPath, err)
+	}
+	if s.update || len(changes) == 0 {
+		s.updated.Add(1)
+		return nil, s.write(snapshotPath, current)
+	}
+	return changes, nil
+}
+
+func (s *Snapshots) write(snapshotPath string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(snapshotPath), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(snapshotPath, data, 0o644)
+}
+
+// snapshotFinding sums up changes in one line, listing the first few.
+func snapshotFinding(changes []ValueChange) string {
+	described := make([]string, min(len(changes), maxSnapshotChanges))
+	for i := range described {
+		described[i] = changes[i].String()
+	}
+	finding := "changed since snapshot: " + strings.Join(described, ", ")
+	if len(changes) > maxSnapshotChanges {
+		finding += fmt.Sprintf(" and %d more", len(changes)-maxSnapshotChanges)
+	}
+	return finding
+}
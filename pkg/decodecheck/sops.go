@@ -0,0 +1,50 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"github.com/getsops/sops/v3/decrypt"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// sopsFormats are the sops formats of the files sops can encrypt, by decoder.
+var sopsFormats = map[string]string{
+	"json":        "json",
+	"tfvars-json": "json",
+	"yaml":        "yaml",
+	"ini":         "ini",
+}
+
+// sopsEncrypted reports whether value, decoded by decoderName, is a file
+// encrypted by sops, one with a top level sops block holding its mac.
+func sopsEncrypted(decoderName string, value cty.Value) bool {
+	if _, ok := sopsFormats[decoderName]; !ok {
+		return false
+	}
+	metadata, ok := element(value, "sops")
+	if !ok {
+		return false
+	}
+	_, ok = element(metadata, "mac")
+	return ok
+}
+
+// element returns the attribute or map element of value named key.
+func element(value cty.Value, key string) (cty.Value, bool) {
+	if !value.IsWhollyKnown() || value.IsNull() || !(value.Type().IsObjectType() || value.Type().IsMapType()) {
+		return cty.NilVal, false
+	}
+	for it := value.ElementIterator(); it.Next(); {
+		if k, v := it.Element(); k.AsString() == key {
+			return v, true
+		}
+	}
+	return cty.NilVal, false
+}
+
+// decryptSOPS decrypts src, a file decoded by decoderName that sops encrypted,
+// with the keys sops finds in the environment.
+func decryptSOPS(decoderName string, src []byte) ([]byte, error) {
+	return decrypt.Data(src, sopsFormats[decoderName])
+}
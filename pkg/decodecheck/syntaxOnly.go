@@ -0,0 +1,179 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+	"golang.org/x/text/transform"
+)
+
+// syntaxCheckers check the syntax of json and yaml files as they are read, for
+// files over Options.SyntaxOnlyAbove.   multiDoc allows more than one yaml
+// document, as the yaml decoder does when it is one of Options.StreamDecoders.
+var syntaxCheckers = map[string]func(reader io.Reader, multiDoc bool) error{
+	"json": checkJSONSyntax,
+	"yaml": checkYAMLSyntax,
+}
+
+// checkJSONSyntax reads a single JSON value a token at a time, so only the
+// longest string in it is ever held in memory.
+func checkJSONSyntax(reader io.Reader, _ bool) error {
+	dec := json.NewDecoder(reader)
+	dec.UseNumber()
+	depth := 0
+	for {
+		token, err := dec.Token()
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF // nothing but whitespace
+		}
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			break
+		}
+	}
+	end := dec.InputOffset()
+	if _, err := dec.Token(); err != io.EOF {
+		return &extraJSONError{offset: end}
+	}
+	return nil
+}
+
+// extraJSONError is more JSON after the value, which ends at byte offset.
+type extraJSONError struct {
+	offset int64
+}
+
+func (e *extraJSONError) Error() string {
+	return "extraneous data after JSON value"
+}
+
+// checkYAMLSyntax parses one yaml document at a time, so memory is bounded
+// by the largest document rather than the file.
+func checkYAMLSyntax(reader io.Reader, multiDoc bool) error {
+	dec := yaml.NewDecoder(reader)
+	for documents := 0; ; documents++ {
+		var node yaml.Node
+		err := dec.Decode(&node)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if documents > 0 && !multiDoc {
+			return fmt.Errorf("on line %d, column %d: unexpected extra content after value", node.Line, node.Column)
+		}
+	}
+}
+
+// syntaxOnlyDecode checks the syntax of name in root as it reads it, rather
+// than reading it into memory and decoding it to a value, for files over
+// Options.SyntaxOnlyAbove.   Only the syntax is checked, so the checks and
+// everything else done with decoded values are skipped.   A byte order mark
+// is handled as for other files, the bool is false if ctx was cancelled while
+// waiting for a decode token.
+func (r *Runner) syntaxOnlyDecode(ctx context.Context, root searchRoot, name string, result Result, check func(io.Reader, bool) error) (Result, bool) {
+	result.SyntaxOnly = true
+	if !acquire(ctx, r.decodeSema) {
+		return Result{}, false
+	}
+	defer release(r.decodeSema)
+
+	file, err := root.fsys.Open(name)
+	if err != nil {
+		result.Status = ReadFailed
+		result.Err = displayError(root, err)
+		return result, true
+	}
+	defer file.Close()
+
+	// Decode UTF-16 And UTF-32 As They Are Read, Like transcode Does For Whole Files
+	reader := bufio.NewReaderSize(file, 1<<16)
+	var encoding string
+	head, _ := reader.Peek(4)
+	for _, mark := range byteOrderMarks {
+		if bytes.HasPrefix(head, mark.bom) {
+			reader.Discard(len(mark.bom))
+			encoding = mark.name
+			if mark.encoding != nil {
+				reader = bufio.NewReaderSize(transform.NewReader(reader, mark.encoding.NewDecoder()), 1<<16)
+			}
+			break
+		}
+	}
+
+	start := time.Now()
+	err = check(reader, contains(r.opts.StreamDecoders, result.Type))
+	result.Duration = time.Since(start)
+	result.Status = Passed
+	if err != nil {
+		result.Status = DecodeFailed
+		result.Err = err
+		result.Line, result.Column = r.syntaxErrorPosition(root, name, err, encoding != "")
+		result.Err = withoutPosition(err, result.Line, result.Column)
+	}
+	if encoding != "" {
+		r.addFinding(&result, CategoryEncoding, SeverityWarning, "encoded as "+encoding+", checked as UTF-8")
+	}
+	return result, true
+}
+
+// syntaxErrorPosition returns the position of err, met checking the syntax of
+// name in root.   JSON errors only have a byte offset, so the file is read
+// again counting lines up to it, unless it was transcoded and the offset is not
+// one in the file.
+func (r *Runner) syntaxErrorPosition(root searchRoot, name string, err error, transcoded bool) (line, column int) {
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	var extraErr *extraJSONError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset - 1
+	case errors.As(err, &extraErr):
+		offset = extraErr.offset
+	default:
+		line, column, _ = errorPosition(err, nil)
+		return line, column
+	}
+	if transcoded {
+		return 0, 0
+	}
+	file, err := root.fsys.Open(name)
+	if err != nil {
+		return 0, 0
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	line, column = 1, 1
+	for ; offset > 0; offset-- {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return 0, 0
+		}
+		if b == '\n' {
+			line, column = line+1, 1
+		} else {
+			column++
+		}
+	}
+	return line, column
+}
@@ -0,0 +1,289 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// TerraformSummary describes a terraform state or plan file.
+type TerraformSummary struct {
+	// Kind is state or plan.
+	Kind string `json:"kind"`
+	// Version is the state version, or the plan format_version.
+	Version          string `json:"version"`
+	TerraformVersion string `json:"terraformVersion,omitempty"`
+	// Resources is the number of resources in a state, and Instances the
+	// number of their instances.
+	Resources int `json:"resources"`
+	Instances int `json:"instances,omitempty"`
+	// Changes counts the resource changes of a plan by action, like create or
+	// replace.
+	Changes map[string]int `json:"changes,omitempty"`
+}
+
+// String describes the summary in a line, like version 4, terraform 1.5.7, 12
+// resources, 14 instances.
+func (s TerraformSummary) String() string {
+	parts := []string{"version " + s.Version}
+	if s.TerraformVersion != "" {
+		parts = append(parts, "terraform "+s.TerraformVersion)
+	}
+	if s.Kind == "plan" {
+		actions := make([]string, 0, len(s.Changes))
+		for action := range s.Changes {
+			actions = append(actions, action)
+		}
+		sort.Strings(actions)
+		for _, action := range actions {
+			parts = append(parts, fmt.Sprintf("%d %s", s.Changes[action], action))
+		}
+		return strings.Join(parts, ", ")
+	}
+	return strings.Join(append(parts, fmt.Sprintf("%d resources, %d instances", s.Resources, s.Instances)), ", ")
+}
+
+// maxTerraformProblems is how many structural problems are reported for one
+// file.
+const maxTerraformProblems = 10
+
+// stateFile is the part of a terraform.tfstate file that is checked, state
+// version 4, or 3 with its modules.
+type stateFile struct {
+	Version          *int                       `json:"version"`
+	TerraformVersion string                     `json:"terraform_version"`
+	Serial           *int64                     `json:"serial"`
+	Lineage          string                     `json:"lineage"`
+	Outputs          map[string]json.RawMessage `json:"outputs"`
+	Resources        []stateResource            `json:"resources"`
+	Modules          []struct {
+		Path      []string                   `json:"path"`
+		Resources map[string]json.RawMessage `json:"resources"`
+	} `json:"modules"`
+}
+
+// stateResource is a resource of a version 4 state.
+type stateResource struct {
+	Module    string `json:"module"`
+	Mode      string `json:"mode"`
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	Provider  string `json:"provider"`
+	Instances []struct {
+		IndexKey       json.RawMessage `json:"index_key"`
+		Attributes     json.RawMessage `json:"attributes"`
+		AttributesFlat json.RawMessage `json:"attributes_flat"`
+	} `json:"instances"`
+}
+
+// planFile is the part of the JSON output of terraform show -json for a plan
+// that is checked.
+type planFile struct {
+	FormatVersion    string          `json:"format_version"`
+	TerraformVersion string          `json:"terraform_version"`
+	PlannedValues    json.RawMessage `json:"planned_values"`
+	ResourceChanges  []struct {
+		Address string `json:"address"`
+		Mode    string `json:"mode"`
+		Type    string `json:"type"`
+		Name    string `json:"name"`
+		Change  *struct {
+			Actions []string `json:"actions"`
+		} `json:"change"`
+	} `json:"resource_changes"`
+}
+
+// planActions are the actions a resource change can have.
+var planActions = map[string]bool{"no-op": true, "create": true, "read": true, "update": true, "delete": true, "forget": true}
+
+// TFStateDecodeFunc decodes terraform.tfstate files as JSON, failing ones that
+// are not state version 3 or 4 or whose structure terraform would reject, like
+// resources without a mode or instances without attributes.
+var TFStateDecodeFunc = terraformDecodeFunc(func(src []byte) (TerraformSummary, []string, error) {
+	return checkState(src)
+})
+
+// TFPlanDecodeFunc decodes the JSON terraform show -json prints for a plan,
+// failing ones whose format_version is not 1.x or whose resource changes are
+// malformed.
+var TFPlanDecodeFunc = terraformDecodeFunc(func(src []byte) (TerraformSummary, []string, error) {
+	return checkPlan(src)
+})
+
+// terraformDecodeFunc returns a decoder checking files with check and
+// decoding them as JSON.
+func terraformDecodeFunc(check func(src []byte) (TerraformSummary, []string, error)) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "src",
+				Type: cty.String,
+			},
+		},
+		Type: function.StaticReturnType(cty.DynamicPseudoType),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			value, err := stdlib.JSONDecode(args[0])
+			if err != nil {
+				return cty.NilVal, err
+			}
+			_, problems, err := check([]byte(args[0].AsString()))
+			if err != nil {
+				return cty.NilVal, err
+			}
+			if len(problems) > maxTerraformProblems {
+				problems = append(problems[:maxTerraformProblems], fmt.Sprintf("and %d more", len(problems)-maxTerraformProblems))
+			}
+			if len(problems) > 0 {
+				return cty.NilVal, errors.New(strings.Join(problems, "\n"))
+			}
+			return value, nil
+		},
+	})
+}
+
+// terraformSummary summarizes src, decoded by decoderName, or returns nil if
+// it is not a terraform state or plan.
+func terraformSummary(decoderName string, src []byte) *TerraformSummary {
+	check := map[string]func([]byte) (TerraformSummary, []string, error){"tfstate": checkState, "tfplan-json": checkPlan}[decoderName]
+	if check == nil {
+		return nil
+	}
+	summary, _, err := check(src)
+	if err != nil {
+		return nil
+	}
+	return &summary
+}
+
+// checkState summarizes the state in src with its structural problems.
+func checkState(src []byte) (TerraformSummary, []string, error) {
+	var state stateFile
+	if err := json.Unmarshal(src, &state); err != nil {
+		return TerraformSummary{}, nil, fmt.Errorf("not a terraform state: %v", err)
+	}
+	if state.Version == nil {
+		return TerraformSummary{}, nil, errors.New("not a terraform state: no version")
+	}
+	summary := TerraformSummary{Kind: "state", Version: fmt.Sprint(*state.Version), TerraformVersion: state.TerraformVersion}
+	var problems []string
+	if state.Serial == nil {
+		problems = append(problems, "no serial")
+	}
+	if state.Lineage == "" {
+		problems = append(problems, "no lineage")
+	}
+
+	switch *state.Version {
+	case 3:
+		if state.Modules == nil {
+			problems = append(problems, "version 3 state has no modules")
+		}
+		for _, module := range state.Modules {
+			summary.Resources += len(module.Resources)
+			summary.Instances += len(module.Resources)
+		}
+		return summary, problems, nil
+	case 4:
+	default:
+		return summary, []string{fmt.Sprintf("unsupported state version %d, expected 3 or 4", *state.Version)}, nil
+	}
+
+	if state.TerraformVersion == "" {
+		problems = append(problems, "no terraform_version")
+	}
+	names := make([]string, 0, len(state.Outputs))
+	for name := range state.Outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(state.Outputs[name], &fields); err != nil || fields["value"] == nil || fields["type"] == nil {
+			problems = append(problems, fmt.Sprintf("output %s: needs a value and a type", name))
+		}
+	}
+	seen := make(map[string]bool, len(state.Resources))
+	for i, resource := range state.Resources {
+		address := strings.TrimPrefix(resource.Module+".", ".") + resource.Type + "." + resource.Name
+		if resource.Mode == "data" {
+			address = strings.TrimPrefix(resource.Module+".", ".") + "data." + resource.Type + "." + resource.Name
+		}
+		at := fmt.Sprintf("resources[%d] %s", i, address)
+		switch {
+		case resource.Mode != "managed" && resource.Mode != "data":
+			problems = append(problems, fmt.Sprintf("%s: mode %q is not managed or data", at, resource.Mode))
+		case resource.Type == "" || resource.Name == "":
+			problems = append(problems, fmt.Sprintf("resources[%d]: needs a type and a name", i))
+		case resource.Provider == "":
+			problems = append(problems, at+": no provider")
+		case seen[address]:
+			problems = append(problems, at+": repeats a resource address")
+		}
+		seen[address] = true
+		for j, instance := range resource.Instances {
+			if instance.Attributes == nil && instance.AttributesFlat == nil {
+				problems = append(problems, fmt.Sprintf("%s: instances[%d] has no attributes", at, j))
+			}
+		}
+		summary.Resources++
+		summary.Instances += len(resource.Instances)
+	}
+	return summary, problems, nil
+}
+
+// checkPlan summarizes the plan in src with its structural problems.
+func checkPlan(src []byte) (TerraformSummary, []string, error) {
+	var plan planFile
+	if err := json.Unmarshal(src, &plan); err != nil {
+		return TerraformSummary{}, nil, fmt.Errorf("not a terraform plan: %v", err)
+	}
+	if plan.FormatVersion == "" {
+		return TerraformSummary{}, nil, errors.New("not a terraform plan: no format_version")
+	}
+	summary := TerraformSummary{Kind: "plan", Version: plan.FormatVersion, TerraformVersion: plan.TerraformVersion, Changes: map[string]int{}}
+	if major, _, _ := strings.Cut(plan.FormatVersion, "."); major != "1" {
+		return summary, []string{fmt.Sprintf("unsupported plan format_version %s, expected 1.x", plan.FormatVersion)}, nil
+	}
+	var problems []string
+	if plan.TerraformVersion == "" {
+		problems = append(problems, "no terraform_version")
+	}
+	if plan.PlannedValues == nil {
+		problems = append(problems, "no planned_values")
+	}
+	for i, change := range plan.ResourceChanges {
+		at := fmt.Sprintf("resource_changes[%d] %s", i, change.Address)
+		switch {
+		case change.Address == "" || change.Type == "" || change.Name == "":
+			problems = append(problems, fmt.Sprintf("resource_changes[%d]: needs an address, a type and a name", i))
+			continue
+		case change.Mode != "managed" && change.Mode != "data":
+			problems = append(problems, fmt.Sprintf("%s: mode %q is not managed or data", at, change.Mode))
+			continue
+		case change.Change == nil || len(change.Change.Actions) == 0:
+			problems = append(problems, at+": no change actions")
+			continue
+		}
+		for _, action := range change.Change.Actions {
+			if !planActions[action] {
+				problems = append(problems, fmt.Sprintf("%s: unknown action %q", at, action))
+			}
+		}
+		action := change.Change.Actions[0]
+		if len(change.Change.Actions) == 2 {
+			action = "replace"
+		}
+		summary.Changes[action]++
+		summary.Resources++
+	}
+	return summary, problems, nil
+}
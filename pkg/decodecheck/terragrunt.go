@@ -0,0 +1,228 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	ctyyaml "github.com/zclconf/go-cty-yaml"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// terragruntConfig is the name of the files Options.TerragruntRefs checks.
+const terragruntConfig = "terragrunt.hcl"
+
+// terragruntLoaders are the functions terragrunt loads files with, wrapping
+// file() or taking the path themselves, and the decoders they load them with.
+var terragruntLoaders = map[string]function.Function{
+	"yamldecode":             ctyyaml.YAMLDecodeFunc,
+	"jsondecode":             stdlib.JSONDecodeFunc,
+	"read_terragrunt_config": HCLDecodeFunc,
+}
+
+// isTerragruntConfig reports whether the file name is a terragrunt config.
+func isTerragruntConfig(name string) bool {
+	return path.Base(filepath.ToSlash(name)) == terragruntConfig
+}
+
+// terragruntRef is a file a terragrunt config loads, at the path expr
+// evaluates to.
+type terragruntRef struct {
+	expr     hcl.Expression
+	decode   function.Function
+	optional bool // guarded by try, can or fileexists
+}
+
+// terragruntFindings checks the files the terragrunt config name, holding src,
+// loads with yamldecode(file(...)), jsondecode(file(...)) and
+// read_terragrunt_config(...), returning a finding for each that is missing
+// or does not decode.   Paths are evaluated with terragrunt's path functions
+// and the locals they use, and ones that cannot be are reported as warnings.
+// Files loaded inside try or can, or whose path is checked with fileexists,
+// may be missing.
+func terragruntFindings(name string, src []byte) []finding {
+	file, diags := hclsyntax.ParseConfig(src, name, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil
+	}
+	body := file.Body.(*hclsyntax.Body)
+	dir, err := filepath.Abs(filepath.Dir(name))
+	if err != nil {
+		return nil
+	}
+	ctx := terragruntContext(dir, body)
+
+	var refs []terragruntRef
+	optional := map[*hclsyntax.FunctionCallExpr]bool{}
+	checked := map[string]bool{}
+	hclsyntax.VisitAll(body, func(node hclsyntax.Node) hcl.Diagnostics {
+		call, ok := node.(*hclsyntax.FunctionCallExpr)
+		if !ok || len(call.Args) == 0 {
+			return nil
+		}
+		switch call.Name {
+		case "try", "can":
+			for _, arg := range call.Args {
+				hclsyntax.VisitAll(arg, func(node hclsyntax.Node) hcl.Diagnostics {
+					if inner, ok := node.(*hclsyntax.FunctionCallExpr); ok {
+						optional[inner] = true
+					}
+					return nil
+				})
+			}
+		case "fileexists":
+			if value, diags := call.Args[0].Value(ctx); !diags.HasErrors() && value.Type() == cty.String && value.IsKnown() {
+				checked[resolveRef(dir, value.AsString())] = true
+			}
+		}
+		decode, ok := terragruntLoaders[call.Name]
+		if !ok {
+			return nil
+		}
+		expr := call.Args[0]
+		if call.Name != "read_terragrunt_config" {
+			inner, ok := expr.(*hclsyntax.FunctionCallExpr)
+			if !ok || inner.Name != "file" || len(inner.Args) == 0 {
+				return nil
+			}
+			expr = inner.Args[0]
+		}
+		refs = append(refs, terragruntRef{expr: expr, decode: decode, optional: optional[call]})
+		return nil
+	})
+
+	// Attributes Are Visited In No Particular Order, Report By Line
+	sort.SliceStable(refs, func(i, j int) bool {
+		return refs[i].expr.Range().Start.Byte < refs[j].expr.Range().Start.Byte
+	})
+	var findings []finding
+	for _, ref := range refs {
+		line := ref.expr.Range().Start.Line
+		value, diags := ref.expr.Value(ctx)
+		if diags.HasErrors() || !value.IsWhollyKnown() || value.Type() != cty.String {
+			findings = append(findings, finding{CategoryTerragrunt + "/unresolved", SeverityWarning, fmt.Sprintf("line %d: cannot resolve the file loaded here", line)})
+			continue
+		}
+		refPath := resolveRef(dir, value.AsString())
+		shown, err := filepath.Rel(dir, refPath)
+		if err != nil {
+			shown = refPath
+		}
+		refSrc, err := os.ReadFile(refPath)
+		switch {
+		case err != nil && (ref.optional || checked[refPath]):
+		case err != nil:
+			findings = append(findings, finding{CategoryTerragrunt + "/missing", SeverityError, fmt.Sprintf("line %d: %s does not exist", line, shown)})
+		default:
+			args := []cty.Value{cty.StringVal(string(refSrc))}
+			if ref.decode.VarParam() != nil {
+				args = append(args, cty.StringVal(shown))
+			}
+			if _, err := ref.decode.Call(args); err != nil {
+				findings = append(findings, finding{CategoryTerragrunt + "/invalid", SeverityError, fmt.Sprintf("line %d: %s does not decode: %v", line, shown, err)})
+			}
+		}
+	}
+	return findings
+}
+
+// resolveRef resolves a path loaded by the terragrunt config in dir, relative
+// to dir as terragrunt runs there.
+func resolveRef(dir, ref string) string {
+	if filepath.IsAbs(ref) {
+		return filepath.Clean(ref)
+	}
+	return filepath.Join(dir, ref)
+}
+
+// terragruntContext returns an evaluation context for the terragrunt config in
+// dir with body, with the path functions terragrunt provides and the locals
+// that can be evaluated with them.
+func terragruntContext(dir string, body *hclsyntax.Body) *hcl.EvalContext {
+	ctx := &hcl.EvalContext{
+		Functions: map[string]function.Function{
+			"get_terragrunt_dir": function.New(&function.Spec{
+				Type: function.StaticReturnType(cty.String),
+				Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+					return cty.StringVal(dir), nil
+				},
+			}),
+			// find_in_parent_folders Returns The Path In The Nearest Parent When Nothing Is Found, So It Is Reported Missing
+			"find_in_parent_folders": function.New(&function.Spec{
+				VarParam: &function.Parameter{Name: "args", Type: cty.String},
+				Type:     function.StaticReturnType(cty.String),
+				Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+					name := terragruntConfig
+					if len(args) > 0 {
+						name = args[0].AsString()
+					}
+					for parent := filepath.Dir(dir); ; parent = filepath.Dir(parent) {
+						if _, err := os.Stat(filepath.Join(parent, name)); err == nil {
+							return cty.StringVal(filepath.Join(parent, name)), nil
+						}
+						if parent == filepath.Dir(parent) {
+							break
+						}
+					}
+					if len(args) > 1 {
+						return args[1], nil
+					}
+					return cty.StringVal(filepath.Join(filepath.Dir(dir), name)), nil
+				},
+			}),
+			"get_env": function.New(&function.Spec{
+				Params:   []function.Parameter{{Name: "name", Type: cty.String}},
+				VarParam: &function.Parameter{Name: "default", Type: cty.String},
+				Type:     function.StaticReturnType(cty.String),
+				Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+					if value, ok := os.LookupEnv(args[0].AsString()); ok {
+						return cty.StringVal(value), nil
+					}
+					if len(args) > 1 {
+						return args[1], nil
+					}
+					return cty.UnknownVal(cty.String), nil
+				},
+			}),
+			"format": stdlib.FormatFunc,
+			"join":   stdlib.JoinFunc,
+			"lower":  stdlib.LowerFunc,
+			"upper":  stdlib.UpperFunc,
+		},
+	}
+
+	// Evaluate Locals In Passes, Since They Can Refer To Each Other In Any Order
+	var attrs []*hclsyntax.Attribute
+	for _, block := range body.Blocks {
+		if block.Type == "locals" {
+			for _, attr := range block.Body.Attributes {
+				attrs = append(attrs, attr)
+			}
+		}
+	}
+	locals := map[string]cty.Value{}
+	for progress := true; progress; {
+		progress = false
+		ctx.Variables = map[string]cty.Value{"local": cty.ObjectVal(locals)}
+		for _, attr := range attrs {
+			if _, done := locals[attr.Name]; done {
+				continue
+			}
+			if value, diags := attr.Expr.Value(ctx); !diags.HasErrors() && value.IsWhollyKnown() {
+				locals[attr.Name] = value
+				progress = true
+			}
+		}
+	}
+	ctx.Variables = map[string]cty.Value{"local": cty.ObjectVal(locals)}
+	return ctx
+}
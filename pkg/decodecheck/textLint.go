@@ -0,0 +1,54 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+)
+
+// lintText returns the invalid UTF-8 and NUL bytes in src when utf8Checks is
+// set, and its CRLF line endings and a missing final newline when crlf and
+// finalNewline are, each with the position of the first.   Invalid UTF-8 and
+// NUL bytes are errors by default, the others warnings.
+func lintText(src []byte, utf8Checks, crlf, finalNewline bool) []finding {
+	var findings []finding
+	if utf8Checks {
+		if offset := invalidUTF8(src); offset >= 0 {
+			findings = append(findings, finding{"text/invalid-utf8", SeverityError, "invalid UTF-8 " + atOffset(src, offset)})
+		}
+		if offset := bytes.IndexByte(src, 0); offset >= 0 {
+			findings = append(findings, finding{"text/nul-byte", SeverityError, "NUL byte " + atOffset(src, offset)})
+		}
+	}
+	if crlf {
+		if n := bytes.Count(src, []byte("\r\n")); n > 0 {
+			findings = append(findings, finding{"text/crlf", SeverityWarning, fmt.Sprintf("%d CRLF line endings, the first %s", n, atOffset(src, bytes.Index(src, []byte("\r\n"))))})
+		}
+	}
+	if finalNewline && len(src) > 0 && src[len(src)-1] != '\n' {
+		findings = append(findings, finding{"text/final-newline", SeverityWarning, "no newline at end of file"})
+	}
+	return findings
+}
+
+// invalidUTF8 returns the offset of the first byte of src that is not valid
+// UTF-8, or -1.
+func invalidUTF8(src []byte) int {
+	for offset := 0; offset < len(src); {
+		r, size := utf8.DecodeRune(src[offset:])
+		if r == utf8.RuneError && size == 1 {
+			return offset
+		}
+		offset += size
+	}
+	return -1
+}
+
+// atOffset describes where the byte at offset is in src.
+func atOffset(src []byte, offset int) string {
+	line, column, _ := offsetPosition(src, int64(offset))
+	return fmt.Sprintf("at line %d, column %d (byte %d)", line, column, offset)
+}
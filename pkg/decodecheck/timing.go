@@ -0,0 +1,78 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"sort"
+	"time"
+)
+
+// Timing summarizes how long the files of a Report took to decode.   Only
+// files decoded this run count, not cached results or files never decoded.
+type Timing struct {
+	// Files is the number of files timed.
+	Files int
+	// Total is the time spent decoding them all, across goroutines.
+	Total time.Duration
+	// P50 and P95 are the median and 95th percentile decode times, and Max
+	// the longest.
+	P50, P95, Max time.Duration
+}
+
+// Timing returns the decode time statistics of the report's results.
+func (rep *Report) Timing() Timing {
+	var durations []time.Duration
+	var timing Timing
+	for _, result := range rep.Results {
+		if result.Duration > 0 {
+			durations = append(durations, result.Duration)
+			timing.Total += result.Duration
+		}
+	}
+	if len(durations) == 0 {
+		return timing
+	}
+	sort.Slice(durations, func(i, j int) bool {
+		return durations[i] < durations[j]
+	})
+	timing.Files = len(durations)
+	timing.P50 = percentile(durations, 50)
+	timing.P95 = percentile(durations, 95)
+	timing.Max = durations[len(durations)-1]
+	return timing
+}
+
+// percentile returns the nearest rank p percentile of the sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
+
+// Slowest returns the n results that took longest to decode, slowest first.
+func (rep *Report) Slowest(n int) []Result {
+	var timed []Result
+	for _, result := range rep.Results {
+		if result.Duration > 0 {
+			timed = append(timed, result)
+		}
+	}
+	sort.SliceStable(timed, func(i, j int) bool {
+		return timed[i].Duration > timed[j].Duration
+	})
+	return timed[:min(n, len(timed))]
+}
+
+// timingJSON is the Timing in a JSON report, in milliseconds.
+type timingJSON struct {
+	Files   int     `json:"files"`
+	TotalMs float64 `json:"totalMs"`
+	P50Ms   float64 `json:"p50Ms"`
+	P95Ms   float64 `json:"p95Ms"`
+	MaxMs   float64 `json:"maxMs"`
+}
+
+// milliseconds returns d in fractional milliseconds, for JSON reports.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,29 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"github.com/BurntSushi/toml"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// TOMLDecodeFunc decodes a TOML document into the same object/tuple cty values
+// jsondecode and yamldecode produce.
+var TOMLDecodeFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "src",
+			Type: cty.String,
+		},
+	},
+	Type: function.StaticReturnType(cty.DynamicPseudoType),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		var doc map[string]interface{}
+		if _, err := toml.Decode(args[0].AsString(), &doc); err != nil {
+			return cty.NilVal, err
+		}
+		return goToCty(doc)
+	},
+})
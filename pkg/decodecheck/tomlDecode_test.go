@@ -0,0 +1,71 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestTOMLDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    cty.Value
+		wantErr string
+	}{
+		{
+			name: "numbers",
+			src:  "int = 1\nfloat = 2.5\n",
+			want: cty.ObjectVal(map[string]cty.Value{"int": cty.NumberIntVal(1), "float": cty.NumberFloatVal(2.5)}),
+		},
+		{
+			name:    "nan",
+			src:     "x = nan\n",
+			wantErr: "x: NaN is not representable as a number",
+		},
+		{
+			name:    "inf",
+			src:     "y = inf\n",
+			wantErr: "y: +Inf is not representable as a number",
+		},
+		{
+			name:    "positive inf",
+			src:     "y = +inf\n",
+			wantErr: "y: +Inf is not representable as a number",
+		},
+		{
+			name:    "negative inf",
+			src:     "z = -inf\n",
+			wantErr: "z: -Inf is not representable as a number",
+		},
+		{
+			name:    "nested",
+			src:     "[limits]\nmax = -nan\n",
+			wantErr: "limits: max: NaN is not representable as a number",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TOMLDecodeFunc.Call([]cty.Value{cty.StringVal(tt.src)})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if strings.Contains(err.Error(), "panic") {
+					t.Fatalf("err = %v, the decoder panicked", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.RawEquals(tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
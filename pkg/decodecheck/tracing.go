@@ -0,0 +1,39 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer starts the spans of scans, from the global OpenTelemetry tracer
+// provider, so they cost nothing until the embedding program sets one up:
+//
+//	scan              Runner.Run, over all the roots
+//	walk directory    listing one directory, parent of the spans below it
+//	open archive      reading an archive, with Options.Archives
+//	check file        one file, from being found to its Result
+//	decode            decoding and checking it, after waiting for a token
+var tracer = otel.Tracer("github.com/JasonPodgorny/terraformDecodeTest/pkg/decodecheck")
+
+// endFileSpan records the outcome of result on span and ends it.
+func endFileSpan(span trace.Span, result Result) {
+	span.SetAttributes(
+		attribute.String("decodetest.type", result.Type),
+		attribute.String("decodetest.status", result.Status.String()),
+		attribute.Int64("decodetest.size", result.Size),
+		attribute.Bool("decodetest.cached", result.Cached),
+	)
+	if result.Failed() {
+		message := result.Status.String()
+		if result.Err != nil {
+			message = result.Err.Error()
+		}
+		span.SetStatus(codes.Error, message)
+	}
+	span.End()
+}
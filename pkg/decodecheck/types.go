@@ -0,0 +1,68 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// TypeRule returns a CheckRule requiring the values of files matching pattern
+// to convert to the Terraform type constraint typeExpr, like
+// map(object({cidr=string, azs=list(string)})), the way Terraform converts
+// variable values.   Optional attributes and their defaults are supported.
+func TypeRule(pattern, typeExpr string) (CheckRule, error) {
+	ty, defaults, err := ParseType(typeExpr)
+	if err != nil {
+		return CheckRule{}, err
+	}
+	return CheckRule{
+		Pattern:  pattern,
+		Category: "type",
+		Name:     "type=" + typeexpr.TypeString(ty),
+		Check: func(path string, value cty.Value) error {
+			return conformsTo(value, ty, defaults, typeExpr)
+		},
+	}, nil
+}
+
+// TypeSignature returns the type of value as a Terraform type expression, like
+// object({regions=tuple([string]),tags=object({env=string})}), to compare
+// with the type of the variable it is meant for.   YAML and JSON sequences and
+// maps decode to tuples and objects, which Terraform converts to lists and maps.
+func TypeSignature(value cty.Value) string {
+	return typeexpr.TypeString(value.Type())
+}
+
+// ParseType parses a Terraform type constraint expression, returning the type
+// and the defaults of any optional attributes.
+func ParseType(typeExpr string) (cty.Type, *typeexpr.Defaults, error) {
+	expr, diags := hclsyntax.ParseExpression([]byte(typeExpr), "type", hcl.InitialPos)
+	if diags.HasErrors() {
+		return cty.NilType, nil, fmt.Errorf("invalid type %q: %v", typeExpr, diagsError(diags))
+	}
+	ty, defaults, diags := typeexpr.TypeConstraintWithDefaults(expr)
+	if diags.HasErrors() {
+		return cty.NilType, nil, fmt.Errorf("invalid type %q: %v", typeExpr, diagsError(diags))
+	}
+	return ty, defaults, nil
+}
+
+// conformsTo converts value to ty after applying defaults, returning the
+// conversion error, which names the element or attribute at fault, against
+// typeExpr.
+func conformsTo(value cty.Value, ty cty.Type, defaults *typeexpr.Defaults, typeExpr string) error {
+	if defaults != nil {
+		value = defaults.Apply(value)
+	}
+	if _, err := convert.Convert(value, ty); err != nil {
+		return fmt.Errorf("does not conform to %s: %v", typeExpr, err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// uniqueValues returns the value at each of paths in value, normalized to JSON,
+// leaving out the paths it does not have.
+func uniqueValues(paths []ValuePath, value cty.Value) map[string]string {
+	var values map[string]string
+	for _, p := range paths {
+		normalized, ok := p.lookupJSON(value)
+		if !ok {
+			continue
+		}
+		if values == nil {
+			values = make(map[string]string, len(paths))
+		}
+		values[p.Path] = normalized
+	}
+	return values
+}
+
+// uniqueTag describes paths for cache keys.
+func uniqueTag(paths []ValuePath) string {
+	if len(paths) == 0 {
+		return ""
+	}
+	exprs := make([]string, len(paths))
+	for i, p := range paths {
+		exprs[i] = p.Path
+	}
+	return " unique=" + strings.Join(exprs, ",")
+}
+
+// applyUnique fails result when it has the same value at one of Options.Unique
+// as a file checked before it, naming that file, or warns about it when
+// Options.Severities makes the unique category a warning.   seen maps each key
+// and value to the first file that had it.
+func (r *Runner) applyUnique(seen map[string]string, result *Result) {
+	var errs []error
+	for _, key := range r.opts.Unique {
+		value, ok := result.UniqueValues[key.Path]
+		if !ok {
+			continue
+		}
+		id := key.Path + "\x00" + value
+		first, dup := seen[id]
+		if !dup {
+			seen[id] = result.Path
+			continue
+		}
+		err := fmt.Errorf("%s %s is also declared in %s", key.Path, value, first)
+		if r.opts.severity(CategoryUnique, SeverityError) == SeverityWarning {
+			result.Warnings = append(result.Warnings, err.Error())
+		} else {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return
+	}
+	if result.Status == Invalid {
+		errs = append([]error{result.Err}, errs...)
+	}
+	result.Status, result.Err = Invalid, errors.Join(errs...)
+}
@@ -0,0 +1,165 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// variable is a variable block declared by a Terraform module.
+type variable struct {
+	ty       cty.Type
+	defaults *typeexpr.Defaults
+	required bool // no default
+	nullable bool
+}
+
+// moduleSchema and variableSchema pick out variable blocks and the attributes checked in them.
+var (
+	moduleSchema = &hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{{Type: "variable", LabelNames: []string{"name"}}},
+	}
+	variableSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "type"}, {Name: "default"}, {Name: "nullable"}},
+	}
+)
+
+// VariablesRule returns a CheckRule treating the values of files matching
+// pattern as inputs to the Terraform module in moduleDir.   Each value must be
+// an object whose attributes are declared by the module's variable blocks and
+// convert to their types, and every variable without a default must be set.
+func VariablesRule(pattern, moduleDir string) (CheckRule, error) {
+	variables, hash, err := readVariables(moduleDir)
+	if err != nil {
+		return CheckRule{}, err
+	}
+	return CheckRule{
+		Pattern:  pattern,
+		Category: "module-vars",
+		Name:     "variables=" + moduleDir + "@" + hash,
+		Check: func(path string, value cty.Value) error {
+			return checkInputs(variables, moduleDir, value)
+		},
+	}, nil
+}
+
+// readVariables parses the variable blocks of the *.tf and *.tf.json files in
+// moduleDir, returning them by name with a hash of the files read.
+func readVariables(moduleDir string) (map[string]variable, string, error) {
+	names, err := filepath.Glob(filepath.Join(moduleDir, "*.tf"))
+	if err != nil {
+		return nil, "", err
+	}
+	jsonNames, _ := filepath.Glob(filepath.Join(moduleDir, "*.tf.json"))
+	names = append(names, jsonNames...)
+	if len(names) == 0 {
+		return nil, "", fmt.Errorf("no terraform files in module %s", moduleDir)
+	}
+
+	hash := sha256.New()
+	parser := hclparse.NewParser()
+	variables := make(map[string]variable)
+	for _, name := range names {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			return nil, "", err
+		}
+		hash.Write(src)
+
+		var file *hcl.File
+		var diags hcl.Diagnostics
+		if strings.HasSuffix(name, ".json") {
+			file, diags = parser.ParseJSON(src, name)
+		} else {
+			file, diags = parser.ParseHCL(src, name)
+		}
+		if diags.HasErrors() {
+			return nil, "", fmt.Errorf("%s: %v", name, diagsError(diags))
+		}
+
+		content, _, diags := file.Body.PartialContent(moduleSchema)
+		if diags.HasErrors() {
+			return nil, "", fmt.Errorf("%s: %v", name, diagsError(diags))
+		}
+		for _, block := range content.Blocks {
+			v, err := readVariable(block)
+			if err != nil {
+				return nil, "", fmt.Errorf("%s: variable %q: %v", name, block.Labels[0], err)
+			}
+			variables[block.Labels[0]] = v
+		}
+	}
+	return variables, hex.EncodeToString(hash.Sum(nil)[:8]), nil
+}
+
+// readVariable reads the type, default and nullable attributes of a variable
+// block, which default to any, required and nullable as in Terraform.
+func readVariable(block *hcl.Block) (variable, error) {
+	v := variable{ty: cty.DynamicPseudoType, required: true, nullable: true}
+	content, _, diags := block.Body.PartialContent(variableSchema)
+	if diags.HasErrors() {
+		return v, diagsError(diags)
+	}
+	if attr, ok := content.Attributes["type"]; ok {
+		if v.ty, v.defaults, diags = typeexpr.TypeConstraintWithDefaults(attr.Expr); diags.HasErrors() {
+			return v, diagsError(diags)
+		}
+	}
+	if _, ok := content.Attributes["default"]; ok {
+		v.required = false
+	}
+	if attr, ok := content.Attributes["nullable"]; ok {
+		nullable, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return v, diagsError(diags)
+		}
+		v.nullable = nullable.Type() != cty.Bool || nullable.IsNull() || nullable.True()
+	}
+	return v, nil
+}
+
+// checkInputs checks value sets the variables of moduleDir, returning every
+// problem found on its own line.
+func checkInputs(variables map[string]variable, moduleDir string, value cty.Value) error {
+	if !value.Type().IsObjectType() && !value.Type().IsMapType() {
+		return fmt.Errorf("expected an object of variable values for module %s, got %s", moduleDir, value.Type().FriendlyName())
+	}
+
+	var problems []string
+	inputs := value.AsValueMap()
+	for name, input := range inputs {
+		v, ok := variables[name]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("  %s: variable is not declared", name))
+		case input.IsNull() && !v.nullable:
+			problems = append(problems, fmt.Sprintf("  %s: variable is not nullable", name))
+		case !input.IsNull():
+			if err := conformsTo(input, v.ty, v.defaults, typeexpr.TypeString(v.ty)); err != nil {
+				problems = append(problems, fmt.Sprintf("  %s: %v", name, err))
+			}
+		}
+	}
+	for name, v := range variables {
+		if _, ok := inputs[name]; !ok && v.required {
+			problems = append(problems, fmt.Sprintf("  %s: required variable is not set", name))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return fmt.Errorf("inputs do not match the variables of module %s:\n%s", moduleDir, strings.Join(problems, "\n"))
+}
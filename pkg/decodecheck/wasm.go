@@ -0,0 +1,189 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// wasmTimeout is how long a WASM validator may take over one value.
+const wasmTimeout = 10 * time.Second
+
+// wasmMemoryPages caps the memory of a WASM validator, in 64KiB pages.
+const wasmMemoryPages = 4096
+
+// WASMInput is what a WASM validator reads from stdin, the value a file
+// decoded to with the file's path.
+type WASMInput struct {
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// WASMOutput is what a WASM validator writes to stdout.   The value passes
+// when Findings is empty.
+type WASMOutput struct {
+	Findings []WASMFinding `json:"findings"`
+}
+
+// WASMFinding is one problem a WASM validator found in a value, at Path within
+// it when it has one.   Rule, if given, categorizes the finding as wasm/rule,
+// so each rule of a validator can take its own severity.
+type WASMFinding struct {
+	Message string `json:"message"`
+	Path    string `json:"path,omitempty"`
+	Rule    string `json:"rule,omitempty"`
+}
+
+// WASMRule returns a CheckRule running the WebAssembly module in modulePath on
+// the values of files matching pattern.   The module is a WASI command, run
+// once per value with a WASMInput on stdin, which writes a WASMOutput to
+// stdout.   It is sandboxed: it sees no files, environment or network, its
+// memory is capped and it is stopped after wasmTimeout, so validators can be
+// shared as .wasm files and run on untrusted inputs.
+func WASMRule(pattern, modulePath string) (CheckRule, error) {
+	src, err := os.ReadFile(modulePath)
+	if err != nil {
+		return CheckRule{}, err
+	}
+
+	ctx := context.Background()
+	runtime := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(wasmMemoryPages).
+		WithCloseOnContextDone(true))
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		runtime.Close(ctx)
+		return CheckRule{}, err
+	}
+	module, err := runtime.CompileModule(ctx, src)
+	if err != nil {
+		runtime.Close(ctx)
+		return CheckRule{}, fmt.Errorf("wasm %s: %v", modulePath, err)
+	}
+
+	name := filepath.Base(modulePath)
+	sum := sha256.Sum256(src)
+	return CheckRule{
+		Pattern:  pattern,
+		Category: "wasm",
+		Name:     "wasm=" + modulePath + "@" + hex.EncodeToString(sum[:8]),
+		Check: func(path string, value cty.Value) error {
+			encoded, err := ctyjson.Marshal(value, value.Type())
+			if err != nil {
+				return err
+			}
+			input, err := json.Marshal(WASMInput{Path: path, Value: encoded})
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), wasmTimeout)
+			defer cancel()
+			var stdout, stderr bytes.Buffer
+			config := wazero.NewModuleConfig().
+				WithName("").
+				WithArgs(name).
+				WithStdin(bytes.NewReader(input)).
+				WithStdout(&stdout).
+				WithStderr(&stderr)
+			instance, runErr := runtime.InstantiateModule(ctx, module, config)
+			if instance != nil {
+				instance.Close(ctx)
+			}
+
+			var exitErr *sys.ExitError
+			if errors.As(runErr, &exitErr) && exitErr.ExitCode() == 0 {
+				runErr = nil
+			}
+			if runErr != nil && ctx.Err() != nil {
+				return fmt.Errorf("wasm %s: timed out after %v", name, wasmTimeout)
+			}
+			if runErr != nil && len(bytes.TrimSpace(stdout.Bytes())) == 0 {
+				message := strings.TrimSpace(stderr.String())
+				if message == "" {
+					message = runErr.Error()
+				}
+				return fmt.Errorf("wasm %s failed: %s", name, message)
+			}
+
+			var output WASMOutput
+			if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+				return fmt.Errorf("wasm %s wrote invalid output: %w", name, err)
+			}
+			if len(output.Findings) == 0 {
+				return nil
+			}
+			findings := wasmFindings{name: name, findings: output.Findings}
+			sort.SliceStable(findings.findings, func(i, j int) bool {
+				return findings.findings[i].Path < findings.findings[j].Path
+			})
+			return findings
+		},
+	}, nil
+}
+
+// wasmFindings is the error listing every finding of a WASM validator on its
+// own line.   Findings with a rule are categorized as wasm/rule, so some can be
+// warnings while the rest stay errors.
+type wasmFindings struct {
+	name     string
+	findings []WASMFinding
+}
+
+func (f wasmFindings) Error() string {
+	lines := make([]string, len(f.findings))
+	for i, finding := range f.findings {
+		lines[i] = "  " + finding.String()
+	}
+	return f.name + " findings:\n" + strings.Join(lines, "\n")
+}
+
+func (f wasmFindings) split(severity func(category string, fallback Severity) Severity) (errs, warnings error) {
+	errFindings, warnFindings := wasmFindings{name: f.name}, wasmFindings{name: f.name}
+	for _, finding := range f.findings {
+		category := "wasm"
+		if finding.Rule != "" {
+			category += "/" + finding.Rule
+		}
+		if severity(category, SeverityError) == SeverityWarning {
+			warnFindings.findings = append(warnFindings.findings, finding)
+		} else {
+			errFindings.findings = append(errFindings.findings, finding)
+		}
+	}
+	if len(errFindings.findings) > 0 {
+		errs = errFindings
+	}
+	if len(warnFindings.findings) > 0 {
+		warnings = warnFindings
+	}
+	return errs, warnings
+}
+
+func (f WASMFinding) String() string {
+	text := f.Message
+	if f.Path != "" {
+		text = f.Path + ": " + text
+	}
+	if f.Rule != "" {
+		text += " (" + f.Rule + ")"
+	}
+	return text
+}
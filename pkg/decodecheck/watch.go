@@ -0,0 +1,156 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchSettle is how long a file must go without further events before it is
+// decoded, so editors that save in several writes are only checked once.
+const watchSettle = 100 * time.Millisecond
+
+// Watch watches the Roots for created or modified files until ctx is cancelled,
+// decoding each matching file once it settles and passing its Result to
+// OnResult.   New directories are watched as they appear, excluded ones never.
+// Watching needs OS roots and returns an error if Options.FS is set.
+func (r *Runner) Watch(ctx context.Context) error {
+	if r.opts.FS != nil {
+		return errors.New("watch needs OS roots, it cannot watch Options.FS")
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	for _, root := range r.opts.Roots {
+		if err := r.watchTree(watcher, root, nil); err != nil {
+			return err
+		}
+	}
+
+	// Files Waiting To Settle, By Path, With The Time Of Their Last Event
+	pending := make(map[string]time.Time)
+	ticker := time.NewTicker(watchSettle / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			switch {
+			case event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename):
+				delete(pending, event.Name)
+			case event.Has(fsnotify.Create) || event.Has(fsnotify.Write):
+				info, err := os.Stat(event.Name)
+				if err != nil {
+					continue
+				}
+				if info.IsDir() {
+					// Watch New Directories, And Check Files Already Written Into Them
+					if event.Has(fsnotify.Create) && !r.excludedDir(info.Name()) {
+						if err := r.watchTree(watcher, event.Name, pending); err != nil {
+							r.reportError(err)
+						}
+					}
+				} else {
+					pending[event.Name] = time.Now() // checkPath applies the patterns
+				}
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			r.reportError(err)
+
+		case now := <-ticker.C:
+			for name, last := range pending {
+				if now.Sub(last) < watchSettle {
+					continue
+				}
+				delete(pending, name)
+				if result, ok := r.checkPath(ctx, name); ok {
+					r.applyBaseline(&result)
+					r.observe(result)
+					if r.opts.OnResult != nil {
+						r.opts.OnResult(result)
+					}
+				}
+			}
+		}
+	}
+}
+
+// watchTree adds watches for dir and every directory below it that is not
+// excluded.   If pending is not nil, files found are queued in it.
+func (r *Runner) watchTree(watcher *fsnotify.Watcher, dir string, pending map[string]time.Time) error {
+	return filepath.WalkDir(dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			r.reportError(err)
+			return nil
+		}
+		if entry.IsDir() {
+			if name != dir && r.excludedDir(entry.Name()) {
+				return filepath.SkipDir
+			}
+			return watcher.Add(name)
+		}
+		if name == dir {
+			return watcher.Add(name) // a root that is a file
+		}
+		if pending != nil {
+			pending[name] = time.Now()
+		}
+		return nil
+	})
+}
+
+// checkPath decodes the OS path name, found under one of the Roots, skipping it
+// if it is empty, gone, unmatched, excluded or ignored.   The bool is false when there is no Result.
+func (r *Runner) checkPath(ctx context.Context, name string) (Result, bool) {
+	info, err := os.Stat(name)
+	if err != nil || info.IsDir() || r.skipReason(info) != "" {
+		return Result{}, false
+	}
+	for _, root := range r.opts.Roots {
+		rel, err := filepath.Rel(root, name)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if rel == "." {
+			// Roots That Are Files Are Always Checked
+			dir := filepath.Dir(root)
+			listed := searchRoot{fsys: os.DirFS(dir), prefix: dir, listed: true}
+			result, ok := r.fileDecode(ctx, listed, filepath.Base(root))
+			r.applyPathRules(listed.rel(filepath.Base(root)), &result)
+			return result, ok
+		}
+		searchRoot, name := searchRoot{fsys: os.DirFS(root), prefix: root}, filepath.ToSlash(rel)
+		match, ok := r.matching(name)
+		if !ok || r.excluded(name) || r.pathIgnored(searchRoot, name) {
+			return Result{}, false
+		}
+		result, ok := r.fileDecode(ctx, searchRoot, name)
+		r.applyPathRules(name, &result)
+		result.Match = match
+		return result, ok
+	}
+	return Result{}, false
+}
@@ -0,0 +1,131 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// XMLDecodeFunc decodes an XML document into cty values so it can be checked
+// like the JSON and YAML inputs.   The result is an object holding the root
+// element, where each element becomes:
+//
+//   - a string of its text, when it has no attributes or child elements
+//   - otherwise an object with "@name" keys for attributes, a "#text" key for
+//     any text content, and a key per child element name, holding a tuple when
+//     that child element is repeated
+var XMLDecodeFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "src",
+			Type: cty.String,
+		},
+	},
+	Type: function.StaticReturnType(cty.DynamicPseudoType),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		root, err := parseXML(args[0].AsString())
+		if err != nil {
+			return cty.NilVal, err
+		}
+		return cty.ObjectVal(map[string]cty.Value{
+			root.name: root.value(),
+		}), nil
+	},
+})
+
+// xmlElement is an element of a parsed XML document.
+type xmlElement struct {
+	name     string
+	attrs    []xml.Attr
+	children []*xmlElement
+	text     strings.Builder
+}
+
+// parseXML reads src into a tree of elements, requiring exactly one root element.
+func parseXML(src string) (*xmlElement, error) {
+	decoder := xml.NewDecoder(strings.NewReader(src))
+
+	var root *xmlElement
+	var stack []*xmlElement
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var syntaxErr *xml.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				return nil, fmt.Errorf("on line %d: %s", syntaxErr.Line, syntaxErr.Msg)
+			}
+			return nil, err
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			elem := &xmlElement{name: token.Name.Local, attrs: token.Attr}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, elem)
+			} else if root != nil {
+				line, _ := decoder.InputPos()
+				return nil, fmt.Errorf("on line %d: unexpected second root element <%s>", line, elem.name)
+			} else {
+				root = elem
+			}
+			stack = append(stack, elem)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(token)
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, errors.New("no root element found")
+	}
+	return root, nil
+}
+
+// value converts the element into its cty representation.
+func (e *xmlElement) value() cty.Value {
+	text := strings.TrimSpace(e.text.String())
+	if len(e.attrs) == 0 && len(e.children) == 0 {
+		return cty.StringVal(text)
+	}
+
+	attrs := map[string]cty.Value{}
+	for _, attr := range e.attrs {
+		attrs["@"+attr.Name.Local] = cty.StringVal(attr.Value)
+	}
+	if text != "" {
+		attrs["#text"] = cty.StringVal(text)
+	}
+
+	// Group Children By Name, Keeping Document Order Within Each Group
+	var names []string
+	groups := map[string][]cty.Value{}
+	for _, child := range e.children {
+		if _, ok := groups[child.name]; !ok {
+			names = append(names, child.name)
+		}
+		groups[child.name] = append(groups[child.name], child.value())
+	}
+	for _, name := range names {
+		if len(groups[name]) == 1 {
+			attrs[name] = groups[name][0]
+		} else {
+			attrs[name] = cty.TupleVal(groups[name])
+		}
+	}
+	return cty.ObjectVal(attrs)
+}
@@ -0,0 +1,81 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"bytes"
+	"fmt"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// yamlLimitDecoders are the decoders Options.MaxYAMLAliases and
+// Options.MaxYAMLNodes apply to.
+var yamlLimitDecoders = []string{"yaml", "yaml-stream"}
+
+// yamlLimits counts the aliases in a YAML document and the nodes it expands
+// to, failing as soon as either limit is passed.   Zero disables a limit.
+type yamlLimits struct {
+	maxAliases, maxNodes int
+	aliases, nodes       int
+	sizes                map[*yaml.Node]int // expanded size of each anchored node
+}
+
+// checkYAMLExpansion parses src without expanding aliases and returns an error
+// if expanding them would pass either limit, before the decoder expands them
+// for real.   Files without an alias are not parsed at all.
+func checkYAMLExpansion(src []byte, maxAliases, maxNodes int) error {
+	if (maxAliases <= 0 && maxNodes <= 0) || !bytes.ContainsRune(src, '*') {
+		return nil
+	}
+	limits := &yamlLimits{maxAliases: maxAliases, maxNodes: maxNodes, sizes: make(map[*yaml.Node]int)}
+	dec := yaml.NewDecoder(bytes.NewReader(src))
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); err != nil {
+			// Syntax Errors Are Left To The Decoder
+			return nil
+		}
+		if _, err := limits.size(&doc); err != nil {
+			return err
+		}
+	}
+}
+
+// size returns the number of nodes node expands to, adding them to the total.
+func (l *yamlLimits) size(node *yaml.Node) (int, error) {
+	if node.Kind == yaml.AliasNode {
+		l.aliases++
+		if l.maxAliases > 0 && l.aliases > l.maxAliases {
+			return 0, fmt.Errorf("expansion limit exceeded: more than %d aliases", l.maxAliases)
+		}
+		size, ok := l.sizes[node.Alias]
+		if !ok {
+			// An Alias To An Enclosing Node Is Left To The Decoder
+			return 1, nil
+		}
+		l.nodes += size
+		if l.maxNodes > 0 && l.nodes > l.maxNodes {
+			return 0, fmt.Errorf("expansion limit exceeded: aliases expand to more than %d nodes", l.maxNodes)
+		}
+		return size, nil
+	}
+
+	l.nodes++
+	if l.maxNodes > 0 && l.nodes > l.maxNodes {
+		return 0, fmt.Errorf("expansion limit exceeded: aliases expand to more than %d nodes", l.maxNodes)
+	}
+	size := 1
+	for _, child := range node.Content {
+		childSize, err := l.size(child)
+		if err != nil {
+			return 0, err
+		}
+		size += childSize
+	}
+	if node.Anchor != "" {
+		l.sizes[node] = size
+	}
+	return size, nil
+}
@@ -0,0 +1,79 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// yamlLintDecoders are the decoders Options.LintYAML applies to.
+var yamlLintDecoders = []string{"yaml", "yaml-stream"}
+
+// yamlBools are the YAML 1.1 booleans other than true and false, which the
+// YAML decoder still reads as booleans.
+var yamlBools = map[string]bool{
+	"y": true, "Y": true, "yes": true, "Yes": true, "YES": true, "on": true, "On": true, "ON": true,
+	"n": false, "N": false, "no": false, "No": false, "NO": false, "off": false, "Off": false, "OFF": false,
+}
+
+// Patterns For Plain Scalars The YAML Decoder Reads As Something Else
+var (
+	trailingZeroPattern  = regexp.MustCompile(`^[-+]?[0-9]+\.[0-9]*0$`)
+	leadingZeroPattern   = regexp.MustCompile(`^[-+]?0[0-9_]+$`)
+	radixPattern         = regexp.MustCompile(`^[-+]?0[xXoObB][0-9a-fA-F_]+$`)
+	sexagesimalPattern   = regexp.MustCompile(`^[-+]?[0-9][0-9_]*(:[0-5]?[0-9])+(\.[0-9_]*)?$`)
+	yamlTimestampPattern = regexp.MustCompile(`^[0-9]{4}-[0-9]{1,2}-[0-9]{1,2}([Tt ].*)?$`)
+)
+
+// lintYAML returns a warning, with its position, for each plain scalar in src
+// that is read as a different type than it most likely means, like no as false,
+// 1.10 as 1.1 or 0123 as 123.   Quoting the value silences the warning.
+func lintYAML(src []byte) []string {
+	var warnings []string
+	dec := yaml.NewDecoder(bytes.NewReader(src))
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); err != nil {
+			return warnings
+		}
+		lintYAMLNode(&doc, &warnings)
+	}
+}
+
+// lintYAMLNode appends the warnings for node and the nodes below it.
+func lintYAMLNode(node *yaml.Node, warnings *[]string) {
+	if node.Kind == yaml.ScalarNode && node.Style == 0 {
+		if reason := yamlAmbiguity(node.Value); reason != "" {
+			*warnings = append(*warnings, fmt.Sprintf("line %d, column %d: unquoted %s %s, quote it if a string is meant", node.Line, node.Column, node.Value, reason))
+		}
+	}
+	for _, child := range node.Content {
+		lintYAMLNode(child, warnings)
+	}
+}
+
+// yamlAmbiguity describes how the plain scalar value is misread, or returns ""
+// if it is read the way it looks.
+func yamlAmbiguity(value string) string {
+	if b, ok := yamlBools[value]; ok {
+		return fmt.Sprintf("is read as the boolean %t", b)
+	}
+	switch {
+	case trailingZeroPattern.MatchString(value):
+		return "is read as a number, dropping the trailing zero"
+	case leadingZeroPattern.MatchString(value):
+		return "is read as a number, dropping the leading zero"
+	case radixPattern.MatchString(value):
+		return "is read as a hexadecimal, octal or binary number"
+	case sexagesimalPattern.MatchString(value):
+		return "is read as a base 60 number by YAML 1.1 parsers"
+	case yamlTimestampPattern.MatchString(value):
+		return "is read as a timestamp"
+	}
+	return ""
+}
@@ -0,0 +1,98 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"fmt"
+	"strings"
+
+	ctyyaml "github.com/zclconf/go-cty-yaml"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// YAMLStreamDecodeFunc decodes a YAML stream that may hold several "---"
+// separated documents, as Kubernetes style manifests do.   Each document goes
+// through yamldecode on its own and the result is a tuple of the documents.
+// Errors name the failing document and keep line numbers relative to the file.
+var YAMLStreamDecodeFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "src",
+			Type: cty.String,
+		},
+	},
+	Type: function.StaticReturnType(cty.DynamicPseudoType),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		var docs []cty.Value
+		for i, doc := range splitYAMLDocuments(args[0].AsString()) {
+			// Pad With Newlines So Decoder Line Numbers Match The Original File
+			src := strings.Repeat("\n", doc.line-1) + doc.src
+			val, err := ctyyaml.YAMLDecodeFunc.Call([]cty.Value{cty.StringVal(src)})
+			if err != nil {
+				return cty.NilVal, fmt.Errorf("document %d (line %d): %v", i+1, doc.line, err)
+			}
+			docs = append(docs, val)
+		}
+		return cty.TupleVal(docs), nil
+	},
+})
+
+// yamlDocument is one document of a YAML stream and the line it starts on.
+type yamlDocument struct {
+	line int
+	src  string
+}
+
+// splitYAMLDocuments splits src on "---" and "..." document markers.   Leading
+// directives and comments stay with the document that follows them, and
+// documents with no content at all, like a bare "---" or one holding only
+// comments, are dropped.
+func splitYAMLDocuments(src string) []yamlDocument {
+	var docs []yamlDocument
+	lines := strings.SplitAfter(src, "\n")
+
+	start := 0
+	content, marked := false, false
+	flush := func(end int) {
+		if content {
+			docs = append(docs, yamlDocument{line: start + 1, src: strings.Join(lines[start:end], "")})
+		}
+		start = end
+		content, marked = false, false
+	}
+
+	for i, line := range lines {
+		trimmed := strings.TrimRight(line, "\r\n")
+		switch {
+		case isYAMLMarker(trimmed, "---"):
+			// Only Split Once The Current Document Has Content Of Its Own, Dropping It If A Bare Marker Left It Empty
+			if content {
+				flush(i)
+			} else if marked {
+				start = i
+			}
+			inline := strings.TrimSpace(trimmed[len("---"):])
+			content, marked = inline != "" && !strings.HasPrefix(inline, "#"), true
+		case isYAMLMarker(trimmed, "..."):
+			flush(i + 1)
+		case !content && (strings.TrimSpace(trimmed) == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "%")):
+			// Directives, Comments And Blank Lines Before A Document
+		default:
+			content = true
+		}
+	}
+	flush(len(lines))
+	return docs
+}
+
+// isYAMLMarker reports whether line is the document marker followed by nothing
+// but whitespace or inline content.
+func isYAMLMarker(line string, marker string) bool {
+	if !strings.HasPrefix(line, marker) {
+		return false
+	}
+	rest := line[len(marker):]
+	return rest == "" || rest[0] == ' ' || rest[0] == '\t'
+}
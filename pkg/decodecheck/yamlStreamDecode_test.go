@@ -0,0 +1,54 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitYAMLDocuments(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []yamlDocument
+	}{
+		{name: "empty", src: "", want: nil},
+		{name: "single", src: "a: 1\n", want: []yamlDocument{{1, "a: 1\n"}}},
+		{name: "bare marker", src: "---\n", want: nil},
+		{name: "bare markers", src: "---\n---\n# nothing\n---\n", want: nil},
+		{
+			name: "two documents",
+			src:  "---\na: 1\n---\nb: 2\n",
+			want: []yamlDocument{{1, "---\na: 1\n"}, {3, "---\nb: 2\n"}},
+		},
+		{
+			name: "empty document between",
+			src:  "a: 1\n---\n---\nb: 2\n",
+			want: []yamlDocument{{1, "a: 1\n"}, {3, "---\nb: 2\n"}},
+		},
+		{
+			name: "trailing bare marker",
+			src:  "a: 1\n---\n",
+			want: []yamlDocument{{1, "a: 1\n"}},
+		},
+		{
+			name: "inline content",
+			src:  "--- 1\n--- # comment\n",
+			want: []yamlDocument{{1, "--- 1\n"}},
+		},
+		{
+			name: "directives stay with their document",
+			src:  "%YAML 1.2\n# header\n---\na: 1\n...\n",
+			want: []yamlDocument{{1, "%YAML 1.2\n# header\n---\na: 1\n...\n"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitYAMLDocuments(tt.src); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitYAMLDocuments(%q) = %+v, want %+v", tt.src, got, tt.want)
+			}
+		})
+	}
+}
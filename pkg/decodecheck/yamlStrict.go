@@ -0,0 +1,94 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package decodecheck
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// yamlStrictDecoders are the decoders Options.StrictYAML applies to.
+var yamlStrictDecoders = []string{"yaml", "yaml-stream"}
+
+// yamlCoreTags are the tags of the YAML 1.2 core schema, the only ones a file
+// may give explicitly with StrictYAML.
+var yamlCoreTags = map[string]bool{
+	"!!str": true, "!!int": true, "!!float": true, "!!bool": true, "!!null": true, "!!map": true, "!!seq": true,
+}
+
+// Patterns For Plain Scalars The YAML Decoder Reads Differently Than The Core Schema
+var (
+	underscoreNumberPattern = regexp.MustCompile(`^[-+]?(0o[0-7_]+|0x[0-9a-fA-F_]+|[0-9][0-9_]*)$`)
+	signedRadixPattern      = regexp.MustCompile(`^[-+]0(x[0-9a-fA-F_]+|o[0-7_]+)$`)
+)
+
+// strictYAML returns an error for each part of the documents in src that the
+// YAML 1.2 core schema would not read the way the YAML decoder does: explicit
+// tags outside the core schema, << merge keys, and plain scalars the decoder
+// reads with YAML 1.1 rules, like yes as true, 1_000 as 1000 or 2020-09-03 as
+// a timestamp, or that YAML 1.1 parsers read as base 60 numbers, like 1:30.
+// Syntax errors are left to the decoder.
+func strictYAML(src []byte) error {
+	var errs []error
+	dec := yaml.NewDecoder(bytes.NewReader(src))
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); err != nil {
+			return errors.Join(errs...)
+		}
+		strictYAMLNode(&doc, &errs)
+	}
+}
+
+// strictYAMLNode appends the errors for node and the nodes below it.
+func strictYAMLNode(node *yaml.Node, errs *[]error) {
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, fmt.Errorf("line %d, column %d: %s", node.Line, node.Column, fmt.Sprintf(format, args...)))
+	}
+	switch {
+	case node.Style&yaml.TaggedStyle != 0 && !yamlCoreTags[node.Tag]:
+		fail("tag %s is not in the YAML 1.2 core schema", node.Tag)
+	case node.Kind == yaml.ScalarNode && node.Style == 0:
+		if reason := yamlCoreMismatch(node.Value); reason != "" {
+			fail("unquoted %s %s, quote it if a string is meant", node.Value, reason)
+		}
+	}
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i < len(node.Content); i += 2 {
+			if key := node.Content[i]; key.Kind == yaml.ScalarNode && key.Style == 0 && key.Value == "<<" {
+				*errs = append(*errs, fmt.Errorf("line %d, column %d: merge key << is YAML 1.1, not in the YAML 1.2 core schema", key.Line, key.Column))
+			}
+		}
+	}
+	for _, child := range node.Content {
+		if child.Kind == yaml.ScalarNode && child.Style == 0 && child.Value == "<<" {
+			continue // reported with its mapping
+		}
+		strictYAMLNode(child, errs)
+	}
+}
+
+// yamlCoreMismatch describes how the plain scalar value is read differently
+// than the YAML 1.2 core schema reads it, or returns "" if it is read the same.
+func yamlCoreMismatch(value string) string {
+	if b, ok := yamlBools[value]; ok {
+		return fmt.Sprintf("is read as the YAML 1.1 boolean %t, a string in YAML 1.2", b)
+	}
+	switch {
+	case underscoreNumberPattern.MatchString(value) && strings.Contains(value, "_"):
+		return "is read as a number with its underscores dropped, a string in YAML 1.2"
+	case signedRadixPattern.MatchString(value):
+		return "is read as a signed hexadecimal or octal number, a string in YAML 1.2"
+	case sexagesimalPattern.MatchString(value):
+		return "is read as a base 60 number by YAML 1.1 parsers"
+	case yamlTimestampPattern.MatchString(value):
+		return "is read as a YAML 1.1 timestamp, a string in YAML 1.2"
+	}
+	return ""
+}
@@ -0,0 +1,703 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+// The DecodeCheck service runs decodeTest checks over gRPC, see
+// pkg/decodecheck for what each check does.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: decodecheck.proto
+
+package decodecheckpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Status int32
+
+const (
+	Status_STATUS_UNSPECIFIED   Status = 0
+	Status_STATUS_PASSED        Status = 1
+	Status_STATUS_NON_STRICT    Status = 2
+	Status_STATUS_NO_DECODER    Status = 3
+	Status_STATUS_READ_FAILED   Status = 4
+	Status_STATUS_DECODE_FAILED Status = 5
+	Status_STATUS_TOO_LARGE     Status = 6
+	Status_STATUS_INVALID       Status = 7
+)
+
+// Enum value maps for Status.
+var (
+	Status_name = map[int32]string{
+		0: "STATUS_UNSPECIFIED",
+		1: "STATUS_PASSED",
+		2: "STATUS_NON_STRICT",
+		3: "STATUS_NO_DECODER",
+		4: "STATUS_READ_FAILED",
+		5: "STATUS_DECODE_FAILED",
+		6: "STATUS_TOO_LARGE",
+		7: "STATUS_INVALID",
+	}
+	Status_value = map[string]int32{
+		"STATUS_UNSPECIFIED":   0,
+		"STATUS_PASSED":        1,
+		"STATUS_NON_STRICT":    2,
+		"STATUS_NO_DECODER":    3,
+		"STATUS_READ_FAILED":   4,
+		"STATUS_DECODE_FAILED": 5,
+		"STATUS_TOO_LARGE":     6,
+		"STATUS_INVALID":       7,
+	}
+)
+
+func (x Status) Enum() *Status {
+	p := new(Status)
+	*p = x
+	return p
+}
+
+func (x Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_decodecheck_proto_enumTypes[0].Descriptor()
+}
+
+func (Status) Type() protoreflect.EnumType {
+	return &file_decodecheck_proto_enumTypes[0]
+}
+
+func (x Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Status.Descriptor instead.
+func (Status) EnumDescriptor() ([]byte, []int) {
+	return file_decodecheck_proto_rawDescGZIP(), []int{0}
+}
+
+type ValidateRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Name picks the decoder through the decoder rules when decoder is
+	// empty, and is used as the path in the result.
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Decoder is the decoder name to use, like "yaml".
+	Decoder       string `protobuf:"bytes,2,opt,name=decoder,proto3" json:"decoder,omitempty"`
+	Content       []byte `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidateRequest) Reset() {
+	*x = ValidateRequest{}
+	mi := &file_decodecheck_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateRequest) ProtoMessage() {}
+
+func (x *ValidateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_decodecheck_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateRequest.ProtoReflect.Descriptor instead.
+func (*ValidateRequest) Descriptor() ([]byte, []int) {
+	return file_decodecheck_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ValidateRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ValidateRequest) GetDecoder() string {
+	if x != nil {
+		return x.Decoder
+	}
+	return ""
+}
+
+func (x *ValidateRequest) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+type ScanRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Path is relative to the served root and may not escape it, empty scans
+	// the whole root.
+	Path          string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanRequest) Reset() {
+	*x = ScanRequest{}
+	mi := &file_decodecheck_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanRequest) ProtoMessage() {}
+
+func (x *ScanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_decodecheck_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanRequest.ProtoReflect.Descriptor instead.
+func (*ScanRequest) Descriptor() ([]byte, []int) {
+	return file_decodecheck_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ScanRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type Result struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Path   string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Size   int64                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	Type   string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	Status Status                 `protobuf:"varint,4,opt,name=status,proto3,enum=decodecheck.v1.Status" json:"status,omitempty"`
+	Error  string                 `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	Cached bool                   `protobuf:"varint,6,opt,name=cached,proto3" json:"cached,omitempty"`
+	// Match is the match pattern that selected the file during a scan.
+	Match string `protobuf:"bytes,7,opt,name=match,proto3" json:"match,omitempty"`
+	// Warnings are findings reported without failing the file.
+	Warnings []string `protobuf:"bytes,8,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	// Line and column are the 1 based position the error points at, or 0
+	// when it has none.
+	Line   int32 `protobuf:"varint,9,opt,name=line,proto3" json:"line,omitempty"`
+	Column int32 `protobuf:"varint,10,opt,name=column,proto3" json:"column,omitempty"`
+	// Cty type is the type of the decoded value, when type signatures are on.
+	CtyType       string `protobuf:"bytes,11,opt,name=cty_type,json=ctyType,proto3" json:"cty_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Result) Reset() {
+	*x = Result{}
+	mi := &file_decodecheck_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Result) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Result) ProtoMessage() {}
+
+func (x *Result) ProtoReflect() protoreflect.Message {
+	mi := &file_decodecheck_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Result.ProtoReflect.Descriptor instead.
+func (*Result) Descriptor() ([]byte, []int) {
+	return file_decodecheck_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Result) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Result) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Result) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Result) GetStatus() Status {
+	if x != nil {
+		return x.Status
+	}
+	return Status_STATUS_UNSPECIFIED
+}
+
+func (x *Result) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Result) GetCached() bool {
+	if x != nil {
+		return x.Cached
+	}
+	return false
+}
+
+func (x *Result) GetMatch() string {
+	if x != nil {
+		return x.Match
+	}
+	return ""
+}
+
+func (x *Result) GetWarnings() []string {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
+func (x *Result) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *Result) GetColumn() int32 {
+	if x != nil {
+		return x.Column
+	}
+	return 0
+}
+
+func (x *Result) GetCtyType() string {
+	if x != nil {
+		return x.CtyType
+	}
+	return ""
+}
+
+type TypeCounts struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Files         int64                  `protobuf:"varint,1,opt,name=files,proto3" json:"files,omitempty"`
+	Errors        int64                  `protobuf:"varint,2,opt,name=errors,proto3" json:"errors,omitempty"`
+	NonStrict     int64                  `protobuf:"varint,3,opt,name=non_strict,json=nonStrict,proto3" json:"non_strict,omitempty"`
+	Warnings      int64                  `protobuf:"varint,4,opt,name=warnings,proto3" json:"warnings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TypeCounts) Reset() {
+	*x = TypeCounts{}
+	mi := &file_decodecheck_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TypeCounts) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TypeCounts) ProtoMessage() {}
+
+func (x *TypeCounts) ProtoReflect() protoreflect.Message {
+	mi := &file_decodecheck_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TypeCounts.ProtoReflect.Descriptor instead.
+func (*TypeCounts) Descriptor() ([]byte, []int) {
+	return file_decodecheck_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *TypeCounts) GetFiles() int64 {
+	if x != nil {
+		return x.Files
+	}
+	return 0
+}
+
+func (x *TypeCounts) GetErrors() int64 {
+	if x != nil {
+		return x.Errors
+	}
+	return 0
+}
+
+func (x *TypeCounts) GetNonStrict() int64 {
+	if x != nil {
+		return x.NonStrict
+	}
+	return 0
+}
+
+func (x *TypeCounts) GetWarnings() int64 {
+	if x != nil {
+		return x.Warnings
+	}
+	return 0
+}
+
+type Summary struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Files       int64                  `protobuf:"varint,1,opt,name=files,proto3" json:"files,omitempty"`
+	Bytes       int64                  `protobuf:"varint,2,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	Errors      int64                  `protobuf:"varint,3,opt,name=errors,proto3" json:"errors,omitempty"`
+	Failed      bool                   `protobuf:"varint,4,opt,name=failed,proto3" json:"failed,omitempty"`
+	Interrupted bool                   `protobuf:"varint,5,opt,name=interrupted,proto3" json:"interrupted,omitempty"`
+	Types       map[string]*TypeCounts `protobuf:"bytes,6,rep,name=types,proto3" json:"types,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Set when the scan stopped early after reaching its error limit.
+	Stopped       bool `protobuf:"varint,7,opt,name=stopped,proto3" json:"stopped,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Summary) Reset() {
+	*x = Summary{}
+	mi := &file_decodecheck_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Summary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Summary) ProtoMessage() {}
+
+func (x *Summary) ProtoReflect() protoreflect.Message {
+	mi := &file_decodecheck_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Summary.ProtoReflect.Descriptor instead.
+func (*Summary) Descriptor() ([]byte, []int) {
+	return file_decodecheck_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Summary) GetFiles() int64 {
+	if x != nil {
+		return x.Files
+	}
+	return 0
+}
+
+func (x *Summary) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *Summary) GetErrors() int64 {
+	if x != nil {
+		return x.Errors
+	}
+	return 0
+}
+
+func (x *Summary) GetFailed() bool {
+	if x != nil {
+		return x.Failed
+	}
+	return false
+}
+
+func (x *Summary) GetInterrupted() bool {
+	if x != nil {
+		return x.Interrupted
+	}
+	return false
+}
+
+func (x *Summary) GetTypes() map[string]*TypeCounts {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+func (x *Summary) GetStopped() bool {
+	if x != nil {
+		return x.Stopped
+	}
+	return false
+}
+
+type ScanResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*ScanResponse_Result
+	//	*ScanResponse_Summary
+	Event         isScanResponse_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanResponse) Reset() {
+	*x = ScanResponse{}
+	mi := &file_decodecheck_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanResponse) ProtoMessage() {}
+
+func (x *ScanResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_decodecheck_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanResponse.ProtoReflect.Descriptor instead.
+func (*ScanResponse) Descriptor() ([]byte, []int) {
+	return file_decodecheck_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ScanResponse) GetEvent() isScanResponse_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *ScanResponse) GetResult() *Result {
+	if x != nil {
+		if x, ok := x.Event.(*ScanResponse_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+func (x *ScanResponse) GetSummary() *Summary {
+	if x != nil {
+		if x, ok := x.Event.(*ScanResponse_Summary); ok {
+			return x.Summary
+		}
+	}
+	return nil
+}
+
+type isScanResponse_Event interface {
+	isScanResponse_Event()
+}
+
+type ScanResponse_Result struct {
+	Result *Result `protobuf:"bytes,1,opt,name=result,proto3,oneof"`
+}
+
+type ScanResponse_Summary struct {
+	Summary *Summary `protobuf:"bytes,2,opt,name=summary,proto3,oneof"`
+}
+
+func (*ScanResponse_Result) isScanResponse_Event() {}
+
+func (*ScanResponse_Summary) isScanResponse_Event() {}
+
+var File_decodecheck_proto protoreflect.FileDescriptor
+
+const file_decodecheck_proto_rawDesc = "" +
+	"\n" +
+	"\x11decodecheck.proto\x12\x0edecodecheck.v1\"Y\n" +
+	"\x0fValidateRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\adecoder\x18\x02 \x01(\tR\adecoder\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\fR\acontent\"!\n" +
+	"\vScanRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\"\x9b\x02\n" +
+	"\x06Result\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12.\n" +
+	"\x06status\x18\x04 \x01(\x0e2\x16.decodecheck.v1.StatusR\x06status\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\x12\x16\n" +
+	"\x06cached\x18\x06 \x01(\bR\x06cached\x12\x14\n" +
+	"\x05match\x18\a \x01(\tR\x05match\x12\x1a\n" +
+	"\bwarnings\x18\b \x03(\tR\bwarnings\x12\x12\n" +
+	"\x04line\x18\t \x01(\x05R\x04line\x12\x16\n" +
+	"\x06column\x18\n" +
+	" \x01(\x05R\x06column\x12\x19\n" +
+	"\bcty_type\x18\v \x01(\tR\actyType\"u\n" +
+	"\n" +
+	"TypeCounts\x12\x14\n" +
+	"\x05files\x18\x01 \x01(\x03R\x05files\x12\x16\n" +
+	"\x06errors\x18\x02 \x01(\x03R\x06errors\x12\x1d\n" +
+	"\n" +
+	"non_strict\x18\x03 \x01(\x03R\tnonStrict\x12\x1a\n" +
+	"\bwarnings\x18\x04 \x01(\x03R\bwarnings\"\xb1\x02\n" +
+	"\aSummary\x12\x14\n" +
+	"\x05files\x18\x01 \x01(\x03R\x05files\x12\x14\n" +
+	"\x05bytes\x18\x02 \x01(\x03R\x05bytes\x12\x16\n" +
+	"\x06errors\x18\x03 \x01(\x03R\x06errors\x12\x16\n" +
+	"\x06failed\x18\x04 \x01(\bR\x06failed\x12 \n" +
+	"\vinterrupted\x18\x05 \x01(\bR\vinterrupted\x128\n" +
+	"\x05types\x18\x06 \x03(\v2\".decodecheck.v1.Summary.TypesEntryR\x05types\x12\x18\n" +
+	"\astopped\x18\a \x01(\bR\astopped\x1aT\n" +
+	"\n" +
+	"TypesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x120\n" +
+	"\x05value\x18\x02 \x01(\v2\x1a.decodecheck.v1.TypeCountsR\x05value:\x028\x01\"~\n" +
+	"\fScanResponse\x120\n" +
+	"\x06result\x18\x01 \x01(\v2\x16.decodecheck.v1.ResultH\x00R\x06result\x123\n" +
+	"\asummary\x18\x02 \x01(\v2\x17.decodecheck.v1.SummaryH\x00R\asummaryB\a\n" +
+	"\x05event*\xbd\x01\n" +
+	"\x06Status\x12\x16\n" +
+	"\x12STATUS_UNSPECIFIED\x10\x00\x12\x11\n" +
+	"\rSTATUS_PASSED\x10\x01\x12\x15\n" +
+	"\x11STATUS_NON_STRICT\x10\x02\x12\x15\n" +
+	"\x11STATUS_NO_DECODER\x10\x03\x12\x16\n" +
+	"\x12STATUS_READ_FAILED\x10\x04\x12\x18\n" +
+	"\x14STATUS_DECODE_FAILED\x10\x05\x12\x14\n" +
+	"\x10STATUS_TOO_LARGE\x10\x06\x12\x12\n" +
+	"\x0eSTATUS_INVALID\x10\a2\x97\x01\n" +
+	"\vDecodeCheck\x12C\n" +
+	"\bValidate\x12\x1f.decodecheck.v1.ValidateRequest\x1a\x16.decodecheck.v1.Result\x12C\n" +
+	"\x04Scan\x12\x1b.decodecheck.v1.ScanRequest\x1a\x1c.decodecheck.v1.ScanResponse0\x01B@Z>github.com/JasonPodgorny/terraformDecodeTest/pkg/decodecheckpbb\x06proto3"
+
+var (
+	file_decodecheck_proto_rawDescOnce sync.Once
+	file_decodecheck_proto_rawDescData []byte
+)
+
+func file_decodecheck_proto_rawDescGZIP() []byte {
+	file_decodecheck_proto_rawDescOnce.Do(func() {
+		file_decodecheck_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_decodecheck_proto_rawDesc), len(file_decodecheck_proto_rawDesc)))
+	})
+	return file_decodecheck_proto_rawDescData
+}
+
+var file_decodecheck_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_decodecheck_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_decodecheck_proto_goTypes = []any{
+	(Status)(0),             // 0: decodecheck.v1.Status
+	(*ValidateRequest)(nil), // 1: decodecheck.v1.ValidateRequest
+	(*ScanRequest)(nil),     // 2: decodecheck.v1.ScanRequest
+	(*Result)(nil),          // 3: decodecheck.v1.Result
+	(*TypeCounts)(nil),      // 4: decodecheck.v1.TypeCounts
+	(*Summary)(nil),         // 5: decodecheck.v1.Summary
+	(*ScanResponse)(nil),    // 6: decodecheck.v1.ScanResponse
+	nil,                     // 7: decodecheck.v1.Summary.TypesEntry
+}
+var file_decodecheck_proto_depIdxs = []int32{
+	0, // 0: decodecheck.v1.Result.status:type_name -> decodecheck.v1.Status
+	7, // 1: decodecheck.v1.Summary.types:type_name -> decodecheck.v1.Summary.TypesEntry
+	3, // 2: decodecheck.v1.ScanResponse.result:type_name -> decodecheck.v1.Result
+	5, // 3: decodecheck.v1.ScanResponse.summary:type_name -> decodecheck.v1.Summary
+	4, // 4: decodecheck.v1.Summary.TypesEntry.value:type_name -> decodecheck.v1.TypeCounts
+	1, // 5: decodecheck.v1.DecodeCheck.Validate:input_type -> decodecheck.v1.ValidateRequest
+	2, // 6: decodecheck.v1.DecodeCheck.Scan:input_type -> decodecheck.v1.ScanRequest
+	3, // 7: decodecheck.v1.DecodeCheck.Validate:output_type -> decodecheck.v1.Result
+	6, // 8: decodecheck.v1.DecodeCheck.Scan:output_type -> decodecheck.v1.ScanResponse
+	7, // [7:9] is the sub-list for method output_type
+	5, // [5:7] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_decodecheck_proto_init() }
+func file_decodecheck_proto_init() {
+	if File_decodecheck_proto != nil {
+		return
+	}
+	file_decodecheck_proto_msgTypes[5].OneofWrappers = []any{
+		(*ScanResponse_Result)(nil),
+		(*ScanResponse_Summary)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_decodecheck_proto_rawDesc), len(file_decodecheck_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_decodecheck_proto_goTypes,
+		DependencyIndexes: file_decodecheck_proto_depIdxs,
+		EnumInfos:         file_decodecheck_proto_enumTypes,
+		MessageInfos:      file_decodecheck_proto_msgTypes,
+	}.Build()
+	File_decodecheck_proto = out.File
+	file_decodecheck_proto_goTypes = nil
+	file_decodecheck_proto_depIdxs = nil
+}
@@ -0,0 +1,175 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+// The DecodeCheck service runs decodeTest checks over gRPC, see
+// pkg/decodecheck for what each check does.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: decodecheck.proto
+
+package decodecheckpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	DecodeCheck_Validate_FullMethodName = "/decodecheck.v1.DecodeCheck/Validate"
+	DecodeCheck_Scan_FullMethodName     = "/decodecheck.v1.DecodeCheck/Scan"
+)
+
+// DecodeCheckClient is the client API for DecodeCheck service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type DecodeCheckClient interface {
+	// Validate decodes a single payload.
+	Validate(ctx context.Context, in *ValidateRequest, opts ...grpc.CallOption) (*Result, error)
+	// Scan walks a directory under the served root, streaming each file's
+	// result as it completes and ending with a summary.
+	Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ScanResponse], error)
+}
+
+type decodeCheckClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDecodeCheckClient(cc grpc.ClientConnInterface) DecodeCheckClient {
+	return &decodeCheckClient{cc}
+}
+
+func (c *decodeCheckClient) Validate(ctx context.Context, in *ValidateRequest, opts ...grpc.CallOption) (*Result, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Result)
+	err := c.cc.Invoke(ctx, DecodeCheck_Validate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *decodeCheckClient) Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ScanResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &DecodeCheck_ServiceDesc.Streams[0], DecodeCheck_Scan_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ScanRequest, ScanResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DecodeCheck_ScanClient = grpc.ServerStreamingClient[ScanResponse]
+
+// DecodeCheckServer is the server API for DecodeCheck service.
+// All implementations must embed UnimplementedDecodeCheckServer
+// for forward compatibility.
+type DecodeCheckServer interface {
+	// Validate decodes a single payload.
+	Validate(context.Context, *ValidateRequest) (*Result, error)
+	// Scan walks a directory under the served root, streaming each file's
+	// result as it completes and ending with a summary.
+	Scan(*ScanRequest, grpc.ServerStreamingServer[ScanResponse]) error
+	mustEmbedUnimplementedDecodeCheckServer()
+}
+
+// UnimplementedDecodeCheckServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDecodeCheckServer struct{}
+
+func (UnimplementedDecodeCheckServer) Validate(context.Context, *ValidateRequest) (*Result, error) {
+	return nil, status.Error(codes.Unimplemented, "method Validate not implemented")
+}
+func (UnimplementedDecodeCheckServer) Scan(*ScanRequest, grpc.ServerStreamingServer[ScanResponse]) error {
+	return status.Error(codes.Unimplemented, "method Scan not implemented")
+}
+func (UnimplementedDecodeCheckServer) mustEmbedUnimplementedDecodeCheckServer() {}
+func (UnimplementedDecodeCheckServer) testEmbeddedByValue()                     {}
+
+// UnsafeDecodeCheckServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DecodeCheckServer will
+// result in compilation errors.
+type UnsafeDecodeCheckServer interface {
+	mustEmbedUnimplementedDecodeCheckServer()
+}
+
+func RegisterDecodeCheckServer(s grpc.ServiceRegistrar, srv DecodeCheckServer) {
+	// If the following call panics, it indicates UnimplementedDecodeCheckServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&DecodeCheck_ServiceDesc, srv)
+}
+
+func _DecodeCheck_Validate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DecodeCheckServer).Validate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DecodeCheck_Validate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DecodeCheckServer).Validate(ctx, req.(*ValidateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DecodeCheck_Scan_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ScanRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DecodeCheckServer).Scan(m, &grpc.GenericServerStream[ScanRequest, ScanResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DecodeCheck_ScanServer = grpc.ServerStreamingServer[ScanResponse]
+
+// DecodeCheck_ServiceDesc is the grpc.ServiceDesc for DecodeCheck service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DecodeCheck_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "decodecheck.v1.DecodeCheck",
+	HandlerType: (*DecodeCheckServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Validate",
+			Handler:    _DecodeCheck_Validate_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Scan",
+			Handler:       _DecodeCheck_Scan_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "decodecheck.proto",
+}
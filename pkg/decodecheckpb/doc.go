@@ -0,0 +1,9 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+// Package decodecheckpb holds the protobuf messages and gRPC stubs for the
+// DecodeCheck service, generated from decodecheck.proto.   The server is
+// decodecheck.RegisterGRPC.
+package decodecheckpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative decodecheck.proto
@@ -0,0 +1,81 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	rtpprof "runtime/pprof"
+	"runtime/trace"
+)
+
+// startProfiling starts writing a CPU profile to cpuPath and an execution
+// trace to tracePath, and serves the pprof endpoints on pprofAddr, each when
+// set.   The profiles are finished on exit, along with a heap profile written
+// to memPath.
+func startProfiling(cpuPath, memPath, tracePath, pprofAddr string) {
+	if cpuPath != "" {
+		file, err := os.Create(cpuPath)
+		if err != nil {
+			fatalf("Cannot Create CPU Profile: %v", err)
+		}
+		if err := rtpprof.StartCPUProfile(file); err != nil {
+			fatalf("Cannot Start CPU Profile: %v", err)
+		}
+		atExit = append(atExit, func() {
+			rtpprof.StopCPUProfile()
+			file.Close()
+		})
+	}
+
+	if tracePath != "" {
+		file, err := os.Create(tracePath)
+		if err != nil {
+			fatalf("Cannot Create Execution Trace: %v", err)
+		}
+		if err := trace.Start(file); err != nil {
+			fatalf("Cannot Start Execution Trace: %v", err)
+		}
+		atExit = append(atExit, func() {
+			trace.Stop()
+			file.Close()
+		})
+	}
+
+	// Write The Heap Profile Last Thing, After A GC So It Shows What Is Still Live
+	if memPath != "" {
+		atExit = append(atExit, func() {
+			file, err := os.Create(memPath)
+			if err != nil {
+				logEvent(slog.LevelWarn, "profile", fmt.Sprintf("Cannot Create Memory Profile: %v", err), "error", err.Error())
+				return
+			}
+			defer file.Close()
+			runtime.GC()
+			if err := rtpprof.WriteHeapProfile(file); err != nil {
+				logEvent(slog.LevelWarn, "profile", fmt.Sprintf("Cannot Write Memory Profile: %v", err), "error", err.Error())
+			}
+		})
+	}
+
+	if pprofAddr != "" {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		go func() {
+			logf("serve", "Serving pprof On %s", pprofAddr)
+			if err := http.ListenAndServe(pprofAddr, mux); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				fatalf("Cannot Serve pprof: %v", err)
+			}
+		}()
+	}
+}
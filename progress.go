@@ -0,0 +1,123 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/JasonPodgorny/terraformDecodeTest/pkg/decodecheck"
+)
+
+// liveRefresh is how often the live status line is redrawn.
+const liveRefresh = 200 * time.Millisecond
+
+// progressReporter shows how far a run has got while it runs, as a status line
+// redrawn in place on a terminal or as a heartbeat log line every interval
+// otherwise, so long scans in CI do not look hung.
+type progressReporter struct {
+	live     bool
+	interval time.Duration
+	start    time.Time
+	done     chan struct{}
+	stopped  sync.WaitGroup
+
+	mu    sync.Mutex
+	last  decodecheck.Progress
+	shown bool // the status line is on screen
+}
+
+// startProgress starts reporting progress, live when live is set and as a
+// heartbeat every interval otherwise.   Live reporting takes over the log
+// output, so log lines are written above the status line.
+func startProgress(live bool, interval time.Duration) *progressReporter {
+	p := &progressReporter{live: live, interval: interval, start: time.Now(), done: make(chan struct{})}
+	tick := interval
+	if live {
+		tick = liveRefresh
+		log.SetOutput(p)
+	}
+	p.stopped.Add(1)
+	go func() {
+		defer p.stopped.Done()
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.done:
+				return
+			case <-ticker.C:
+				p.tick()
+			}
+		}
+	}()
+	return p
+}
+
+// update records the latest progress, it is Options.OnProgress.
+func (p *progressReporter) update(progress decodecheck.Progress) {
+	p.mu.Lock()
+	p.last = progress
+	p.mu.Unlock()
+}
+
+// tick redraws the status line, or logs a heartbeat.
+func (p *progressReporter) tick() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.live {
+		p.clear()
+		fmt.Fprint(os.Stderr, p.status())
+		p.shown = true
+		return
+	}
+	logEvent(slog.LevelInfo, "progress", p.status(), "found", p.last.Found, "checked", p.last.Checked, "bytes", p.last.Bytes)
+}
+
+// status describes the progress so far, with the throughput and, once files
+// are being checked, the time left to check the files found so far.
+func (p *progressReporter) status() string {
+	elapsed := time.Since(p.start)
+	rate := float64(p.last.Checked) / elapsed.Seconds()
+	status := fmt.Sprintf("%d/%d files checked  %.1f MB found  %.0f files/s  %s elapsed", p.last.Checked, p.last.Found, float64(p.last.Bytes)/1e6, rate, elapsed.Round(time.Second))
+	if rate > 0 && p.last.Found > p.last.Checked {
+		eta := time.Duration(float64(p.last.Found-p.last.Checked) / rate * float64(time.Second))
+		status += fmt.Sprintf("  ETA %s", eta.Round(time.Second))
+	}
+	return status
+}
+
+// clear erases the status line if it is on screen, the caller holds mu.
+func (p *progressReporter) clear() {
+	if p.shown {
+		fmt.Fprint(os.Stderr, "\r\x1b[K")
+		p.shown = false
+	}
+}
+
+// Write writes a log line to stderr above the status line, which is redrawn on
+// the next tick.
+func (p *progressReporter) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+	return os.Stderr.Write(b)
+}
+
+// stop stops reporting, clearing the status line and handing the log output
+// back to stderr.
+func (p *progressReporter) stop() {
+	close(p.done)
+	p.stopped.Wait()
+	if p.live {
+		p.mu.Lock()
+		p.clear()
+		p.mu.Unlock()
+		log.SetOutput(os.Stderr)
+	}
+}
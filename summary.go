@@ -0,0 +1,206 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JasonPodgorny/terraformDecodeTest/pkg/decodecheck"
+)
+
+// ANSI colors for text output on a terminal.
+const (
+	colorRed     = "\x1b[31m"
+	colorGreen   = "\x1b[32m"
+	colorYellow  = "\x1b[33m"
+	colorReverse = "\x1b[7m"
+	colorReset   = "\x1b[0m"
+)
+
+// useColor is set by setupLogging when text logs go to a terminal, unless
+// -no-color is given or NO_COLOR is set.
+var useColor bool
+
+// colorEnabled reports whether text logs on stderr should be colored.
+func colorEnabled(noColor bool) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return isTerminal(os.Stderr)
+}
+
+// isTerminal reports whether f is a terminal rather than a file or pipe.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorize wraps s in color when useColor is set.
+func colorize(color, s string) string {
+	if !useColor {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// levelColor returns the color messages logged at level are shown in, or ""
+// to leave them plain.
+func levelColor(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError:
+		return colorRed
+	case level >= slog.LevelWarn:
+		return colorYellow
+	}
+	return ""
+}
+
+// tableCell is one cell of a table, colored when it is a non zero count.
+type tableCell struct {
+	text  string
+	color string
+}
+
+// countCell returns a cell for the count n, in color unless it is zero.
+func countCell(n int, color string) tableCell {
+	if n == 0 {
+		color = ""
+	}
+	return tableCell{strconv.Itoa(n), color}
+}
+
+// printTable writes rows under headers with each column padded to its widest
+// cell, the first left aligned and the rest right aligned.   Cells are sized
+// before they are colored, so escapes do not skew the alignment.
+func printTable(w io.Writer, headers []string, rows [][]tableCell) {
+	widths := make([]int, len(headers))
+	for i, header := range headers {
+		widths[i] = len(header)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell.text))
+		}
+	}
+
+	line := func(cells []tableCell) string {
+		var b strings.Builder
+		for i, cell := range cells {
+			text := fmt.Sprintf("%*s", widths[i], cell.text)
+			if i == 0 {
+				text = fmt.Sprintf("%-*s", widths[i], cell.text)
+			} else {
+				b.WriteString("  ")
+			}
+			if cell.color != "" {
+				text = colorize(cell.color, text)
+			}
+			b.WriteString(text)
+		}
+		return strings.TrimRight(b.String(), " ")
+	}
+	headerCells := make([]tableCell, len(headers))
+	for i, header := range headers {
+		headerCells[i] = tableCell{text: header}
+	}
+	fmt.Fprintln(w, line(headerCells))
+	for _, row := range rows {
+		fmt.Fprintln(w, line(row))
+	}
+}
+
+// printCountsTable writes the per type counts as a table, with a total row
+// and the overall size, passes in green, errors in red and non-strict files
+// and warnings in yellow.
+func printCountsTable(w io.Writer, counts *decodecheck.SafeCounter) {
+	var rows [][]tableCell
+	for _, fileType := range append(counts.Types(), "total") {
+		rows = append(rows, []tableCell{
+			{text: fileType},
+			countCell(counts.Files(fileType), ""),
+			countCell(counts.Files(fileType)-counts.Errors(fileType), colorGreen),
+			countCell(counts.Errors(fileType), colorRed),
+			countCell(counts.NonStrictFiles(fileType), colorYellow),
+			countCell(counts.WarningFiles(fileType), colorYellow),
+		})
+	}
+	printTable(w, []string{"TYPE", "FILES", "PASSED", "ERRORS", "NON-STRICT", "WARNINGS"}, rows)
+	fmt.Fprintf(w, "%d files, %.1f MB\n", counts.Files("total"), float64(counts.Bytes())/1e6)
+}
+
+// printDirectoriesTable writes the counts for each directory of a -summary-depth
+// breakdown as a table, errors in red and warnings in yellow.
+func printDirectoriesTable(w io.Writer, directories []decodecheck.DirectoryCounts) {
+	rows := make([][]tableCell, len(directories))
+	for i, dir := range directories {
+		rows[i] = []tableCell{
+			{text: dir.Path},
+			countCell(dir.Files, ""),
+			{text: fmt.Sprintf("%.1f", float64(dir.Bytes)/1e6)},
+			countCell(dir.Errors, colorRed),
+			countCell(dir.Warnings, colorYellow),
+		}
+	}
+	printTable(w, []string{"DIRECTORY", "FILES", "MB", "ERRORS", "WARNINGS"}, rows)
+}
+
+// printSlowestTable writes the slowest files to decode as a table, slowest
+// first.
+func printSlowestTable(w io.Writer, results []decodecheck.Result) {
+	rows := make([][]tableCell, len(results))
+	for i, result := range results {
+		rows[i] = []tableCell{
+			{text: result.Path},
+			{text: result.Type},
+			{text: fmt.Sprintf("%.1f", float64(result.Size)/1e6)},
+			{text: result.Duration.Round(time.Microsecond).String()},
+		}
+	}
+	printTable(w, []string{"SLOWEST FILE", "TYPE", "MB", "DURATION"}, rows)
+}
+
+// printSizeTable writes the size histogram of each file type as a table, with
+// files of a megabyte or more in yellow.
+func printSizeTable(w io.Writer, histogram map[string][]int, types []string) {
+	headers := []string{"TYPE"}
+	lower := "0"
+	for _, limit := range decodecheck.SizeBuckets {
+		headers = append(headers, lower+"-"+sizeLabel(limit))
+		lower = sizeLabel(limit)
+	}
+	headers = append(headers, ">"+lower)
+
+	var rows [][]tableCell
+	for _, fileType := range append(types, "total") {
+		row := []tableCell{{text: fileType}}
+		for i, n := range histogram[fileType] {
+			color := ""
+			if i > 0 && decodecheck.SizeBuckets[i-1] >= 1e6 {
+				color = colorYellow
+			}
+			row = append(row, countCell(n, color))
+		}
+		rows = append(rows, row)
+	}
+	printTable(w, headers, rows)
+}
+
+// sizeLabel returns a short label for a bucket limit, like 10KB or 1MB.
+func sizeLabel(size int64) string {
+	switch {
+	case size >= 1e9:
+		return strconv.FormatInt(size/1e9, 10) + "GB"
+	case size >= 1e6:
+		return strconv.FormatInt(size/1e6, 10) + "MB"
+	case size >= 1e3:
+		return strconv.FormatInt(size/1e3, 10) + "KB"
+	}
+	return strconv.FormatInt(size, 10) + "B"
+}
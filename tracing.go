@@ -0,0 +1,76 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// tracingConfigured reports whether the environment names an OTLP endpoint to
+// export traces to, the usual OTEL_EXPORTER_OTLP_ENDPOINT or its traces only
+// form.
+func tracingConfigured() bool {
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// setupTracing exports the spans of scans over OTLP, configured by the standard
+// OTEL_ environment variables, over gRPC when OTEL_EXPORTER_OTLP_PROTOCOL is
+// grpc and HTTP otherwise.   A TRACEPARENT in the environment, as some CI
+// systems set, makes the scan part of that trace.   The returned context
+// carries it, and the function flushes the spans still buffered.
+func setupTracing(ctx context.Context) (context.Context, func(), error) {
+	var client otlptrace.Client
+	protocol := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL")
+	if protocol == "" {
+		protocol = os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL")
+	}
+	if protocol == "grpc" {
+		client = otlptracegrpc.NewClient()
+	} else {
+		client = otlptracehttp.NewClient()
+	}
+	exporter, err := otlptrace.New(ctx, client)
+	if err != nil {
+		return ctx, nil, err
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName("decodeTest")))
+	if err != nil {
+		return ctx, nil, err
+	}
+	if env, err := resource.New(ctx, resource.WithFromEnv()); err == nil {
+		res, _ = resource.Merge(res, env)
+	}
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logEvent(slog.LevelWarn, "tracing", fmt.Sprintf("Cannot Export Traces: %v", err), "error", err.Error())
+	}))
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+
+	// Join The Trace Of The CI Job Running Us, If It Passed One Down
+	propagator := propagation.TraceContext{}
+	otel.SetTextMapPropagator(propagator)
+	if traceparent := os.Getenv("TRACEPARENT"); traceparent != "" {
+		ctx = propagator.Extract(ctx, propagation.MapCarrier{"traceparent": traceparent, "tracestate": os.Getenv("TRACESTATE")})
+	}
+
+	shutdown := func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		provider.Shutdown(shutdownCtx)
+	}
+	return ctx, shutdown, nil
+}
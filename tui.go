@@ -0,0 +1,421 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/JasonPodgorny/terraformDecodeTest/pkg/decodecheck"
+)
+
+// contextLines is how many lines either side of an error the -tui details
+// show.
+const contextLines = 5
+
+// lineArgEditors are the editors -tui opens at the error line with +line.
+var lineArgEditors = []string{"vi", "vim", "nvim", "nano", "emacs", "micro", "kak"}
+
+// browser is the -tui results browser, listing the files that failed the scan
+// and showing each error in its file.   Files can be checked again after
+// editing them, from the browser or elsewhere.
+type browser struct {
+	ctx     context.Context
+	opts    decodecheck.Options
+	results []decodecheck.Result // failed files in path order, replaced when checked again
+	shown   []int                // indexes of the results the filter keeps
+	cursor  int                  // index into shown of the selected file
+	offset  int                  // index into shown of the first row on screen
+	filter  string
+	typing  bool // reading a new filter into input
+	input   string
+	detail  bool   // showing the selected file rather than the list
+	status  string // message for the bottom line, until the next key
+	width   int
+	height  int
+}
+
+// rechecked is the outcome of checking results[index] again, ok is false when
+// it is no longer checked at all, being deleted or renamed.
+type rechecked struct {
+	index  int
+	result decodecheck.Result
+	ok     bool
+}
+
+// edited is sent when the editor opened on results[index] exits.
+type edited struct {
+	index int
+	err   error
+}
+
+// browseResults runs the -tui browser over the files that failed in report
+// until the user quits, returning how many of them still fail.
+func browseResults(ctx context.Context, opts decodecheck.Options, report *decodecheck.Report) (int, error) {
+	b := &browser{ctx: ctx, opts: opts, width: 80, height: 24}
+	for _, result := range report.Results {
+		if result.Failed() {
+			b.results = append(b.results, result)
+		}
+	}
+	if len(b.results) == 0 {
+		return 0, nil
+	}
+	b.applyFilter()
+
+	if _, err := tea.NewProgram(b, tea.WithAltScreen(), tea.WithContext(ctx)).Run(); err != nil && !errors.Is(err, tea.ErrProgramKilled) {
+		return 0, err
+	}
+	failing := 0
+	for _, result := range b.results {
+		if result.Failed() {
+			failing++
+		}
+	}
+	return failing, nil
+}
+
+func (b *browser) Init() tea.Cmd {
+	return nil
+}
+
+func (b *browser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		b.width, b.height = msg.Width, msg.Height
+		b.scroll()
+
+	case rechecked:
+		name := b.results[msg.index].Path
+		switch {
+		case !msg.ok:
+			b.results[msg.index].Status = decodecheck.Passed
+			b.results[msg.index].Err = errors.New("no longer checked")
+			b.status = name + " Is No Longer Checked"
+		case msg.result.Failed():
+			b.results[msg.index] = msg.result
+			b.status = name + " Still Fails"
+		default:
+			b.results[msg.index] = msg.result
+			b.status = name + " Passes Now"
+		}
+
+	case edited:
+		if msg.err != nil {
+			b.status = fmt.Sprintf("Cannot Run Editor: %v", msg.err)
+			return b, nil
+		}
+		b.status = "Checking " + b.results[msg.index].Path
+		return b, b.recheck(msg.index)
+
+	case tea.KeyMsg:
+		if b.typing {
+			return b, b.typeFilter(msg)
+		}
+		b.status = ""
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return b, tea.Quit
+		case "up", "k":
+			b.move(-1)
+		case "down", "j":
+			b.move(1)
+		case "pgup":
+			b.move(-b.rows())
+		case "pgdown", " ":
+			b.move(b.rows())
+		case "home", "g":
+			b.move(-len(b.shown))
+		case "end", "G":
+			b.move(len(b.shown))
+		case "enter", "right", "l":
+			b.detail = len(b.shown) > 0
+		case "esc", "left", "h", "backspace":
+			b.detail = false
+		case "/":
+			b.typing, b.input = true, b.filter
+		case "r":
+			if index, ok := b.selected(); ok {
+				b.status = "Checking " + b.results[index].Path
+				return b, b.recheck(index)
+			}
+		case "e":
+			if index, ok := b.selected(); ok {
+				return b, b.edit(index)
+			}
+		}
+	}
+	return b, nil
+}
+
+// typeFilter handles a key while the filter is being typed, applying it on
+// enter and dropping it on escape.
+func (b *browser) typeFilter(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return tea.Quit
+	case tea.KeyEnter:
+		b.typing, b.filter = false, strings.TrimSpace(b.input)
+		b.detail = false
+		b.applyFilter()
+	case tea.KeyEsc:
+		b.typing = false
+	case tea.KeyBackspace:
+		if runes := []rune(b.input); len(runes) > 0 {
+			b.input = string(runes[:len(runes)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		b.input += string(msg.Runes)
+	}
+	return nil
+}
+
+// applyFilter lists the results the filter keeps.   Its words starting with a
+// dot are extensions and the others directories, a file is kept when it has
+// one of the extensions and is under one of the directories.
+func (b *browser) applyFilter() {
+	var exts, dirs []string
+	for _, word := range strings.Fields(b.filter) {
+		if strings.HasPrefix(word, ".") {
+			exts = append(exts, word)
+		} else {
+			dirs = append(dirs, strings.TrimSuffix(filepath.ToSlash(word), "/")+"/")
+		}
+	}
+	b.shown = b.shown[:0]
+	for i, result := range b.results {
+		name := filepath.ToSlash(result.Path)
+		if matchesAny(exts, func(ext string) bool { return strings.HasSuffix(name, ext) }) &&
+			matchesAny(dirs, func(dir string) bool { return strings.HasPrefix(name, dir) }) {
+			b.shown = append(b.shown, i)
+		}
+	}
+	b.cursor, b.offset = 0, 0
+}
+
+// matchesAny reports whether match holds for any of terms, or there are none.
+func matchesAny(terms []string, match func(string) bool) bool {
+	for _, term := range terms {
+		if match(term) {
+			return true
+		}
+	}
+	return len(terms) == 0
+}
+
+// selected returns the index in results of the selected file.
+func (b *browser) selected() (int, bool) {
+	if len(b.shown) == 0 {
+		return 0, false
+	}
+	return b.shown[b.cursor], true
+}
+
+// move moves the selection by delta rows, scrolling to keep it on screen.
+func (b *browser) move(delta int) {
+	b.cursor = max(0, min(len(b.shown)-1, b.cursor+delta))
+	b.scroll()
+}
+
+// rows is how many files fit on screen below the title and above the help.
+func (b *browser) rows() int {
+	return max(1, b.height-3)
+}
+
+// scroll moves the list so the selected file is on screen.
+func (b *browser) scroll() {
+	if b.cursor < b.offset {
+		b.offset = b.cursor
+	} else if b.cursor >= b.offset+b.rows() {
+		b.offset = b.cursor - b.rows() + 1
+	}
+}
+
+// recheck checks results[index] again with the options of the scan.
+func (b *browser) recheck(index int) tea.Cmd {
+	opts := b.opts
+	opts.Files = []string{b.results[index].Path}
+	opts.OnResult, opts.OnProgress, opts.OnRetry = nil, nil, nil
+	opts.OnWalkError = func(error) {}
+	opts.Metrics = nil
+	ctx := b.ctx
+	return func() tea.Msg {
+		report := decodecheck.NewRunner(opts).Run(ctx)
+		if len(report.Results) == 0 {
+			return rechecked{index: index}
+		}
+		return rechecked{index: index, result: report.Results[0], ok: true}
+	}
+}
+
+// edit opens results[index] in $VISUAL or $EDITOR, vi by default, at the error
+// line for editors known to take +line.
+func (b *browser) edit(index int) tea.Cmd {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	args := strings.Fields(editor)
+	result := b.results[index]
+	if result.Line > 0 && contains(lineArgEditors, filepath.Base(args[0])) {
+		args = append(args, fmt.Sprintf("+%d", result.Line))
+	}
+	args = append(args, result.Path)
+	cmd := exec.Command(args[0], args[1:]...)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return edited{index: index, err: err}
+	})
+}
+
+func (b *browser) View() string {
+	var view strings.Builder
+	if b.detail {
+		b.viewDetail(&view)
+	} else {
+		b.viewList(&view)
+	}
+
+	// The Help Line, Or The Filter Being Typed, Or The Last Message
+	switch {
+	case b.typing:
+		view.WriteString("Filter (.ext or dir, enter to apply, esc to cancel): " + b.input)
+	case b.status != "":
+		view.WriteString(b.truncate(b.status))
+	case b.detail:
+		view.WriteString(b.truncate("esc back  ↑/↓ next file  r recheck  e edit  q quit"))
+	default:
+		view.WriteString(b.truncate("↑/↓ move  enter details  / filter  r recheck  e edit  q quit"))
+	}
+	return view.String()
+}
+
+// viewList writes the list of failed files the filter keeps, one per line with
+// its status and error.
+func (b *browser) viewList(view *strings.Builder) {
+	failing := 0
+	for _, result := range b.results {
+		if result.Failed() {
+			failing++
+		}
+	}
+	title := fmt.Sprintf("decodeTest: %d of %d files still failing, %d shown", failing, len(b.results), len(b.shown))
+	if b.filter != "" {
+		title += ", filter " + b.filter
+	}
+	view.WriteString(b.truncate(title) + "\n\n")
+
+	end := min(len(b.shown), b.offset+b.rows())
+	for row := b.offset; row < end; row++ {
+		result := b.results[b.shown[row]]
+		line := fmt.Sprintf("  %s  %s  %s", result.Location(), result.Status, firstLine(result.Err))
+		if row == b.cursor {
+			line = ">" + line[1:]
+		}
+		line = b.truncate(line)
+		switch {
+		case row == b.cursor:
+			line = colorize(colorReverse, line)
+		case !result.Failed():
+			line = colorize(colorGreen, line)
+		}
+		view.WriteString(line + "\n")
+	}
+	for row := end - b.offset; row < b.rows(); row++ {
+		view.WriteString("\n")
+	}
+}
+
+// viewDetail writes the selected file's error in full, then the lines around
+// it with the column marked.
+func (b *browser) viewDetail(view *strings.Builder) {
+	result := b.results[b.shown[b.cursor]]
+	header := fmt.Sprintf("%s  %s  %s", result.Location(), result.Status, result.Type)
+	if result.Failed() {
+		header = colorize(colorRed, b.truncate(header))
+	} else {
+		header = colorize(colorGreen, b.truncate(header))
+	}
+	lines := []string{header, ""}
+	if result.Err != nil {
+		for _, line := range strings.Split(result.Err.Error(), "\n") {
+			lines = append(lines, b.truncate(line))
+		}
+	}
+	for _, warning := range result.Warnings {
+		lines = append(lines, colorize(colorYellow, b.truncate("warning: "+warning)))
+	}
+	lines = append(lines, "")
+	lines = append(lines, b.fileContext(result)...)
+
+	for i := 0; i < b.height-1; i++ {
+		if i < len(lines) {
+			view.WriteString(lines[i])
+		}
+		view.WriteString("\n")
+	}
+}
+
+// fileContext returns the lines of the file around result's error, numbered,
+// with the error line marked and a caret under its column.   Files without an
+// error position show their first lines.
+func (b *browser) fileContext(result decodecheck.Result) []string {
+	first, last := 1, 2*contextLines+1
+	if result.Line > 0 {
+		first, last = max(1, result.Line-contextLines), result.Line+contextLines
+	}
+	source, err := readLines(result.Path, first, last)
+	if err != nil {
+		return []string{b.truncate(fmt.Sprintf("Cannot Read %s: %v", result.Path, err))}
+	}
+
+	width := len(fmt.Sprint(last))
+	var lines []string
+	for i, text := range source {
+		number := first + i
+		marker := " "
+		if number == result.Line {
+			marker = ">"
+		}
+		line := b.truncate(fmt.Sprintf("%s %*d | %s", marker, width, number, strings.ReplaceAll(text, "\t", "    ")))
+		if number != result.Line {
+			lines = append(lines, line)
+			continue
+		}
+		lines = append(lines, colorize(colorRed, line))
+		if result.Column > 0 {
+			// Tabs Were Widened Above, So Widen Them Before The Column Too
+			prefix := text[:min(len(text), result.Column-1)]
+			indent := len([]rune(strings.ReplaceAll(prefix, "\t", "    ")))
+			lines = append(lines, b.truncate(fmt.Sprintf("  %*s | %s^", width, "", strings.Repeat(" ", indent))))
+		}
+	}
+	return lines
+}
+
+// truncate cuts line to the width of the screen.
+func (b *browser) truncate(line string) string {
+	if runes := []rune(line); len(runes) > b.width {
+		return string(runes[:max(0, b.width-1)]) + "…"
+	}
+	return line
+}
+
+// firstLine returns the first line of err's message, or "" when err is nil.
+func firstLine(err error) string {
+	if err == nil {
+		return ""
+	}
+	message, _, _ := strings.Cut(err.Error(), "\n")
+	return message
+}
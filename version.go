@@ -0,0 +1,90 @@
+// Copyright © 2020 - Updates By Jason Podgorny.
+// License: https://creativecommons.org/licenses/by-nc-sa/4.0/
+
+package main
+
+import (
+	"flag"
+	"runtime"
+	"runtime/debug"
+	"sort"
+
+	"github.com/JasonPodgorny/terraformDecodeTest/pkg/decodecheck"
+)
+
+// version is the release of the binary, set when building releases with
+// -ldflags "-X main.version=v0.2".   Otherwise the module version go install
+// recorded is used, if any.
+var version = "dev"
+
+// buildInfo describes the binary for -version, so wrapper scripts can check
+// what a deployed decodeTest supports rather than parsing its help.
+type buildInfo struct {
+	Version        string            `json:"version"`
+	Commit         string            `json:"commit,omitempty"`
+	CommitTime     string            `json:"commitTime,omitempty"`
+	Modified       bool              `json:"modified,omitempty"`
+	GoVersion      string            `json:"goVersion"`
+	Platform       string            `json:"platform"`
+	Dependencies   map[string]string `json:"dependencies"`
+	Decoders       []string          `json:"decoders"`
+	OutputFormats  []string          `json:"outputFormats"`
+	LogFormats     []string          `json:"logFormats"`
+	ConvertFormats []string          `json:"convertFormats"`
+	Flags          []string          `json:"flags"`
+}
+
+// reportedDependencies are the modules whose versions decide what decodes, by
+// the short names -version reports them under.
+var reportedDependencies = map[string]string{
+	"github.com/zclconf/go-cty":      "go-cty",
+	"github.com/zclconf/go-cty-yaml": "go-cty-yaml",
+	"go.yaml.in/yaml/v3":             "yaml",
+}
+
+// getBuildInfo returns the version, commit and dependency versions of the
+// binary from what the Go toolchain recorded in it, along with the decoders,
+// formats and flags it supports.
+func getBuildInfo(opts decodecheck.Options) buildInfo {
+	info := buildInfo{
+		Version:        version,
+		GoVersion:      runtime.Version(),
+		Platform:       runtime.GOOS + "/" + runtime.GOARCH,
+		Dependencies:   map[string]string{},
+		Decoders:       decodecheck.DecoderNames(opts.Decoders),
+		OutputFormats:  []string{"text", "json"},
+		LogFormats:     []string{"text", "json"},
+		ConvertFormats: decodecheck.ConvertFormats,
+	}
+	flag.VisitAll(func(f *flag.Flag) {
+		info.Flags = append(info.Flags, f.Name)
+	})
+	sort.Strings(info.Flags)
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	if info.Version == "dev" && build.Main.Version != "" && build.Main.Version != "(devel)" {
+		info.Version = build.Main.Version
+	}
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Commit = setting.Value
+		case "vcs.time":
+			info.CommitTime = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	for _, dep := range build.Deps {
+		if name, ok := reportedDependencies[dep.Path]; ok {
+			if dep.Replace != nil {
+				dep = dep.Replace
+			}
+			info.Dependencies[name] = dep.Version
+		}
+	}
+	return info
+}